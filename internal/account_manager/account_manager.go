@@ -1,12 +1,16 @@
 package account_manager
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"insadem/multi_roblox_macos/internal/config_store"
 	"insadem/multi_roblox_macos/internal/logger"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"insadem/multi_roblox_macos/internal/secret_store"
+	"insadem/multi_roblox_macos/internal/state_db"
 	"strings"
 )
 
@@ -14,223 +18,389 @@ import (
 type Account struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
-	Label    string `json:"label"` // e.g., "Main Account", "Alt 1"
+	Label    string `json:"label"`            // e.g., "Main Account", "Alt 1"
+	UserID   int64  `json:"userId,omitempty"` // Roblox user ID; stable across username changes
+
+	// UserIDLookedUp is set once the user was offered binding by username,
+	// so the guess is never repeated
+	UserIDLookedUp bool `json:"userIdLookedUp,omitempty"`
+
+	// LegacyID is the old sequential ID while its data is being re-keyed
+	LegacyID string `json:"legacyId,omitempty"`
+}
+
+// accountsSchemaVersion is the current accounts.json format. Version 1 was
+// a bare JSON array with sequential "account_N" IDs.
+const accountsSchemaVersion = 2
+
+// accountsDocument is the versioned accounts.json envelope
+type accountsDocument struct {
+	Version  int       `json:"version"`
+	Accounts []Account `json:"accounts"`
 }
 
 const (
-	keychainService = "multi-roblox-manager"
+	passwordService = "multi-roblox-manager" // secret store service for account passwords
 	accountsFile    = "accounts.json"
 )
 
-// GetAccountsPath returns the path to the accounts file
-func GetAccountsPath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, "Library", "Application Support", "multi_roblox_macos", accountsFile)
+func init() {
+	state_db.RegisterJSONSource(state_db.JSONSource{
+		File: accountsFile,
+		Import: func(tx *sql.Tx, data []byte) error {
+			var doc accountsDocument
+			if err := json.Unmarshal(data, &doc); err != nil {
+				return err
+			}
+			if doc.Version > accountsSchemaVersion {
+				return fmt.Errorf("accounts.json version %d is newer than this app supports (%d)", doc.Version, accountsSchemaVersion)
+			}
+			return replaceAccounts(tx, doc.Accounts)
+		},
+		Export: func(db *sql.DB) ([]byte, error) {
+			accounts, err := queryAccounts(db, "")
+			if err != nil {
+				return nil, err
+			}
+			return json.MarshalIndent(accountsDocument{Version: accountsSchemaVersion, Accounts: accounts}, "", "  ")
+		},
+	})
 }
 
-// LoadAccounts loads all accounts from disk
-func LoadAccounts() ([]Account, error) {
-	path := GetAccountsPath()
+// UnmarshalJSON also reads version 1 files, which are a bare array
+func (d *accountsDocument) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		d.Version = 1
+		return json.Unmarshal(trimmed, &d.Accounts)
+	}
+	type plain accountsDocument
+	return json.Unmarshal(data, (*plain)(d))
+}
 
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+// GetAccountsPath returns the path to the accounts JSON file, which is
+// imported into the state database if present
+func GetAccountsPath() string {
+	return config_store.Path(accountsFile)
+}
+
+// queryAccounts returns the accounts matching where (all if empty) in the
+// order they were added
+func queryAccounts(q state_db.Querier, where string, args ...any) ([]Account, error) {
+	query := "SELECT id, username, label, user_id, user_id_looked_up, legacy_id FROM accounts"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	rows, err := q.Query(query+" ORDER BY seq", args...)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	// If file doesn't exist, return empty list
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return []Account{}, nil
+	accounts := []Account{}
+	for rows.Next() {
+		var acc Account
+		if err := rows.Scan(&acc.ID, &acc.Username, &acc.Label, &acc.UserID, &acc.UserIDLookedUp, &acc.LegacyID); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
 	}
+	return accounts, rows.Err()
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+func replaceAccounts(tx *sql.Tx, accounts []Account) error {
+	if _, err := tx.Exec("DELETE FROM accounts"); err != nil {
+		return err
+	}
+	for _, acc := range accounts {
+		_, err := tx.Exec("INSERT INTO accounts (id, username, label, user_id, user_id_looked_up, legacy_id) VALUES (?, ?, ?, ?, ?, ?)",
+			acc.ID, acc.Username, acc.Label, acc.UserID, acc.UserIDLookedUp, acc.LegacyID)
+		if err != nil {
+			return fmt.Errorf("failed to save account %s: %w", acc.Username, err)
+		}
 	}
+	return nil
+}
 
-	var accounts []Account
-	if err := json.Unmarshal(data, &accounts); err != nil {
+// LoadAccounts loads all accounts
+func LoadAccounts() ([]Account, error) {
+	db, err := state_db.Default()
+	if err != nil {
 		return nil, err
 	}
-
-	return accounts, nil
+	return queryAccounts(db, "")
 }
 
-// SaveAccounts saves accounts to disk
+// SaveAccounts replaces all accounts
 func SaveAccounts(accounts []Account) error {
-	path := GetAccountsPath()
+	return state_db.Update(func(tx *sql.Tx) error {
+		return replaceAccounts(tx, accounts)
+	})
+}
 
-	data, err := json.MarshalIndent(accounts, "", "  ")
-	if err != nil {
-		return err
-	}
+// updateAccounts applies fn to the saved accounts and saves the result in
+// one transaction
+func updateAccounts(fn func(accounts *[]Account) error) error {
+	return state_db.Update(func(tx *sql.Tx) error {
+		accounts, err := queryAccounts(tx, "")
+		if err != nil {
+			return err
+		}
+		if err := fn(&accounts); err != nil {
+			return err
+		}
+		return replaceAccounts(tx, accounts)
+	})
+}
 
-	return os.WriteFile(path, data, 0600)
+// updateAccount applies fn to the account with accountID and saves it
+func updateAccount(accountID string, fn func(acc *Account) error) error {
+	return updateAccounts(func(accounts *[]Account) error {
+		for i := range *accounts {
+			if (*accounts)[i].ID == accountID {
+				return fn(&(*accounts)[i])
+			}
+		}
+		return fmt.Errorf("account not found")
+	})
 }
 
 // AddAccount adds a new account with secure password storage
 func AddAccount(username, password, label string) error {
 	logger.LogInfo("AddAccount called for username: %s, label: %s", username, label)
 
-	accounts, err := LoadAccounts()
-	if err != nil {
-		logger.LogError("Failed to load accounts: %v", err)
-		return err
+	// Resolve the Roblox user ID so the account survives username changes.
+	// A network failure shouldn't block adding; the ID is filled in later.
+	var userID int64
+	user, err := roblox_api.LookupUserByUsername(username)
+	if errors.Is(err, roblox_api.ErrUserNotFound) {
+		return fmt.Errorf("roblox user %q does not exist", username)
+	} else if err != nil {
+		logger.LogError("Failed to resolve user ID for %s: %v", username, err)
+	} else {
+		userID = user.UserID
+		username = user.Username
 	}
 
-	// Generate unique ID
-	id := fmt.Sprintf("account_%d", len(accounts)+1)
-	logger.LogDebug("Generated account ID: %s", id)
-
-	// Store password in macOS Keychain
-	if err := storePasswordInKeychain(id, password); err != nil {
-		logger.LogError("Failed to store password in keychain for %s: %v", username, err)
-		return fmt.Errorf("failed to store password in keychain: %w", err)
-	}
-	logger.LogDebug("Password stored in keychain for account ID: %s", id)
+	var id string
+	err = updateAccounts(func(accounts *[]Account) error {
+		for _, acc := range *accounts {
+			if userID != 0 && acc.UserID == userID {
+				return fmt.Errorf("account %s already exists", acc.Username)
+			}
+		}
 
-	// Add account metadata (no password stored here)
-	account := Account{
-		ID:       id,
-		Username: username,
-		Label:    label,
+		// Generate unique ID
+		var err error
+		id, err = newAccountID()
+		if err != nil {
+			return fmt.Errorf("failed to generate account ID: %w", err)
+		}
+		logger.LogDebug("Generated account ID: %s", id)
+
+		// Add account metadata (no password stored here)
+		*accounts = append(*accounts, Account{
+			ID:       id,
+			Username: username,
+			Label:    label,
+			UserID:   userID,
+		})
+		return nil
+	})
+	if err != nil {
+		logger.LogError("Failed to add account %s: %v", username, err)
+		return err
 	}
 
-	accounts = append(accounts, account)
-	if err := SaveAccounts(accounts); err != nil {
-		logger.LogError("Failed to save accounts: %v", err)
+	// The password is stored once the account is saved, so a failed save
+	// can't leave it behind. If storing fails, the account is removed again.
+	if err := storePassword(id, password); err != nil {
+		logger.LogError("Failed to store password for %s: %v", username, err)
+		if _, rollbackErr := removeAccount(id); rollbackErr != nil {
+			logger.LogError("Failed to remove account %s after the password wasn't stored: %v", username, rollbackErr)
+		}
 		return err
 	}
+	logger.LogDebug("Password stored for account ID: %s", id)
 
-	logger.LogInfo("Account added successfully: %s (ID: %s)", username, id)
+	logger.LogInfo("Account added successfully: %s (ID: %s, user ID: %d)", username, id, userID)
 	return nil
 }
 
-// GetPassword retrieves password from macOS Keychain
+// GetPassword retrieves password from the secret store
 func GetPassword(accountID string) (string, error) {
-	cmd := exec.Command("security", "find-generic-password",
-		"-s", keychainService,
-		"-a", accountID,
-		"-w")
-
-	output, err := cmd.Output()
+	password, err := secret_store.Default().Get(passwordService, accountID)
 	if err != nil {
-		return "", fmt.Errorf("password not found in keychain")
+		return "", fmt.Errorf("password not found: %w", err)
 	}
 
-	return strings.TrimSpace(string(output)), nil
+	return strings.TrimSpace(password), nil
 }
 
-// storePasswordInKeychain stores password securely in macOS Keychain with enhanced security
-func storePasswordInKeychain(accountID, password string) error {
-	// First, try to delete existing entry (ignore errors)
-	exec.Command("security", "delete-generic-password",
-		"-s", keychainService,
-		"-a", accountID).Run()
-
-	// Add new entry with enhanced security flags
-	cmd := exec.Command("security", "add-generic-password",
-		"-s", keychainService,
-		"-a", accountID,
-		"-w", password,
-		"-T", "", // Trusted applications (empty = only this app)
-		"-U") // Update if exists
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to store password: %w (output: %s)", err, string(output))
+// storePassword stores password in the secret store, replacing any existing entry
+func storePassword(accountID, password string) error {
+	if err := secret_store.Default().Set(passwordService, accountID, password); err != nil {
+		return fmt.Errorf("failed to store password: %w", err)
 	}
 
-	// Clear password from memory
-	password = ""
-
 	return nil
 }
 
-// DeleteAccount removes account and its password from keychain
-func DeleteAccount(accountID string) error {
+// DeleteAccount removes an account, its password and its entries in the
+// other secret store services that hold per-account data (e.g.
+// cookie_manager.SecretServices())
+func DeleteAccount(accountID string, services ...string) error {
 	logger.LogInfo("DeleteAccount called for ID: %s", accountID)
 
-	accounts, err := LoadAccounts()
+	deletedUsername, err := removeAccount(accountID)
 	if err != nil {
-		logger.LogError("Failed to load accounts: %v", err)
+		logger.LogError("Failed to save accounts after deletion: %v", err)
 		return err
 	}
 
-	// Find the account being deleted for logging
-	var deletedUsername string
-	for _, acc := range accounts {
-		if acc.ID == accountID {
-			deletedUsername = acc.Username
-			break
+	// Secrets go once the account is gone, so a failed save keeps them
+	for _, service := range append([]string{passwordService}, services...) {
+		if err := secret_store.Default().Delete(service, accountID); err != nil {
+			logger.LogDebug("Deleting %s entry returned: %v (may not exist)", service, err)
+		} else {
+			logger.LogDebug("Removed %s entry for ID: %s", service, accountID)
 		}
 	}
 
-	// Remove from accounts list
-	newAccounts := []Account{}
-	for _, acc := range accounts {
-		if acc.ID != accountID {
+	logger.LogInfo("Account deleted successfully: %s (ID: %s)", deletedUsername, accountID)
+	return nil
+}
+
+// removeAccount removes an account's metadata, leaving its secrets, and
+// returns its username
+func removeAccount(accountID string) (string, error) {
+	var username string
+	err := updateAccounts(func(accounts *[]Account) error {
+		newAccounts := []Account{}
+		for _, acc := range *accounts {
+			if acc.ID == accountID {
+				username = acc.Username
+				continue
+			}
 			newAccounts = append(newAccounts, acc)
 		}
+		*accounts = newAccounts
+		return nil
+	})
+	return username, err
+}
+
+// GetAccount finds an account by ID
+func GetAccount(accountID string) (*Account, error) {
+	db, err := state_db.Default()
+	if err != nil {
+		return nil, err
 	}
 
-	// Delete password from keychain
-	if err := exec.Command("security", "delete-generic-password",
-		"-s", keychainService,
-		"-a", accountID).Run(); err != nil {
-		logger.LogDebug("Keychain entry deletion returned: %v (may not exist)", err)
-	} else {
-		logger.LogDebug("Password removed from keychain for ID: %s", accountID)
+	accounts, err := queryAccounts(db, "id = ?", accountID)
+	if err != nil {
+		return nil, err
 	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("account not found")
+	}
+	return &accounts[0], nil
+}
 
-	if err := SaveAccounts(newAccounts); err != nil {
-		logger.LogError("Failed to save accounts after deletion: %v", err)
+// UpdateAccountLabel updates the label for an account
+func UpdateAccountLabel(accountID, newLabel string) error {
+	logger.LogInfo("UpdateAccountLabel called for ID: %s, new label: %s", accountID, newLabel)
+
+	var oldLabel string
+	err := updateAccount(accountID, func(acc *Account) error {
+		oldLabel = acc.Label
+		acc.Label = newLabel
+		return nil
+	})
+	if err != nil {
+		logger.LogError("Failed to update label for account %s: %v", accountID, err)
 		return err
 	}
 
-	logger.LogInfo("Account deleted successfully: %s (ID: %s)", deletedUsername, accountID)
+	logger.LogInfo("Account label updated: %s -> %s (ID: %s)", oldLabel, newLabel, accountID)
 	return nil
 }
 
-// GetAccount finds an account by ID
-func GetAccount(accountID string) (*Account, error) {
-	accounts, err := LoadAccounts()
+// FindAccountByUserID finds the account bound to a Roblox user ID
+func FindAccountByUserID(userID int64) (*Account, error) {
+	if userID <= 0 {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+
+	db, err := state_db.Default()
 	if err != nil {
 		return nil, err
 	}
 
-	for _, acc := range accounts {
-		if acc.ID == accountID {
-			return &acc, nil
-		}
+	accounts, err := queryAccounts(db, "user_id = ?", userID)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, fmt.Errorf("account not found")
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no account for user ID %d", userID)
+	}
+	return &accounts[0], nil
 }
 
-// UpdateAccountLabel updates the label for an account
-func UpdateAccountLabel(accountID, newLabel string) error {
-	logger.LogInfo("UpdateAccountLabel called for ID: %s, new label: %s", accountID, newLabel)
+// SyncAccountIdentity records what Roblox reports for an account's user:
+// it binds the user ID if the account has none yet and follows username
+// changes. It refuses to rebind an account to a different user ID.
+func SyncAccountIdentity(accountID string, userID int64, username string) error {
+	return updateAccount(accountID, func(acc *Account) error {
+		if acc.UserID != 0 && acc.UserID != userID {
+			return fmt.Errorf("account %s belongs to user ID %d, not %d", acc.Username, acc.UserID, userID)
+		}
+		if acc.UserID == userID && acc.Username == username {
+			return nil
+		}
 
+		if acc.UserID == 0 {
+			logger.LogInfo("Bound account %s to user ID %d", acc.Username, userID)
+		}
+		if username != "" && acc.Username != username {
+			logger.LogInfo("Username changed upstream: %s -> %s (user ID %d)", acc.Username, username, userID)
+			acc.Username = username
+		}
+		acc.UserID = userID
+		return nil
+	})
+}
+
+// PendingUserIDLookups returns the accounts saved before user IDs were
+// tracked that have no saved cookie to bind them, and weren't offered a
+// lookup by username yet. Accounts with a cookie are bound when it's
+// validated.
+func PendingUserIDLookups(hasCookie func(accountID string) bool) ([]Account, error) {
 	accounts, err := LoadAccounts()
 	if err != nil {
-		logger.LogError("Failed to load accounts: %v", err)
-		return err
+		return nil, err
 	}
-
-	for i := range accounts {
-		if accounts[i].ID == accountID {
-			oldLabel := accounts[i].Label
-			accounts[i].Label = newLabel
-			if err := SaveAccounts(accounts); err != nil {
-				logger.LogError("Failed to save accounts after label update: %v", err)
-				return err
-			}
-			logger.LogInfo("Account label updated: %s -> %s (ID: %s)", oldLabel, newLabel, accountID)
-			return nil
+	var pending []Account
+	for _, acc := range accounts {
+		if acc.UserID == 0 && !acc.UserIDLookedUp && !hasCookie(acc.ID) {
+			pending = append(pending, acc)
 		}
 	}
+	return pending, nil
+}
 
-	logger.LogError("Account not found for ID: %s", accountID)
-	return fmt.Errorf("account not found")
+// LookupUserID finds the Roblox user currently holding an account's
+// username. Usernames change hands, so this is only a guess for the user to
+// confirm before binding it with SyncAccountIdentity. The lookup is marked
+// done first, so it happens at most once per account.
+func LookupUserID(accountID string) (*roblox_api.UserInfo, error) {
+	var username string
+	err := updateAccount(accountID, func(acc *Account) error {
+		username = acc.Username
+		acc.UserIDLookedUp = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roblox_api.LookupUserByUsername(username)
 }
@@ -0,0 +1,225 @@
+package account_manager
+
+import (
+	"errors"
+	"insadem/multi_roblox_macos/internal/fake_roblox"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"insadem/multi_roblox_macos/internal/secret_store"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddAccountStoresPassword(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 156, Name: "builderman"})
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+
+	if err := AddAccount("builderman", "p4ssword", "Main"); err != nil {
+		t.Fatalf("AddAccount: %v", err)
+	}
+
+	accounts, err := LoadAccounts()
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("LoadAccounts: got %d accounts, %v", len(accounts), err)
+	}
+
+	if accounts[0].UserID != 156 {
+		t.Fatalf("AddAccount didn't resolve the user ID: %+v", accounts[0])
+	}
+	if err := AddAccount("BUILDERMAN", "x", ""); err == nil {
+		t.Fatalf("AddAccount accepted a duplicate user")
+	}
+	if err := AddAccount("nobody", "x", ""); err == nil {
+		t.Fatalf("AddAccount accepted a nonexistent user")
+	}
+
+	password, err := GetPassword(accounts[0].ID)
+	if err != nil || password != "p4ssword" {
+		t.Fatalf("GetPassword: got %q, %v", password, err)
+	}
+
+	secret_store.Default().Set("cookies", accounts[0].ID, "cookie")
+	if err := DeleteAccount(accounts[0].ID, "cookies"); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	if _, err := GetPassword(accounts[0].ID); err == nil {
+		t.Fatalf("password still present after DeleteAccount")
+	}
+	if _, err := secret_store.Default().Get("cookies", accounts[0].ID); !errors.Is(err, secret_store.ErrNotFound) {
+		t.Fatalf("cookie still present after DeleteAccount: %v", err)
+	}
+}
+
+// failingStore refuses to store anything
+type failingStore struct {
+	secret_store.SecretStore
+}
+
+func (failingStore) Set(service, account, secret string) error {
+	return errors.New("keychain locked")
+}
+
+func TestAddAccountRollsBackWithoutPassword(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(failingStore{secret_store.NewMemoryStore()})
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 156, Name: "builderman"})
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+
+	if err := AddAccount("builderman", "p4ssword", ""); err == nil {
+		t.Fatalf("AddAccount succeeded without storing the password")
+	}
+	if accounts, err := LoadAccounts(); err != nil || len(accounts) != 0 {
+		t.Fatalf("LoadAccounts = %+v, %v; want the account removed", accounts, err)
+	}
+}
+
+func TestMigrateAccountIDs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store := secret_store.NewMemoryStore()
+	secret_store.SetDefault(store)
+
+	// A version 1 file with a collision left behind by delete-then-add
+	legacy := `[
+  {"id": "account_1", "username": "first", "label": ""},
+  {"id": "account_2", "username": "stale", "label": ""},
+  {"id": "account_2", "username": "second", "label": ""}
+]`
+	if err := os.MkdirAll(filepath.Dir(GetAccountsPath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(GetAccountsPath(), []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store.Set(passwordService, "account_1", "pw1")
+	store.Set(passwordService, "account_2", "pw2")
+
+	moved := map[string]string{}
+	rekey := func(oldID, newID string) error {
+		moved[oldID] = newID
+		return nil
+	}
+
+	if err := MigrateAccountIDs(rekey); err != nil {
+		t.Fatalf("MigrateAccountIDs: %v", err)
+	}
+
+	accounts, err := LoadAccounts()
+	if err != nil || len(accounts) != 3 {
+		t.Fatalf("LoadAccounts: got %d accounts, %v", len(accounts), err)
+	}
+	seen := map[string]bool{}
+	for _, acc := range accounts {
+		if legacyIDPattern.MatchString(acc.ID) || acc.LegacyID != "" || seen[acc.ID] {
+			t.Fatalf("account not migrated: %+v", acc)
+		}
+		seen[acc.ID] = true
+	}
+
+	if pw, _ := GetPassword(accounts[0].ID); pw != "pw1" {
+		t.Fatalf("first password = %q", pw)
+	}
+	if pw, _ := GetPassword(accounts[2].ID); pw != "pw2" {
+		t.Fatalf("second password = %q", pw)
+	}
+	if moved["account_1"] != accounts[0].ID || moved["account_2"] != accounts[2].ID {
+		t.Fatalf("rekeyers called with %v", moved)
+	}
+
+	// The file was imported into the state database and set aside
+	if _, err := os.Stat(GetAccountsPath()); !os.IsNotExist(err) {
+		t.Fatalf("accounts.json still in place after import: %v", err)
+	}
+	if _, err := os.Stat(GetAccountsPath() + ".imported"); err != nil {
+		t.Fatalf("accounts.json backup missing: %v", err)
+	}
+
+	// Running again is a no-op
+	if err := MigrateAccountIDs(func(string, string) error {
+		t.Fatalf("rekeyer called on migrated data")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateAccountIDsResumes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	if err := SaveAccounts([]Account{{ID: "account_1", Username: "first"}}); err != nil {
+		t.Fatal(err)
+	}
+	storePassword("account_1", "pw1")
+
+	failing := func(string, string) error { return errors.New("disk full") }
+	if err := MigrateAccountIDs(failing); err == nil {
+		t.Fatalf("MigrateAccountIDs ignored a rekeyer error")
+	}
+
+	interrupted, _ := LoadAccounts()
+	if interrupted[0].LegacyID != "account_1" {
+		t.Fatalf("pending migration not recorded: %+v", interrupted[0])
+	}
+
+	var got [2]string
+	if err := MigrateAccountIDs(func(oldID, newID string) error {
+		got = [2]string{oldID, newID}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts, _ := LoadAccounts()
+	if got != [2]string{"account_1", interrupted[0].ID} || accounts[0].ID != interrupted[0].ID {
+		t.Fatalf("resumed with %v, accounts %+v", got, accounts)
+	}
+	if pw, _ := GetPassword(accounts[0].ID); pw != "pw1" {
+		t.Fatalf("password = %q", pw)
+	}
+}
+
+func TestUserIDLookupIsOfferedOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 156, Name: "builderman"})
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+
+	if err := SaveAccounts([]Account{
+		{ID: "a", Username: "builderman"},
+		{ID: "b", Username: "withcookie"},
+		{ID: "c", Username: "bound", UserID: 7},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	hasCookie := func(accountID string) bool { return accountID == "b" }
+
+	pending, err := PendingUserIDLookups(hasCookie)
+	if err != nil || len(pending) != 1 || pending[0].ID != "a" {
+		t.Fatalf("PendingUserIDLookups = %+v, %v", pending, err)
+	}
+
+	user, err := LookupUserID("a")
+	if err != nil || user.UserID != 156 {
+		t.Fatalf("LookupUserID = %+v, %v", user, err)
+	}
+	// Looking up doesn't bind; that waits for the user
+	if acc, _ := GetAccount("a"); acc.UserID != 0 {
+		t.Fatalf("lookup bound the account: %+v", acc)
+	}
+	if pending, _ := PendingUserIDLookups(hasCookie); len(pending) != 0 {
+		t.Fatalf("lookup offered again: %+v", pending)
+	}
+}
@@ -0,0 +1,112 @@
+package account_manager
+
+import (
+	"crypto/rand"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/secret_store"
+	"regexp"
+)
+
+// legacyIDPattern matches the sequential IDs used before accounts.json was
+// versioned. They were derived from the list length, so deleting an account
+// and adding another could reuse an ID that still had data attached.
+var legacyIDPattern = regexp.MustCompile(`^account_\d+$`)
+
+// Rekeyer moves data stored under an old account ID to a new one. It must be
+// safe to call again if an earlier migration attempt was interrupted.
+type Rekeyer func(oldID, newID string) error
+
+// newAccountID returns a random (version 4) UUID
+func newAccountID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// MigrateAccountIDs replaces sequential account IDs with random ones and
+// re-keys the passwords plus anything handled by the given rekeyers.
+//
+// The old-to-new mapping is saved before any data is moved, so if the app
+// quits halfway the next run finishes the same mapping instead of losing
+// track of where the data went.
+func MigrateAccountIDs(rekeyers ...Rekeyer) error {
+	accounts, err := LoadAccounts()
+	if err != nil {
+		return err
+	}
+
+	// Assign new IDs. A duplicated legacy ID can only have one owner of its
+	// data; the last entry wins since it overwrote the others' secrets.
+	lastIndex := make(map[string]int)
+	for i, acc := range accounts {
+		if acc.LegacyID == "" && legacyIDPattern.MatchString(acc.ID) {
+			lastIndex[acc.ID] = i
+		}
+	}
+
+	assigned := false
+	for i := range accounts {
+		acc := &accounts[i]
+		if acc.LegacyID != "" || !legacyIDPattern.MatchString(acc.ID) {
+			continue
+		}
+
+		newID, err := newAccountID()
+		if err != nil {
+			return fmt.Errorf("failed to generate account ID: %w", err)
+		}
+		if lastIndex[acc.ID] == i {
+			acc.LegacyID = acc.ID
+		} else {
+			logger.LogError("Account %s shares ID %s with another account; its saved data can't be recovered", acc.Username, acc.ID)
+		}
+		acc.ID = newID
+		assigned = true
+	}
+
+	pending := false
+	for _, acc := range accounts {
+		if acc.LegacyID != "" {
+			pending = true
+			break
+		}
+	}
+	if !assigned && !pending {
+		return nil
+	}
+
+	// Commit the mapping before touching any data
+	if err := SaveAccounts(accounts); err != nil {
+		return fmt.Errorf("failed to save new account IDs: %w", err)
+	}
+
+	for i := range accounts {
+		acc := &accounts[i]
+		if acc.LegacyID == "" {
+			continue
+		}
+
+		logger.LogInfo("Migrating account %s: %s -> %s", acc.Username, acc.LegacyID, acc.ID)
+		if err := secret_store.Rename(secret_store.Default(), passwordService, acc.LegacyID, acc.ID); err != nil {
+			return fmt.Errorf("failed to move password for %s: %w", acc.Username, err)
+		}
+		for _, rekey := range rekeyers {
+			if err := rekey(acc.LegacyID, acc.ID); err != nil {
+				return fmt.Errorf("failed to migrate data for %s: %w", acc.Username, err)
+			}
+		}
+		acc.LegacyID = ""
+	}
+
+	if err := SaveAccounts(accounts); err != nil {
+		return fmt.Errorf("failed to save migrated accounts: %w", err)
+	}
+
+	logger.LogInfo("Account ID migration complete")
+	return nil
+}
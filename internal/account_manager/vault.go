@@ -0,0 +1,472 @@
+package account_manager
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/secret_store"
+	"insadem/multi_roblox_macos/internal/state_db"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	vaultFormat  = "multi_roblox_vault"
+	vaultVersion = 1
+	vaultCipher  = "AES-256-GCM"
+)
+
+// vaultFiles are the app files bundled with the accounts. Their data is in
+// the state database; the vault carries it in the files' JSON format.
+var vaultFiles = []string{"presets.json", "friends.json", "labels.json"}
+
+// scrypt cost parameters for new exports. Imports use what the file records
+// but refuse anything costlier, so a crafted file can't exhaust memory.
+var (
+	vaultScryptN = 1 << 15
+	vaultScryptR = 8
+	vaultScryptP = 1
+)
+
+// ErrWrongPassphrase is returned when a vault can't be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted vault")
+
+// vaultEnvelope is the on-disk vault. Only the parameters needed to decrypt
+// it are stored in the clear.
+type vaultEnvelope struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	KDF        vaultKDF  `json:"kdf"`
+	Cipher     string    `json:"cipher"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type vaultKDF struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// Vault is the decrypted contents of an exported archive
+type Vault struct {
+	CreatedAt time.Time                    `json:"createdAt"`
+	Accounts  []Account                    `json:"accounts"`
+	Secrets   map[string]map[string]string `json:"secrets"` // account ID -> service -> secret
+	Files     map[string][]byte            `json:"files"`   // file name -> contents
+}
+
+// ExportVault bundles the accounts, their passwords, the secrets stored under
+// secretServices and the app's other files into an archive encrypted with
+// passphrase.
+func ExportVault(passphrase string, secretServices []string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+
+	accounts, err := LoadAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	vault := Vault{
+		CreatedAt: time.Now().UTC(),
+		Accounts:  accounts,
+		Secrets:   make(map[string]map[string]string),
+		Files:     make(map[string][]byte),
+	}
+
+	store := secret_store.Default()
+	for _, acc := range accounts {
+		for _, service := range vaultServices(secretServices) {
+			secret, err := store.Get(service, acc.ID)
+			if errors.Is(err, secret_store.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s secret for %s: %w", service, acc.Username, err)
+			}
+			if vault.Secrets[acc.ID] == nil {
+				vault.Secrets[acc.ID] = make(map[string]string)
+			}
+			vault.Secrets[acc.ID][service] = secret
+		}
+	}
+
+	for _, name := range vaultFiles {
+		data, err := state_db.ExportJSON(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		vault.Files[name] = data
+	}
+
+	plaintext, err := json.Marshal(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	env := vaultEnvelope{
+		Format:    vaultFormat,
+		Version:   vaultVersion,
+		KDF:       vaultKDF{Name: "scrypt", Salt: make([]byte, 16), N: vaultScryptN, R: vaultScryptR, P: vaultScryptP},
+		Cipher:    vaultCipher,
+		CreatedAt: vault.CreatedAt,
+	}
+	if _, err := rand.Read(env.KDF.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := vaultAEAD(passphrase, env.KDF)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plaintext, vaultAdditionalData(env))
+
+	logger.LogInfo("Exported vault with %d accounts and %d files", len(accounts), len(vault.Files))
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// OpenVault decrypts an archive made by ExportVault
+func OpenVault(data []byte, passphrase string) (*Vault, error) {
+	var env vaultEnvelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Format != vaultFormat {
+		return nil, fmt.Errorf("not a vault file")
+	}
+	if env.Version > vaultVersion {
+		return nil, fmt.Errorf("vault version %d is newer than this app supports (%d)", env.Version, vaultVersion)
+	}
+	if env.Cipher != vaultCipher || env.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported vault encryption %s/%s", env.KDF.Name, env.Cipher)
+	}
+	if kdf := env.KDF; kdf.N > vaultScryptN || kdf.R > vaultScryptR || kdf.P > vaultScryptP {
+		return nil, fmt.Errorf("vault key derivation parameters (N=%d, r=%d, p=%d) exceed the supported limits", kdf.N, kdf.R, kdf.P)
+	}
+
+	aead, err := vaultAEAD(passphrase, env.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, vaultAdditionalData(env))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	var vault Vault
+	if err := json.Unmarshal(plaintext, &vault); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %w", err)
+	}
+	return &vault, nil
+}
+
+// vaultAEAD derives the key for a vault and returns its cipher
+func vaultAEAD(passphrase string, kdf vaultKDF) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), kdf.Salt, kdf.N, kdf.R, kdf.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// vaultAdditionalData authenticates the unencrypted envelope fields so they
+// can't be swapped without failing decryption
+func vaultAdditionalData(env vaultEnvelope) []byte {
+	header, _ := json.Marshal(struct {
+		Format    string
+		Version   int
+		KDF       vaultKDF
+		Cipher    string
+		CreatedAt time.Time
+	}{env.Format, env.Version, env.KDF, env.Cipher, env.CreatedAt})
+	return header
+}
+
+// vaultServices is passwordService plus the caller's services, deduplicated
+func vaultServices(extra []string) []string {
+	services := []string{passwordService}
+	for _, s := range extra {
+		if s != passwordService {
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+// ImportMode says what to do with one account from a vault
+type ImportMode int
+
+const (
+	ImportSkip    ImportMode = iota
+	ImportMerge              // Keep local values, fill in what's missing
+	ImportReplace            // Overwrite local values and secrets with the vault's
+)
+
+// AccountChange describes how a vault account relates to the local accounts
+type AccountChange struct {
+	Vault   Account
+	Local   *Account // nil if the account is new
+	Changes []string // Human-readable differences; empty if identical
+}
+
+// IsNew reports whether the account doesn't exist locally
+func (c AccountChange) IsNew() bool {
+	return c.Local == nil
+}
+
+// ImportPlan is the dry-run result of importing a vault
+type ImportPlan struct {
+	Accounts     []AccountChange
+	LocalOnly    []Account // Local accounts not in the vault; never touched
+	ChangedFiles []string  // Bundled files that differ from the local copy
+}
+
+// PlanImport compares a vault against the local data without changing anything
+func PlanImport(vault *Vault, secretServices []string) (*ImportPlan, error) {
+	local, err := LoadAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ImportPlan{}
+	matched := make(map[string]bool)
+	for _, acc := range vault.Accounts {
+		change := AccountChange{Vault: acc}
+		if i := matchLocalAccount(local, acc); i >= 0 {
+			localAcc := local[i]
+			change.Local = &localAcc
+			change.Changes = diffAccount(localAcc, acc, vault.Secrets[acc.ID], secretServices)
+			matched[localAcc.ID] = true
+		}
+		plan.Accounts = append(plan.Accounts, change)
+	}
+	for _, acc := range local {
+		if !matched[acc.ID] {
+			plan.LocalOnly = append(plan.LocalOnly, acc)
+		}
+	}
+
+	for _, name := range vaultFiles {
+		data, ok := vault.Files[name]
+		if !ok {
+			continue
+		}
+		current, err := state_db.ExportJSON(name)
+		if err != nil || !bytes.Equal(current, data) {
+			plan.ChangedFiles = append(plan.ChangedFiles, name)
+		}
+	}
+
+	return plan, nil
+}
+
+// matchLocalAccount finds the local account a vault account corresponds to:
+// by user ID, then by account ID, then by username
+func matchLocalAccount(local []Account, acc Account) int {
+	if acc.UserID != 0 {
+		for i := range local {
+			if local[i].UserID == acc.UserID {
+				return i
+			}
+		}
+	}
+	for i := range local {
+		if local[i].ID == acc.ID && (local[i].UserID == 0 || acc.UserID == 0) {
+			return i
+		}
+	}
+	for i := range local {
+		if (local[i].UserID == 0 || acc.UserID == 0) && strings.EqualFold(local[i].Username, acc.Username) {
+			return i
+		}
+	}
+	return -1
+}
+
+// diffAccount lists the differences between a local account and its vault copy
+func diffAccount(local, vaultAcc Account, secrets map[string]string, secretServices []string) []string {
+	var changes []string
+	if local.Username != vaultAcc.Username {
+		changes = append(changes, fmt.Sprintf("username %s -> %s", local.Username, vaultAcc.Username))
+	}
+	if local.Label != vaultAcc.Label {
+		changes = append(changes, fmt.Sprintf("label %q -> %q", local.Label, vaultAcc.Label))
+	}
+	if local.UserID != vaultAcc.UserID {
+		changes = append(changes, fmt.Sprintf("user ID %d -> %d", local.UserID, vaultAcc.UserID))
+	}
+
+	store := secret_store.Default()
+	for _, service := range vaultServices(secretServices) {
+		secret, ok := secrets[service]
+		current, err := store.Get(service, local.ID)
+		switch {
+		case ok && err != nil:
+			changes = append(changes, service+" added")
+		case ok && current != secret:
+			changes = append(changes, service+" differs")
+		case !ok && err == nil:
+			changes = append(changes, service+" only local")
+		}
+	}
+	return changes
+}
+
+// ImportOptions controls ApplyImport
+type ImportOptions struct {
+	Modes          map[string]ImportMode // Vault account ID -> mode; missing means skip
+	ReplaceFiles   bool                  // Overwrite local presets, friends and labels
+	SecretServices []string              // Secret services to restore besides passwords
+}
+
+// ApplyImport restores the selected accounts from a vault. Accounts that
+// already exist keep their local ID; rekeyers are then used to point restored
+// files at it, so they should only touch data that came from the vault.
+func ApplyImport(vault *Vault, opts ImportOptions, rekeyers ...Rekeyer) error {
+	local, err := LoadAccounts()
+	if err != nil {
+		return err
+	}
+
+	idMap := make(map[string]string) // vault ID -> local ID
+	var imported []Account           // Vault accounts whose secrets are restored
+
+	for _, acc := range vault.Accounts {
+		mode := opts.Modes[acc.ID]
+		if mode == ImportSkip {
+			continue
+		}
+
+		localID := acc.ID
+		i := matchLocalAccount(local, acc)
+		if i >= 0 {
+			localID = local[i].ID
+			if mode == ImportReplace {
+				local[i].Username = acc.Username
+				local[i].Label = acc.Label
+				local[i].UserID = acc.UserID
+			} else {
+				if local[i].Label == "" {
+					local[i].Label = acc.Label
+				}
+				if local[i].UserID == 0 {
+					local[i].UserID = acc.UserID
+				}
+			}
+		} else {
+			// Legacy IDs and IDs taken by another local account get a fresh one
+			if legacyIDPattern.MatchString(localID) || accountIndex(local, localID) >= 0 {
+				if localID, err = newAccountID(); err != nil {
+					return fmt.Errorf("failed to generate account ID: %w", err)
+				}
+			}
+			restored := acc
+			restored.ID = localID
+			restored.LegacyID = ""
+			local = append(local, restored)
+		}
+		idMap[acc.ID] = localID
+		imported = append(imported, acc)
+	}
+
+	// Secrets are restored once the accounts are saved, so a failed save
+	// doesn't leave secrets without an account
+	if err := SaveAccounts(local); err != nil {
+		return err
+	}
+	for _, acc := range imported {
+		if err := restoreSecrets(vault, acc, idMap[acc.ID], opts); err != nil {
+			return err
+		}
+		logger.LogInfo("Imported account %s as %s", acc.Username, idMap[acc.ID])
+	}
+
+	if !opts.ReplaceFiles {
+		return nil
+	}
+
+	for _, name := range vaultFiles {
+		data, ok := vault.Files[name]
+		if !ok {
+			continue
+		}
+		if err := state_db.ImportJSON(name, data); err != nil {
+			return err
+		}
+		logger.LogInfo("Restored %s from vault", name)
+	}
+
+	// Restored files refer to accounts by their vault IDs
+	for vaultID, localID := range idMap {
+		if vaultID == localID {
+			continue
+		}
+		for _, rekey := range rekeyers {
+			if err := rekey(vaultID, localID); err != nil {
+				return fmt.Errorf("failed to update references to %s: %w", vaultID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// restoreSecrets writes a vault account's secrets under localID. Merge keeps
+// the local secrets; Replace also deletes those the vault doesn't have.
+func restoreSecrets(vault *Vault, acc Account, localID string, opts ImportOptions) error {
+	store := secret_store.Default()
+	mode := opts.Modes[acc.ID]
+	for _, service := range vaultServices(opts.SecretServices) {
+		secret, ok := vault.Secrets[acc.ID][service]
+		if !ok {
+			if mode == ImportReplace {
+				if err := store.Delete(service, localID); err != nil && !errors.Is(err, secret_store.ErrNotFound) {
+					return fmt.Errorf("failed to remove %s secret for %s: %w", service, acc.Username, err)
+				}
+			}
+			continue
+		}
+		if mode == ImportMerge {
+			if _, err := store.Get(service, localID); err == nil {
+				continue
+			}
+		}
+		if err := store.Set(service, localID, secret); err != nil {
+			return fmt.Errorf("failed to restore %s secret for %s: %w", service, acc.Username, err)
+		}
+	}
+	return nil
+}
+
+// accountIndex returns the index of the account with the given ID, or -1
+func accountIndex(accounts []Account, id string) int {
+	for i := range accounts {
+		if accounts[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,152 @@
+package account_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"insadem/multi_roblox_macos/internal/secret_store"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVaultRoundTrip(t *testing.T) {
+	defer func(n int) { vaultScryptN = n }(vaultScryptN)
+	vaultScryptN = 1 << 10
+	t.Setenv("HOME", t.TempDir())
+	store := secret_store.NewMemoryStore()
+	secret_store.SetDefault(store)
+
+	services := []string{"cookie"}
+	if err := SaveAccounts([]Account{
+		{ID: "a", Username: "Main", Label: "Main", UserID: 1},
+		{ID: "b", Username: "Alt", UserID: 2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	storePassword("a", "pw-a")
+	store.Set("cookie", "a", "cookie-a")
+	store.Set("cookie", "b", "cookie-b")
+	presetsPath := filepath.Join(filepath.Dir(GetAccountsPath()), "presets.json")
+	os.WriteFile(presetsPath, []byte(`[{"last_account_used":"b"}]`), 0600)
+
+	archive, err := ExportVault("correct horse", services)
+	if err != nil {
+		t.Fatalf("ExportVault: %v", err)
+	}
+	if _, err := OpenVault(archive, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("OpenVault with wrong passphrase: %v", err)
+	}
+
+	// Move to a "new Mac" that already has Alt under another ID
+	t.Setenv("HOME", t.TempDir())
+	store = secret_store.NewMemoryStore()
+	secret_store.SetDefault(store)
+	if err := SaveAccounts([]Account{{ID: "local-b", Username: "Alt", Label: "mine", UserID: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	store.Set("cookie", "local-b", "newer-cookie")
+
+	vault, err := OpenVault(archive, "correct horse")
+	if err != nil {
+		t.Fatalf("OpenVault: %v", err)
+	}
+
+	plan, err := PlanImport(vault, services)
+	if err != nil || len(plan.Accounts) != 2 || len(plan.ChangedFiles) != 1 {
+		t.Fatalf("PlanImport = %+v, %v", plan, err)
+	}
+	if !plan.Accounts[0].IsNew() || plan.Accounts[1].IsNew() || len(plan.Accounts[1].Changes) == 0 {
+		t.Fatalf("PlanImport accounts = %+v", plan.Accounts)
+	}
+	if accounts, _ := LoadAccounts(); len(accounts) != 1 {
+		t.Fatalf("PlanImport changed local accounts")
+	}
+
+	var rekeyed [2]string
+	err = ApplyImport(vault, ImportOptions{
+		Modes:          map[string]ImportMode{"a": ImportReplace, "b": ImportMerge},
+		ReplaceFiles:   true,
+		SecretServices: services,
+	}, func(oldID, newID string) error {
+		rekeyed = [2]string{oldID, newID}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ApplyImport: %v", err)
+	}
+
+	accounts, _ := LoadAccounts()
+	if len(accounts) != 2 || accounts[0].Label != "mine" || accounts[1].ID != "a" {
+		t.Fatalf("accounts after import = %+v", accounts)
+	}
+	if pw, _ := GetPassword("a"); pw != "pw-a" {
+		t.Fatalf("password = %q", pw)
+	}
+	if c, _ := store.Get("cookie", "local-b"); c != "newer-cookie" {
+		t.Fatalf("merge overwrote the local cookie: %q", c)
+	}
+	if rekeyed != [2]string{"b", "local-b"} {
+		t.Fatalf("rekeyed = %v", rekeyed)
+	}
+	if data, _ := os.ReadFile(presetsPath); len(data) == 0 {
+		t.Fatalf("presets.json not restored")
+	}
+}
+
+func TestOpenVaultRejectsCostlyKDF(t *testing.T) {
+	defer func(n int) { vaultScryptN = n }(vaultScryptN)
+	vaultScryptN = 1 << 10
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	archive, err := ExportVault("correct horse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env vaultEnvelope
+	if err := json.Unmarshal(archive, &env); err != nil {
+		t.Fatal(err)
+	}
+
+	// Would need gigabytes of memory to derive
+	env.KDF.N = 1 << 30
+	crafted, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenVault(crafted, "correct horse"); err == nil || errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("OpenVault with N=2^30 = %v, want a parameter error", err)
+	}
+}
+
+func TestApplyImportReplaceRemovesMissingSecrets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store := secret_store.NewMemoryStore()
+	secret_store.SetDefault(store)
+
+	if err := SaveAccounts([]Account{{ID: "local-b", Username: "Alt", UserID: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	storePassword("local-b", "old-pw")
+	store.Set("cookie", "local-b", "old-cookie")
+
+	// The vault has a cookie for Alt but no password
+	vault := &Vault{
+		Accounts: []Account{{ID: "b", Username: "Alt", UserID: 2}},
+		Secrets:  map[string]map[string]string{"b": {"cookie": "vault-cookie"}},
+	}
+	err := ApplyImport(vault, ImportOptions{
+		Modes:          map[string]ImportMode{"b": ImportReplace},
+		SecretServices: []string{"cookie"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyImport: %v", err)
+	}
+
+	if _, err := GetPassword("local-b"); err == nil {
+		t.Fatalf("password not in the vault was kept")
+	}
+	if c, _ := store.Get("cookie", "local-b"); c != "vault-cookie" {
+		t.Fatalf("cookie = %q, want the vault's", c)
+	}
+}
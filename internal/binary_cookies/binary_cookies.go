@@ -0,0 +1,409 @@
+// binary_cookies reads and writes Apple's Cookies.binarycookies format, used
+// by Safari and by apps built on NSHTTPCookieStorage such as RobloxPlayer.
+//
+// File layout (page sizes and file header are big-endian, everything inside
+// a page is little-endian):
+//
+//	"cook" | page count | page sizes... | pages... | checksum | trailer
+//
+// The trailer (footer magic plus an optional binary plist) is kept verbatim
+// so files written by macOS survive a decode/encode round trip.
+package binary_cookies
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cookie flags
+const (
+	FlagSecure   uint32 = 1
+	FlagHTTPOnly uint32 = 4
+)
+
+const (
+	fileMagic        = "cook"
+	pageHeader       = 0x00000100
+	cookieHeaderSize = 56
+)
+
+// footerMagic is written after the checksum when a jar has no trailer of its own
+var footerMagic = []byte{0x07, 0x17, 0x20, 0x05, 0x00, 0x00, 0x00, 0x4b}
+
+// macEpoch is the reference date for cookie timestamps (2001-01-01 UTC)
+var macEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Cookie is a single stored cookie
+type Cookie struct {
+	Domain  string
+	Name    string
+	Path    string
+	Value   string
+	Comment string
+	Flags   uint32
+	Expires time.Time
+	Created time.Time
+
+	// Version and Port are stored by macOS but unused by us; they are kept
+	// so a round trip doesn't change them
+	Version uint32
+	HasPort bool
+	Port    uint16
+}
+
+// Secure reports whether the cookie is only sent over HTTPS
+func (c Cookie) Secure() bool {
+	return c.Flags&FlagSecure != 0
+}
+
+// HTTPOnly reports whether the cookie is hidden from scripts
+func (c Cookie) HTTPOnly() bool {
+	return c.Flags&FlagHTTPOnly != 0
+}
+
+// Page is a group of cookies as laid out in the file
+type Page struct {
+	Cookies []Cookie
+}
+
+// Jar is a decoded binarycookies file
+type Jar struct {
+	Pages   []Page
+	Trailer []byte
+}
+
+// ReadFile decodes the binarycookies file at path
+func ReadFile(path string) (*Jar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// WriteFile encodes jar and atomically replaces the file at path
+func WriteFile(path string, jar *Jar) error {
+	data, err := jar.Encode()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// Cookies returns every cookie in the jar, in file order
+func (j *Jar) Cookies() []Cookie {
+	var cookies []Cookie
+	for _, page := range j.Pages {
+		cookies = append(cookies, page.Cookies...)
+	}
+	return cookies
+}
+
+// SetCookie replaces the cookie with the same domain, name and path, or
+// appends it to the last page if there is none
+func (j *Jar) SetCookie(cookie Cookie) {
+	for p := range j.Pages {
+		for i, existing := range j.Pages[p].Cookies {
+			if sameCookie(existing, cookie) {
+				j.Pages[p].Cookies[i] = cookie
+				return
+			}
+		}
+	}
+
+	if len(j.Pages) == 0 {
+		j.Pages = append(j.Pages, Page{})
+	}
+	last := &j.Pages[len(j.Pages)-1]
+	last.Cookies = append(last.Cookies, cookie)
+}
+
+// DeleteCookies removes every cookie matching domain and name (any path) and
+// returns how many were removed. Pages left empty are dropped.
+func (j *Jar) DeleteCookies(domain, name string) int {
+	return j.DeleteFunc(func(c Cookie) bool {
+		return strings.EqualFold(c.Domain, domain) && c.Name == name
+	})
+}
+
+// DeleteFunc removes every cookie for which match returns true and returns
+// how many were removed. Pages left empty are dropped.
+func (j *Jar) DeleteFunc(match func(Cookie) bool) int {
+	removed := 0
+	pages := j.Pages[:0]
+	for _, page := range j.Pages {
+		kept := page.Cookies[:0]
+		for _, c := range page.Cookies {
+			if match(c) {
+				removed++
+				continue
+			}
+			kept = append(kept, c)
+		}
+		if len(kept) > 0 {
+			pages = append(pages, Page{Cookies: kept})
+		}
+	}
+	j.Pages = pages
+	return removed
+}
+
+// sameCookie reports whether a and b identify the same cookie slot
+func sameCookie(a, b Cookie) bool {
+	return strings.EqualFold(a.Domain, b.Domain) && a.Name == b.Name && a.Path == b.Path
+}
+
+// Decode parses a binarycookies file
+func Decode(data []byte) (*Jar, error) {
+	if len(data) < 8 || string(data[:4]) != fileMagic {
+		return nil, fmt.Errorf("not a binarycookies file")
+	}
+
+	numPages := int(binary.BigEndian.Uint32(data[4:8]))
+	pos := 8
+	if numPages < 0 || len(data) < pos+numPages*4 {
+		return nil, fmt.Errorf("truncated page table")
+	}
+
+	pageSizes := make([]int, numPages)
+	for i := range pageSizes {
+		pageSizes[i] = int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		pos += 4
+	}
+
+	jar := &Jar{}
+	for i, size := range pageSizes {
+		if size < 0 || pos+size > len(data) {
+			return nil, fmt.Errorf("page %d is truncated", i)
+		}
+		page, err := decodePage(data[pos : pos+size])
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i, err)
+		}
+		jar.Pages = append(jar.Pages, page)
+		pos += size
+	}
+
+	// Checksum (4 bytes), then footer and optional plist
+	if pos+4 <= len(data) {
+		pos += 4
+		jar.Trailer = append([]byte(nil), data[pos:]...)
+	}
+
+	return jar, nil
+}
+
+// decodePage parses one page
+func decodePage(page []byte) (Page, error) {
+	if len(page) < 8 || binary.BigEndian.Uint32(page[:4]) != pageHeader {
+		return Page{}, fmt.Errorf("bad page header")
+	}
+
+	numCookies := int(binary.LittleEndian.Uint32(page[4:8]))
+	if len(page) < 8+numCookies*4 {
+		return Page{}, fmt.Errorf("truncated cookie table")
+	}
+
+	var result Page
+	for i := 0; i < numCookies; i++ {
+		offset := int(binary.LittleEndian.Uint32(page[8+i*4:]))
+		if offset+4 > len(page) {
+			return Page{}, fmt.Errorf("cookie %d offset out of range", i)
+		}
+		size := int(binary.LittleEndian.Uint32(page[offset:]))
+		if size < cookieHeaderSize || offset+size > len(page) {
+			return Page{}, fmt.Errorf("cookie %d is truncated", i)
+		}
+		cookie, err := decodeCookie(page[offset : offset+size])
+		if err != nil {
+			return Page{}, fmt.Errorf("cookie %d: %w", i, err)
+		}
+		result.Cookies = append(result.Cookies, cookie)
+	}
+
+	return result, nil
+}
+
+// decodeCookie parses one cookie record
+func decodeCookie(rec []byte) (Cookie, error) {
+	le := binary.LittleEndian
+	c := Cookie{
+		Version: le.Uint32(rec[4:]),
+		Flags:   le.Uint32(rec[8:]),
+		HasPort: le.Uint32(rec[12:]) != 0,
+		Expires: fromMacTime(math.Float64frombits(le.Uint64(rec[40:]))),
+		Created: fromMacTime(math.Float64frombits(le.Uint64(rec[48:]))),
+	}
+	if c.HasPort && len(rec) >= cookieHeaderSize+2 {
+		c.Port = binary.LittleEndian.Uint16(rec[cookieHeaderSize:])
+	}
+
+	var err error
+	fields := []struct {
+		offset uint32
+		dst    *string
+	}{
+		{le.Uint32(rec[16:]), &c.Domain},
+		{le.Uint32(rec[20:]), &c.Name},
+		{le.Uint32(rec[24:]), &c.Path},
+		{le.Uint32(rec[28:]), &c.Value},
+		{le.Uint32(rec[32:]), &c.Comment},
+	}
+	for _, f := range fields {
+		if f.offset == 0 {
+			continue
+		}
+		if *f.dst, err = cString(rec, int(f.offset)); err != nil {
+			return Cookie{}, err
+		}
+	}
+
+	return c, nil
+}
+
+// cString reads a NUL-terminated string starting at offset
+func cString(rec []byte, offset int) (string, error) {
+	if offset >= len(rec) {
+		return "", fmt.Errorf("string offset %d out of range", offset)
+	}
+	end := bytes.IndexByte(rec[offset:], 0)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string at %d", offset)
+	}
+	return string(rec[offset : offset+end]), nil
+}
+
+// Encode serializes the jar
+func (j *Jar) Encode() ([]byte, error) {
+	pages := make([][]byte, len(j.Pages))
+	for i, page := range j.Pages {
+		encoded, err := encodePage(page)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i, err)
+		}
+		pages[i] = encoded
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fileMagic)
+	binary.Write(&buf, binary.BigEndian, uint32(len(pages)))
+	for _, page := range pages {
+		binary.Write(&buf, binary.BigEndian, uint32(len(page)))
+	}
+
+	var checksum uint32
+	for _, page := range pages {
+		buf.Write(page)
+		for i := 0; i < len(page); i += 4 {
+			checksum += uint32(page[i])
+		}
+	}
+	binary.Write(&buf, binary.BigEndian, checksum)
+
+	if j.Trailer != nil {
+		buf.Write(j.Trailer)
+	} else {
+		buf.Write(footerMagic)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodePage serializes one page
+func encodePage(page Page) ([]byte, error) {
+	records := make([][]byte, len(page.Cookies))
+	for i, c := range page.Cookies {
+		if strings.ContainsRune(c.Domain+c.Name+c.Path+c.Value+c.Comment, 0) {
+			return nil, fmt.Errorf("cookie %q contains a NUL byte", c.Name)
+		}
+		records[i] = encodeCookie(c)
+	}
+
+	headerSize := 8 + 4*len(records) + 4
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(pageHeader))
+	binary.Write(&buf, binary.LittleEndian, uint32(len(records)))
+	offset := headerSize
+	for _, rec := range records {
+		binary.Write(&buf, binary.LittleEndian, uint32(offset))
+		offset += len(rec)
+	}
+	binary.Write(&buf, binary.LittleEndian, uint32(0)) // end of page header
+
+	for _, rec := range records {
+		buf.Write(rec)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeCookie serializes one cookie record
+func encodeCookie(c Cookie) []byte {
+	start := cookieHeaderSize
+	if c.HasPort {
+		start += 2
+	}
+
+	var strs bytes.Buffer
+	offsets := make([]uint32, 5)
+	for i, s := range []string{c.Domain, c.Name, c.Path, c.Value, c.Comment} {
+		if i == 4 && s == "" {
+			break // No comment: offset stays 0
+		}
+		offsets[i] = uint32(start + strs.Len())
+		strs.WriteString(s)
+		strs.WriteByte(0)
+	}
+
+	size := start + strs.Len()
+	rec := make([]byte, cookieHeaderSize, size)
+	le := binary.LittleEndian
+	le.PutUint32(rec[0:], uint32(size))
+	le.PutUint32(rec[4:], c.Version)
+	le.PutUint32(rec[8:], c.Flags)
+	if c.HasPort {
+		le.PutUint32(rec[12:], 1)
+	}
+	for i, off := range offsets {
+		le.PutUint32(rec[16+i*4:], off)
+	}
+	// rec[36:40] is reserved and left zero
+	le.PutUint64(rec[40:], math.Float64bits(toMacTime(c.Expires)))
+	le.PutUint64(rec[48:], math.Float64bits(toMacTime(c.Created)))
+
+	if c.HasPort {
+		rec = le.AppendUint16(rec, c.Port)
+	}
+	return append(rec, strs.Bytes()...)
+}
+
+// fromMacTime converts seconds since 2001-01-01 to a time.Time
+func fromMacTime(secs float64) time.Time {
+	whole, frac := math.Modf(secs)
+	return macEpoch.Add(time.Duration(whole) * time.Second).
+		Add(time.Duration(math.Round(frac * 1e9)))
+}
+
+// toMacTime converts a time.Time to seconds since 2001-01-01
+func toMacTime(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	d := t.Sub(macEpoch)
+	whole := d / time.Second
+	return float64(whole) + float64(d-whole*time.Second)/1e9
+}
@@ -0,0 +1,133 @@
+package binary_cookies
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func sampleJar() *Jar {
+	created := time.Date(2024, 3, 1, 12, 30, 15, 250000000, time.UTC)
+	return &Jar{
+		Pages: []Page{
+			{Cookies: []Cookie{
+				{
+					Domain:  ".roblox.com",
+					Name:    ".ROBLOSECURITY",
+					Path:    "/",
+					Value:   "_|WARNING:-DO-NOT-SHARE-THIS.|_abc'''\"def",
+					Flags:   FlagSecure | FlagHTTPOnly,
+					Expires: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+					Created: created,
+				},
+				{
+					Domain:  "www.roblox.com",
+					Name:    "RBXEventTrackerV2",
+					Path:    "/",
+					Value:   "CreateDate=3/1/2024&browserid=123",
+					Comment: "tracking",
+					Expires: time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC),
+					Created: created,
+				},
+			}},
+			{Cookies: []Cookie{
+				{
+					Domain:  "example.com",
+					Name:    "session",
+					Path:    "/app",
+					Value:   "xyz",
+					Version: 1,
+					HasPort: true,
+					Port:    8443,
+					Expires: time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC),
+					Created: created,
+				},
+			}},
+		},
+		Trailer: append(append([]byte{}, footerMagic...), []byte("bplist00\x01\x02")...),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	jar := sampleJar()
+
+	data, err := jar.Encode()
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(jar, decoded) {
+		t.Fatalf("decoded jar differs:\n got %+v\nwant %+v", decoded, jar)
+	}
+
+	again, err := decoded.Encode()
+	if err != nil {
+		t.Fatalf("re-Encode: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Fatalf("re-encoded bytes differ from original")
+	}
+}
+
+func TestSetCookieMergesIntoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.binarycookies")
+	if err := WriteFile(path, sampleJar()); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	jar, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	jar.SetCookie(Cookie{Domain: ".roblox.com", Name: ".ROBLOSECURITY", Path: "/", Value: "new"})
+	if err := WriteFile(path, jar); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	jar, err = ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	cookies := jar.Cookies()
+	if len(cookies) != 3 {
+		t.Fatalf("got %d cookies, want 3 (others must be preserved)", len(cookies))
+	}
+	if cookies[0].Value != "new" {
+		t.Fatalf(".ROBLOSECURITY not replaced in place: %+v", cookies[0])
+	}
+	if cookies[2].Domain != "example.com" || cookies[2].Port != 8443 {
+		t.Fatalf("unrelated cookie changed: %+v", cookies[2])
+	}
+}
+
+func TestDeleteCookiesDropsEmptyPages(t *testing.T) {
+	jar := sampleJar()
+	if n := jar.DeleteCookies("EXAMPLE.com", "session"); n != 1 {
+		t.Fatalf("DeleteCookies removed %d, want 1", n)
+	}
+	if len(jar.Pages) != 1 {
+		t.Fatalf("got %d pages, want 1", len(jar.Pages))
+	}
+}
+
+func TestDecodeRejectsGarbage(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("nope"), []byte("cook\x00\x00\x00\x05")} {
+		if _, err := Decode(data); err == nil {
+			t.Errorf("Decode(%q) succeeded", data)
+		}
+	}
+}
+
+func TestEncodeRejectsNUL(t *testing.T) {
+	jar := &Jar{}
+	jar.SetCookie(Cookie{Domain: ".roblox.com", Name: "x", Value: "a\x00b"})
+	if _, err := jar.Encode(); err == nil {
+		t.Fatalf("Encode accepted a NUL byte in a value")
+	}
+}
@@ -0,0 +1,136 @@
+package browser_source
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	robloxHost       = "roblox.com"
+	robloxCookieName = ".ROBLOSECURITY"
+)
+
+// Profile is one browser profile that can hold a Roblox session
+type Profile struct {
+	Name string // Display name, e.g. "Person 1" or "default-release"
+	Path string // Profile directory
+}
+
+// Cookie is a .ROBLOSECURITY cookie read from a browser
+type Cookie struct {
+	Value   string
+	Expires time.Time // Zero if the browser didn't record an expiry
+}
+
+// BrowserSource is a browser that Roblox session cookies can be captured from
+type BrowserSource interface {
+	// Name is the display name, also used to persist the user's choice
+	Name() string
+
+	// AppName is the name passed to `open -a`
+	AppName() string
+
+	// Profiles lists profiles that have a cookie store on disk
+	Profiles() ([]Profile, error)
+
+	// ReadRobloxCookie returns the .ROBLOSECURITY cookie in a profile
+	ReadRobloxCookie(profile Profile) (*Cookie, error)
+
+	// ClearRobloxCookies deletes every roblox.com cookie in a profile
+	ClearRobloxCookies(profile Profile) error
+
+	// IsRunning reports whether the browser process is running
+	IsRunning() bool
+
+	// Quit terminates the browser so its cookie store can be modified
+	Quit() error
+}
+
+// All returns every supported browser, in the order shown to the user
+func All() []BrowserSource {
+	return []BrowserSource{
+		Vivaldi(),
+		Chrome(),
+		Brave(),
+		Edge(),
+		Arc(),
+		NewFirefoxSource(),
+		NewSafariSource(),
+	}
+}
+
+// Installed returns the supported browsers that have at least one profile
+func Installed() []BrowserSource {
+	var installed []BrowserSource
+	for _, source := range All() {
+		if profiles, err := source.Profiles(); err == nil && len(profiles) > 0 {
+			installed = append(installed, source)
+		}
+	}
+	return installed
+}
+
+// ByName returns the supported browser with the given name
+func ByName(name string) (BrowserSource, error) {
+	for _, source := range All() {
+		if strings.EqualFold(source.Name(), name) {
+			return source, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported browser: %s", name)
+}
+
+// FindProfile returns the profile of source whose directory or name matches
+// ref, or the first profile when ref is empty
+func FindProfile(source BrowserSource, ref string) (Profile, error) {
+	profiles, err := source.Profiles()
+	if err != nil {
+		return Profile{}, err
+	}
+	if len(profiles) == 0 {
+		return Profile{}, fmt.Errorf("no %s profiles found", source.Name())
+	}
+	if ref == "" {
+		return profiles[0], nil
+	}
+
+	for _, p := range profiles {
+		if p.Path == ref || filepath.Base(p.Path) == ref || p.Name == ref {
+			return p, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("%s profile not found: %s", source.Name(), ref)
+}
+
+// isProcessRunning checks for a process with exactly this name
+func isProcessRunning(processName string) bool {
+	return exec.Command("pgrep", "-x", processName).Run() == nil
+}
+
+// quitProcess kills a process by exact name and gives it a moment to exit
+func quitProcess(processName string) error {
+	if !isProcessRunning(processName) {
+		return nil
+	}
+	if err := exec.Command("pkill", "-x", processName).Run(); err != nil {
+		return fmt.Errorf("failed to quit %s: %w", processName, err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	return nil
+}
+
+// supportDir returns a path under ~/Library/Application Support
+func supportDir(parts ...string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(append([]string{home, "Library", "Application Support"}, parts...)...)
+}
+
+// isRobloxHost reports whether a cookie host belongs to roblox.com
+func isRobloxHost(host string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), ".")
+	return host == robloxHost || strings.HasSuffix(host, "."+robloxHost)
+}
@@ -0,0 +1,111 @@
+package browser_source
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func touch(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChromiumProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	chrome := Chrome()
+	touch(t, filepath.Join(chrome.userDataDir, "Profile 2", "Network", "Cookies"))
+	touch(t, filepath.Join(chrome.userDataDir, "Default", "Cookies"))
+	touch(t, filepath.Join(chrome.userDataDir, "System Profile", "Cookies"))
+	if err := os.WriteFile(filepath.Join(chrome.userDataDir, "Local State"),
+		[]byte(`{"profile":{"info_cache":{"Profile 2":{"name":"Alt"}}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := chrome.Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != "Default" || profiles[1].Name != "Alt" {
+		t.Fatalf("got %+v", profiles)
+	}
+
+	if p, err := FindProfile(chrome, "Profile 2"); err != nil || p.Name != "Alt" {
+		t.Fatalf("FindProfile = %+v, %v", p, err)
+	}
+	if installed := Installed(); len(installed) != 1 || installed[0].Name() != "Chrome" {
+		t.Fatalf("Installed = %v", installed)
+	}
+}
+
+func TestFirefoxCookie(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	firefox := NewFirefoxSource()
+	dir := filepath.Join(firefox.profilesDir, "abcd1234.default-release")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "cookies.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Date(2027, 5, 6, 7, 8, 9, 0, time.UTC)
+	for _, stmt := range []string{
+		`CREATE TABLE moz_cookies (name TEXT, value TEXT, host TEXT, path TEXT, expiry INTEGER)`,
+		`INSERT INTO moz_cookies VALUES ('.ROBLOSECURITY', 'secret', '.roblox.com', '/', ?)`,
+		`INSERT INTO moz_cookies VALUES ('.ROBLOSECURITY', 'wrong', '.notroblox.com', '/', ?)`,
+	} {
+		if _, err := db.Exec(stmt, expires.Unix()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	profile, err := FindProfile(firefox, "")
+	if err != nil || profile.Name != "default-release" {
+		t.Fatalf("FindProfile = %+v, %v", profile, err)
+	}
+
+	cookie, err := firefox.ReadRobloxCookie(profile)
+	if err != nil || cookie.Value != "secret" || !cookie.Expires.Equal(expires) {
+		t.Fatalf("ReadRobloxCookie = %+v, %v", cookie, err)
+	}
+
+	// A login Firefox hasn't checkpointed yet is only in the write-ahead log
+	running, err := sql.Open("sqlite3", filepath.Join(dir, "cookies.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	running.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA wal_autocheckpoint=0",
+		"UPDATE moz_cookies SET value = 'fresh' WHERE host = '.roblox.com'",
+	} {
+		if _, err := running.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cookie, err = firefox.ReadRobloxCookie(profile)
+	running.Close()
+	if err != nil || cookie.Value != "fresh" {
+		t.Fatalf("ReadRobloxCookie with a write-ahead log = %+v, %v", cookie, err)
+	}
+
+	if err := firefox.ClearRobloxCookies(profile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := firefox.ReadRobloxCookie(profile); err == nil {
+		t.Fatalf("cookie still present after clearing")
+	}
+}
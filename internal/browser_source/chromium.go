@@ -0,0 +1,189 @@
+package browser_source
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/chromium_cookies"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChromiumSource reads cookies from a Chromium-based browser
+type ChromiumSource struct {
+	name               string
+	appName            string
+	processName        string
+	userDataDir        string
+	safeStorageService string
+
+	// Passwords supplies the Safe Storage password; defaults to the Keychain
+	Passwords chromium_cookies.PasswordProvider
+}
+
+// NewChromiumSource describes a Chromium-based browser
+func NewChromiumSource(name, appName, processName, userDataDir, safeStorageService string) *ChromiumSource {
+	return &ChromiumSource{
+		name:               name,
+		appName:            appName,
+		processName:        processName,
+		userDataDir:        userDataDir,
+		safeStorageService: safeStorageService,
+		Passwords:          chromium_cookies.KeychainPasswordProvider{},
+	}
+}
+
+// Vivaldi returns the Vivaldi browser source
+func Vivaldi() *ChromiumSource {
+	return NewChromiumSource("Vivaldi", "Vivaldi", "Vivaldi", supportDir("Vivaldi"), "Vivaldi Safe Storage")
+}
+
+// Chrome returns the Google Chrome browser source
+func Chrome() *ChromiumSource {
+	return NewChromiumSource("Chrome", "Google Chrome", "Google Chrome", supportDir("Google", "Chrome"), "Chrome Safe Storage")
+}
+
+// Brave returns the Brave browser source
+func Brave() *ChromiumSource {
+	return NewChromiumSource("Brave", "Brave Browser", "Brave Browser", supportDir("BraveSoftware", "Brave-Browser"), "Brave Safe Storage")
+}
+
+// Edge returns the Microsoft Edge browser source
+func Edge() *ChromiumSource {
+	return NewChromiumSource("Edge", "Microsoft Edge", "Microsoft Edge", supportDir("Microsoft Edge"), "Microsoft Edge Safe Storage")
+}
+
+// Arc returns the Arc browser source
+func Arc() *ChromiumSource {
+	return NewChromiumSource("Arc", "Arc", "Arc", supportDir("Arc", "User Data"), "Arc Safe Storage")
+}
+
+func (c *ChromiumSource) Name() string    { return c.name }
+func (c *ChromiumSource) AppName() string { return c.appName }
+func (c *ChromiumSource) IsRunning() bool { return isProcessRunning(c.processName) }
+func (c *ChromiumSource) Quit() error     { return quitProcess(c.processName) }
+
+// Profiles lists "Default" and "Profile N" directories that have a cookie
+// database, named from the browser's Local State when available
+func (c *ChromiumSource) Profiles() ([]Profile, error) {
+	entries, err := os.ReadDir(c.userDataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	names := c.profileNames()
+
+	var profiles []Profile
+	for _, entry := range entries {
+		dir := entry.Name()
+		if !entry.IsDir() || (dir != "Default" && !strings.HasPrefix(dir, "Profile ")) {
+			continue
+		}
+
+		path := filepath.Join(c.userDataDir, dir)
+		if cookieDBPath(path) == "" {
+			continue
+		}
+
+		name := names[dir]
+		if name == "" {
+			name = dir
+		}
+		profiles = append(profiles, Profile{Name: name, Path: path})
+	}
+
+	// Default first, then Profile 1, Profile 2...
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profileOrder(filepath.Base(profiles[i].Path)) < profileOrder(filepath.Base(profiles[j].Path))
+	})
+	return profiles, nil
+}
+
+// ReadRobloxCookie decrypts the .ROBLOSECURITY cookie in a profile
+func (c *ChromiumSource) ReadRobloxCookie(profile Profile) (*Cookie, error) {
+	dbPath := cookieDBPath(profile.Path)
+	if dbPath == "" {
+		return nil, fmt.Errorf("%s cookie database not found in %s", c.name, profile.Path)
+	}
+
+	cookie, err := chromium_cookies.FindCookie(dbPath, c.safeStorageService, c.Passwords, robloxHost, robloxCookieName)
+	if err != nil {
+		return nil, err
+	}
+	return &Cookie{Value: cookie.Value, Expires: cookie.Expires}, nil
+}
+
+// ClearRobloxCookies deletes roblox.com cookies from a profile. The browser
+// must not be running or it will write its in-memory copy back.
+func (c *ChromiumSource) ClearRobloxCookies(profile Profile) error {
+	dbPath := cookieDBPath(profile.Path)
+	if dbPath == "" {
+		return fmt.Errorf("%s cookie database not found in %s", c.name, profile.Path)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open cookie database: %w", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`DELETE FROM cookies WHERE host_key = ? OR host_key LIKE ?`, robloxHost, "%."+robloxHost)
+	if err != nil {
+		return fmt.Errorf("failed to clear cookies: %w", err)
+	}
+	return nil
+}
+
+// profileNames maps profile directories to display names from Local State
+func (c *ChromiumSource) profileNames() map[string]string {
+	names := make(map[string]string)
+
+	data, err := os.ReadFile(filepath.Join(c.userDataDir, "Local State"))
+	if err != nil {
+		return names
+	}
+
+	var state struct {
+		Profile struct {
+			InfoCache map[string]struct {
+				Name string `json:"name"`
+			} `json:"info_cache"`
+		} `json:"profile"`
+	}
+	if json.Unmarshal(data, &state) != nil {
+		return names
+	}
+
+	for dir, info := range state.Profile.InfoCache {
+		names[dir] = info.Name
+	}
+	return names
+}
+
+// cookieDBPath finds a profile's cookie database. Chromium 96+ keeps it under
+// Network/, older versions (and some forks) in the profile root.
+func cookieDBPath(profileDir string) string {
+	for _, candidate := range []string{
+		filepath.Join(profileDir, "Network", "Cookies"),
+		filepath.Join(profileDir, "Cookies"),
+	} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// profileOrder sorts "Default" before numbered profiles
+func profileOrder(dir string) string {
+	if dir == "Default" {
+		return ""
+	}
+	num := strings.TrimPrefix(dir, "Profile ")
+	return fmt.Sprintf("%08s", num)
+}
@@ -0,0 +1,114 @@
+package browser_source
+
+import (
+	"database/sql"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/chromium_cookies"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// FirefoxSource reads cookies from Firefox's unencrypted cookies.sqlite
+type FirefoxSource struct {
+	profilesDir string
+}
+
+// NewFirefoxSource returns the Firefox browser source
+func NewFirefoxSource() *FirefoxSource {
+	return &FirefoxSource{profilesDir: supportDir("Firefox", "Profiles")}
+}
+
+func (f *FirefoxSource) Name() string    { return "Firefox" }
+func (f *FirefoxSource) AppName() string { return "Firefox" }
+func (f *FirefoxSource) IsRunning() bool { return isProcessRunning("firefox") }
+func (f *FirefoxSource) Quit() error     { return quitProcess("firefox") }
+
+// Profiles lists profile directories (e.g. "abcd1234.default-release") that
+// contain cookies.sqlite, named after the part following the random prefix
+func (f *FirefoxSource) Profiles() ([]Profile, error) {
+	entries, err := os.ReadDir(f.profilesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var profiles []Profile
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(f.profilesDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(path, "cookies.sqlite")); err != nil {
+			continue
+		}
+		profiles = append(profiles, Profile{Name: firefoxProfileName(entry.Name()), Path: path})
+	}
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// ReadRobloxCookie returns the latest-expiring .ROBLOSECURITY cookie
+func (f *FirefoxSource) ReadRobloxCookie(profile Profile) (*Cookie, error) {
+	// Firefox keeps recent logins in the write-ahead log while it runs
+	db, cleanup, err := chromium_cookies.OpenSnapshot(filepath.Join(profile.Path, "cookies.sqlite"))
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var value string
+	var expiry int64
+	err = db.QueryRow(`SELECT value, expiry FROM moz_cookies
+		WHERE name = ? AND (host = ? OR host LIKE ?) AND value != ''
+		ORDER BY expiry DESC LIMIT 1`,
+		robloxCookieName, robloxHost, "%."+robloxHost).Scan(&value, &expiry)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no %s cookie found in Firefox profile %s", robloxCookieName, profile.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cookies: %w", err)
+	}
+
+	cookie := &Cookie{Value: value}
+	if expiry > 0 {
+		// Newer Firefox versions store milliseconds; older ones seconds
+		if expiry > 1e11 {
+			cookie.Expires = time.UnixMilli(expiry).UTC()
+		} else {
+			cookie.Expires = time.Unix(expiry, 0).UTC()
+		}
+	}
+	return cookie, nil
+}
+
+// ClearRobloxCookies deletes roblox.com cookies from a profile
+func (f *FirefoxSource) ClearRobloxCookies(profile Profile) error {
+	db, err := sql.Open("sqlite3", filepath.Join(profile.Path, "cookies.sqlite"))
+	if err != nil {
+		return fmt.Errorf("failed to open cookie database: %w", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`DELETE FROM moz_cookies WHERE host = ? OR host LIKE ?`, robloxHost, "%."+robloxHost)
+	if err != nil {
+		return fmt.Errorf("failed to clear cookies: %w", err)
+	}
+	return nil
+}
+
+// firefoxProfileName strips the random "xxxxxxxx." prefix from a profile dir
+func firefoxProfileName(dir string) string {
+	for i := 0; i < len(dir); i++ {
+		if dir[i] == '.' && i+1 < len(dir) {
+			return dir[i+1:]
+		}
+	}
+	return dir
+}
@@ -0,0 +1,81 @@
+package browser_source
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/binary_cookies"
+	"os"
+	"path/filepath"
+)
+
+// SafariSource reads cookies from Safari's Cookies.binarycookies. macOS only
+// lets apps with Full Disk Access read it.
+type SafariSource struct {
+	candidates []string
+}
+
+// NewSafariSource returns the Safari browser source
+func NewSafariSource() *SafariSource {
+	home, _ := os.UserHomeDir()
+	return &SafariSource{candidates: []string{
+		// Sandboxed Safari (macOS 10.14+)
+		filepath.Join(home, "Library", "Containers", "com.apple.Safari", "Data", "Library", "Cookies", "Cookies.binarycookies"),
+		filepath.Join(home, "Library", "Cookies", "Cookies.binarycookies"),
+	}}
+}
+
+func (s *SafariSource) Name() string    { return "Safari" }
+func (s *SafariSource) AppName() string { return "Safari" }
+func (s *SafariSource) IsRunning() bool { return isProcessRunning("Safari") }
+func (s *SafariSource) Quit() error     { return quitProcess("Safari") }
+
+// Profiles returns the single cookie jar Safari shares across its profiles
+func (s *SafariSource) Profiles() ([]Profile, error) {
+	for _, path := range s.candidates {
+		if _, err := os.Stat(path); err == nil {
+			return []Profile{{Name: "Default", Path: path}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// ReadRobloxCookie returns the latest-expiring .ROBLOSECURITY cookie
+func (s *SafariSource) ReadRobloxCookie(profile Profile) (*Cookie, error) {
+	jar, err := binary_cookies.ReadFile(profile.Path)
+	if err != nil {
+		if os.IsPermission(err) {
+			return nil, fmt.Errorf("reading Safari cookies requires Full Disk Access: %w", err)
+		}
+		return nil, err
+	}
+
+	var best *Cookie
+	for _, c := range jar.Cookies() {
+		if c.Name != robloxCookieName || c.Value == "" || !isRobloxHost(c.Domain) {
+			continue
+		}
+		if best == nil || c.Expires.After(best.Expires) {
+			best = &Cookie{Value: c.Value, Expires: c.Expires}
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no %s cookie found in Safari", robloxCookieName)
+	}
+	return best, nil
+}
+
+// ClearRobloxCookies rewrites the jar without roblox.com cookies
+func (s *SafariSource) ClearRobloxCookies(profile Profile) error {
+	jar, err := binary_cookies.ReadFile(profile.Path)
+	if err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("clearing Safari cookies requires Full Disk Access: %w", err)
+		}
+		return err
+	}
+
+	if jar.DeleteFunc(func(c binary_cookies.Cookie) bool { return isRobloxHost(c.Domain) }) == 0 {
+		return nil
+	}
+	return binary_cookies.WriteFile(profile.Path, jar)
+}
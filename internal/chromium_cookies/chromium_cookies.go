@@ -0,0 +1,341 @@
+// chromium_cookies reads cookies straight from a Chromium-family browser's
+// Cookies SQLite database and decrypts them without external tools.
+//
+// On macOS, Chromium encrypts cookie values as "v10" + AES-128-CBC(PKCS#7),
+// with a 16-space IV and a key derived by PBKDF2-HMAC-SHA1 from the
+// "<Browser> Safe Storage" Keychain password (salt "saltysalt", 1003 rounds).
+// Since database version 24 the plaintext is prefixed with SHA-256(host_key).
+package chromium_cookies
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/logger"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	v10Prefix        = "v10"
+	keySalt          = "saltysalt"
+	keyIterations    = 1003
+	keyLength        = 16
+	hostDigestSchema = 24 // meta.version from which values carry a SHA-256(host) prefix
+)
+
+// chromeEpochOffset is the number of seconds between 1601-01-01 (Chromium's
+// epoch) and 1970-01-01. The span is too long for a time.Duration.
+const chromeEpochOffset = 11644473600
+
+// Cookie is a decrypted cookie row
+type Cookie struct {
+	Host     string
+	Name     string
+	Path     string
+	Value    string
+	Expires  time.Time // Zero for session cookies
+	Secure   bool
+	HTTPOnly bool
+}
+
+// PasswordProvider supplies the "Safe Storage" password used to derive the
+// cookie encryption key
+type PasswordProvider interface {
+	SafeStoragePassword(service string) (string, error)
+}
+
+// KeychainPasswordProvider reads the Safe Storage password from the macOS Keychain
+type KeychainPasswordProvider struct{}
+
+// SafeStoragePassword looks up a generic password such as "Chrome Safe Storage"
+func (KeychainPasswordProvider) SafeStoragePassword(service string) (string, error) {
+	output, err := exec.Command("security", "find-generic-password", "-s", service, "-w").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get %q from Keychain: %w", service, err)
+	}
+
+	password := strings.TrimSpace(string(output))
+	if password == "" {
+		return "", fmt.Errorf("empty %q password", service)
+	}
+	return password, nil
+}
+
+// StaticPasswordProvider always returns the same password (for tests and fixtures)
+type StaticPasswordProvider string
+
+// SafeStoragePassword returns the fixed password regardless of service
+func (p StaticPasswordProvider) SafeStoragePassword(string) (string, error) {
+	return string(p), nil
+}
+
+// DeriveKey derives the AES-128 cookie key from a Safe Storage password
+func DeriveKey(password string) []byte {
+	return pbkdf2.Key([]byte(password), []byte(keySalt), keyIterations, keyLength, sha1.New)
+}
+
+// DecryptValue decrypts an encrypted_value column. When hasHostDigest is set
+// (database version 24+) the SHA-256(hostKey) prefix is verified and removed.
+func DecryptValue(key, encrypted []byte, hostKey string, hasHostDigest bool) (string, error) {
+	if !bytes.HasPrefix(encrypted, []byte(v10Prefix)) {
+		return "", fmt.Errorf("unsupported cookie encryption version")
+	}
+
+	ciphertext := encrypted[len(v10Prefix):]
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("ciphertext is not a whole number of blocks")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, chromeIV()).CryptBlocks(plaintext, ciphertext)
+
+	plaintext, err = pkcs7Unpad(plaintext)
+	if err != nil {
+		return "", fmt.Errorf("decryption failed (wrong Safe Storage password?): %w", err)
+	}
+
+	if hasHostDigest {
+		digest := sha256.Sum256([]byte(hostKey))
+		if !bytes.HasPrefix(plaintext, digest[:]) {
+			return "", fmt.Errorf("cookie host digest mismatch")
+		}
+		plaintext = plaintext[len(digest):]
+	}
+
+	return string(plaintext), nil
+}
+
+// EncryptValue encrypts a value the way Chromium does, producing an
+// encrypted_value column for the given database version's format
+func EncryptValue(key []byte, value, hostKey string, hasHostDigest bool) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := []byte(value)
+	if hasHostDigest {
+		digest := sha256.Sum256([]byte(hostKey))
+		plaintext = append(digest[:], plaintext...)
+	}
+	plaintext = pkcs7Pad(plaintext)
+
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, chromeIV()).CryptBlocks(ciphertext, plaintext)
+	return append([]byte(v10Prefix), ciphertext...), nil
+}
+
+// ReadCookies returns every cookie whose host ends with hostSuffix (e.g.
+// "roblox.com"), decrypting values with the password for safeStorageService.
+// Cookies that can't be decrypted are logged and skipped.
+func ReadCookies(dbPath, safeStorageService string, passwords PasswordProvider, hostSuffix string) ([]Cookie, error) {
+	cookies, _, err := readCookies(dbPath, safeStorageService, passwords, hostSuffix)
+	return cookies, err
+}
+
+// readCookies is ReadCookies, also returning the decryption error of each
+// skipped cookie by name
+func readCookies(dbPath, safeStorageService string, passwords PasswordProvider, hostSuffix string) ([]Cookie, map[string]error, error) {
+	db, cleanup, err := OpenSnapshot(dbPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+
+	hasHostDigest := schemaVersion(db) >= hostDigestSchema
+
+	rows, err := db.Query(`SELECT host_key, name, path, value, encrypted_value, expires_utc, is_secure, is_httponly
+		FROM cookies WHERE host_key = ? OR host_key LIKE ?`,
+		hostSuffix, "%."+hostSuffix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query cookies: %w", err)
+	}
+	defer rows.Close()
+
+	var key []byte
+	var cookies []Cookie
+	failed := map[string]error{}
+	for rows.Next() {
+		var c Cookie
+		var value string
+		var encrypted []byte
+		var expiresUTC int64
+		if err := rows.Scan(&c.Host, &c.Name, &c.Path, &value, &encrypted, &expiresUTC, &c.Secure, &c.HTTPOnly); err != nil {
+			return nil, nil, err
+		}
+
+		if len(encrypted) > 0 {
+			// Only hit the Keychain once we know something is encrypted
+			if key == nil {
+				password, err := passwords.SafeStoragePassword(safeStorageService)
+				if err != nil {
+					return nil, nil, err
+				}
+				key = DeriveKey(password)
+			}
+			// One unreadable cookie shouldn't hide the others
+			if value, err = DecryptValue(key, encrypted, c.Host, hasHostDigest); err != nil {
+				logger.LogDebug("Skipping cookie %s for %s: %v", c.Name, c.Host, err)
+				failed[c.Name] = err
+				continue
+			}
+		}
+		c.Value = value
+
+		if expiresUTC > 0 {
+			c.Expires = FromChromeTime(expiresUTC)
+		}
+		cookies = append(cookies, c)
+	}
+
+	return cookies, failed, rows.Err()
+}
+
+// FindCookie returns the named cookie for hostSuffix, preferring the one
+// that expires last when several hosts carry it
+func FindCookie(dbPath, safeStorageService string, passwords PasswordProvider, hostSuffix, name string) (*Cookie, error) {
+	cookies, failed, err := readCookies(dbPath, safeStorageService, passwords, hostSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Cookie
+	for i := range cookies {
+		c := &cookies[i]
+		if c.Name != name || c.Value == "" {
+			continue
+		}
+		if best == nil || c.Expires.After(best.Expires) {
+			best = c
+		}
+	}
+
+	if best == nil {
+		if err := failed[name]; err != nil {
+			return nil, fmt.Errorf("cookie %s: %w", name, err)
+		}
+		return nil, fmt.Errorf("no %s cookie found for %s", name, hostSuffix)
+	}
+	return best, nil
+}
+
+// ToChromeTime converts a time to Chromium's microseconds-since-1601 format
+func ToChromeTime(t time.Time) int64 {
+	return (t.Unix()+chromeEpochOffset)*1e6 + int64(t.Nanosecond()/1e3)
+}
+
+// FromChromeTime converts Chromium's microseconds-since-1601 format to a time
+func FromChromeTime(micros int64) time.Time {
+	return time.Unix(micros/1e6-chromeEpochOffset, (micros%1e6)*1e3).UTC()
+}
+
+// OpenSnapshot opens a copy of a browser's SQLite database, made with its
+// write-ahead log, so it works while the browser has the file open and
+// still sees rows not yet checkpointed into the main file. cleanup closes
+// the copy and deletes it.
+func OpenSnapshot(dbPath string) (*sql.DB, func(), error) {
+	dir, err := os.MkdirTemp("", "browser-cookies-")
+	if err != nil {
+		return nil, nil, err
+	}
+	copyPath := filepath.Join(dir, filepath.Base(dbPath))
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		err := copyFile(dbPath+suffix, copyPath+suffix)
+		// Only the database itself must exist
+		if err != nil && (suffix == "" || !errors.Is(err, os.ErrNotExist)) {
+			os.RemoveAll(dir)
+			return nil, nil, fmt.Errorf("failed to copy cookie database: %w", err)
+		}
+	}
+
+	dsn := (&url.URL{Scheme: "file", Path: copyPath}).String()
+	db, err := sql.Open("sqlite3", dsn)
+	if err == nil {
+		err = db.Ping()
+		if err != nil {
+			db.Close()
+		}
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, fmt.Errorf("failed to open cookie database: %w", err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// schemaVersion returns meta.version, or 0 if it can't be read
+func schemaVersion(db *sql.DB) int {
+	var version string
+	if err := db.QueryRow(`SELECT value FROM meta WHERE key = 'version'`).Scan(&version); err != nil {
+		return 0
+	}
+	v, _ := strconv.Atoi(version)
+	return v
+}
+
+// chromeIV is the fixed IV Chromium uses on macOS
+func chromeIV() []byte {
+	return bytes.Repeat([]byte{' '}, aes.BlockSize)
+}
+
+func pkcs7Pad(data []byte) []byte {
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	return append(data, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func pkcs7Unpad(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty plaintext")
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > aes.BlockSize || n > len(data) {
+		return nil, fmt.Errorf("bad padding")
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, fmt.Errorf("bad padding")
+		}
+	}
+	return data[:len(data)-n], nil
+}
@@ -0,0 +1,162 @@
+package chromium_cookies
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testPassword = "peanuts"
+
+// writeFixture creates a minimal Chromium Cookies database
+func writeFixture(t *testing.T, schema int, rows []Cookie) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "Cookies")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stmts := []string{
+		`CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT)`,
+		`CREATE TABLE cookies (host_key TEXT, name TEXT, path TEXT, value TEXT,
+			encrypted_value BLOB, expires_utc INTEGER, is_secure INTEGER, is_httponly INTEGER)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO meta VALUES ('version', ?)`, strconv.Itoa(schema)); err != nil {
+		t.Fatal(err)
+	}
+
+	key := DeriveKey(testPassword)
+	for _, c := range rows {
+		encrypted, err := EncryptValue(key, c.Value, c.Host, schema >= hostDigestSchema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = db.Exec(`INSERT INTO cookies VALUES (?, ?, ?, '', ?, ?, ?, ?)`,
+			c.Host, c.Name, c.Path, encrypted, ToChromeTime(c.Expires), c.Secure, c.HTTPOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return path
+}
+
+func TestFindCookie(t *testing.T) {
+	expires := time.Date(2027, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, schema := range []int{18, 24} {
+		path := writeFixture(t, schema, []Cookie{
+			{Host: ".roblox.com", Name: ".ROBLOSECURITY", Path: "/", Value: "_|WARNING|_secret", Expires: expires, Secure: true, HTTPOnly: true},
+			{Host: "www.roblox.com", Name: "RBXSource", Path: "/", Value: "x", Expires: expires},
+			{Host: ".notroblox.com", Name: ".ROBLOSECURITY", Path: "/", Value: "wrong", Expires: expires.Add(time.Hour)},
+		})
+
+		cookie, err := FindCookie(path, "Test Safe Storage", StaticPasswordProvider(testPassword), "roblox.com", ".ROBLOSECURITY")
+		if err != nil {
+			t.Fatalf("schema %d: FindCookie: %v", schema, err)
+		}
+		if cookie.Value != "_|WARNING|_secret" || !cookie.Expires.Equal(expires) || !cookie.Secure || !cookie.HTTPOnly {
+			t.Fatalf("schema %d: got %+v", schema, cookie)
+		}
+
+		all, err := ReadCookies(path, "Test Safe Storage", StaticPasswordProvider(testPassword), "roblox.com")
+		if err != nil || len(all) != 2 {
+			t.Fatalf("schema %d: ReadCookies returned %d cookies, %v", schema, len(all), err)
+		}
+	}
+}
+
+func TestReadsUncheckpointedCookies(t *testing.T) {
+	path := writeFixture(t, 24, nil)
+
+	// The browser keeps the database open in WAL mode, so recent cookies
+	// are only in the -wal file
+	browser, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer browser.Close()
+	browser.SetMaxOpenConns(1)
+	for _, stmt := range []string{"PRAGMA journal_mode=WAL", "PRAGMA wal_autocheckpoint=0"} {
+		if _, err := browser.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	encrypted, err := EncryptValue(DeriveKey(testPassword), "fresh", ".roblox.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := browser.Exec(`INSERT INTO cookies VALUES ('.roblox.com', '.ROBLOSECURITY', '/', '', ?, 0, 1, 1)`, encrypted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + "-wal"); err != nil {
+		t.Fatalf("fixture has no write-ahead log: %v", err)
+	}
+
+	cookie, err := FindCookie(path, "Test Safe Storage", StaticPasswordProvider(testPassword), "roblox.com", ".ROBLOSECURITY")
+	if err != nil || cookie.Value != "fresh" {
+		t.Fatalf("FindCookie = %+v, %v", cookie, err)
+	}
+
+	// The browser's files are left as they were
+	if info, err := os.Stat(path + "-wal"); err != nil || info.Size() == 0 {
+		t.Fatalf("write-ahead log changed: %v", err)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	path := writeFixture(t, 24, []Cookie{
+		{Host: ".roblox.com", Name: ".ROBLOSECURITY", Path: "/", Value: "secret"},
+	})
+
+	if _, err := FindCookie(path, "Test Safe Storage", StaticPasswordProvider("nope"), "roblox.com", ".ROBLOSECURITY"); err == nil {
+		t.Fatalf("decryption with the wrong password succeeded")
+	}
+}
+
+func TestSkipsUndecryptableCookies(t *testing.T) {
+	path := writeFixture(t, 24, []Cookie{
+		{Host: ".roblox.com", Name: ".ROBLOSECURITY", Path: "/", Value: "secret"},
+	})
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	garbage := append([]byte(v10Prefix), make([]byte, 16)...)
+	_, err = db.Exec(`INSERT INTO cookies VALUES ('.roblox.com', 'RBXEventTracker', '/', '', ?, 0, 0, 0)`, garbage)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := ReadCookies(path, "Test Safe Storage", StaticPasswordProvider(testPassword), "roblox.com")
+	if err != nil || len(all) != 1 {
+		t.Fatalf("ReadCookies returned %d cookies, %v", len(all), err)
+	}
+	cookie, err := FindCookie(path, "Test Safe Storage", StaticPasswordProvider(testPassword), "roblox.com", ".ROBLOSECURITY")
+	if err != nil || cookie.Value != "secret" {
+		t.Fatalf("FindCookie = %+v, %v", cookie, err)
+	}
+	if _, err := FindCookie(path, "Test Safe Storage", StaticPasswordProvider(testPassword), "roblox.com", "RBXEventTracker"); err == nil {
+		t.Fatalf("undecryptable cookie found")
+	}
+}
+
+func TestDeriveKeyKnownAnswer(t *testing.T) {
+	// PBKDF2-HMAC-SHA1("peanuts", "saltysalt", 1003, 16), as used by Chromium
+	want := "d9a09d499b4e1b7461f28e67972c6dbd"
+	if got := hex.EncodeToString(DeriveKey("peanuts")); got != want {
+		t.Fatalf("DeriveKey = %s, want %s", got, want)
+	}
+}
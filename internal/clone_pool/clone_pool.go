@@ -0,0 +1,455 @@
+package clone_pool
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/config_store"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/ps_darwin"
+	"insadem/multi_roblox_macos/internal/roblox_install"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	manifestFile = "pool.json"
+	partialExt   = ".partial"
+
+	// reserveTimeout is how long an acquired clone stays reserved without a
+	// PID before GC hands it out again
+	reserveTimeout = 2 * time.Minute
+	// createTimeout is how long a clone may take to copy before it's taken
+	// for the leftover of a crash and deleted
+	createTimeout = 30 * time.Minute
+
+	// DefaultMaxIdle is how many unused clones are kept for reuse
+	DefaultMaxIdle = 3
+)
+
+// Clone is a copy of the Roblox app handed out for one extra instance
+type Clone struct {
+	Name string
+	Path string
+}
+
+// Executable returns the player binary inside the clone
+func (c Clone) Executable() string {
+	return filepath.Join(c.Path, "Contents", "MacOS", "RobloxPlayer")
+}
+
+// cloneRecord is a clone's entry in the pool manifest. A clone is free when
+// it has no PID and no reservation time.
+type cloneRecord struct {
+	Name       string    `json:"name"`
+	PID        int       `json:"pid,omitempty"`
+	PIDStart   time.Time `json:"pid_start,omitempty"` // Start time of PID's process, to detect PID reuse
+	ReservedAt time.Time `json:"reserved_at,omitempty"`
+	Stale      bool      `json:"stale,omitempty"`    // Made from another Roblox install or version
+	Creating   bool      `json:"creating,omitempty"` // Still being copied, reserved by ReservedAt
+}
+
+type manifest struct {
+	SourceVersion string        `json:"source_version"` // Fingerprint of the install the clones came from
+	Clones        []cloneRecord `json:"clones"`
+}
+
+// Pool manages reusable clones of the Roblox app. Clones are leased to the
+// PID running from them and are only deleted once that process is gone. The
+// manifest is locked across processes, and copying a new clone happens
+// outside the lock.
+type Pool struct {
+	dir    string
+	doc    *config_store.Document[manifest]
+	source func() (*roblox_install.Installation, error)
+
+	// MaxIdle caps the unused clones kept around for reuse
+	MaxIdle int
+	// IsAlive reports whether a PID is still running; replaced in tests
+	IsAlive func(pid int) bool
+	// StartTime returns when a PID's process started; replaced in tests
+	StartTime func(pid int) (time.Time, error)
+}
+
+// New returns a pool keeping clones in dir of the installation source
+// returns at the time of each call
+func New(dir string, source func() (*roblox_install.Installation, error)) *Pool {
+	return &Pool{
+		dir:       dir,
+		doc:       config_store.NewDocumentAt[manifest](filepath.Join(dir, manifestFile)),
+		source:    source,
+		MaxIdle:   DefaultMaxIdle,
+		IsAlive:   ps_darwin.ProcessAlive,
+		StartTime: ps_darwin.ProcessStartTime,
+	}
+}
+
+// DefaultDir returns the directory of the default pool
+func DefaultDir() string {
+	return config_store.Path("clones")
+}
+
+var (
+	defaultMu   sync.Mutex
+	defaultPool *Pool
+)
+
+// Default returns the process-wide pool
+func Default() *Pool {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultPool == nil {
+		defaultPool = New(DefaultDir(), roblox_install.Resolve)
+	}
+	return defaultPool
+}
+
+// SetDefault replaces the process-wide pool (used by tests)
+func SetDefault(pool *Pool) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultPool = pool
+}
+
+// Acquire reserves a clone from the default pool
+func Acquire(onCopy func()) (*Clone, error) {
+	return Default().Acquire(onCopy)
+}
+
+// Lease binds a clone of the default pool to the process running from it
+func Lease(clone *Clone, pid int) error {
+	return Default().Lease(clone, pid)
+}
+
+// Release returns a clone to the default pool
+func Release(clone *Clone) error {
+	return Default().Release(clone)
+}
+
+// GC cleans up the default pool
+func GC() (int, error) {
+	return Default().GC()
+}
+
+// Acquire reserves a free clone, creating one if none is available. onCopy,
+// if not nil, is called before a new clone is copied. The caller should
+// Lease it to the launched PID, or Release it if the launch fails.
+func (p *Pool) Acquire(onCopy func()) (*Clone, error) {
+	install, err := p.source()
+	if err != nil {
+		return nil, err
+	}
+
+	var name string
+	reused := false
+	err = p.doc.Update(func(m *manifest) error {
+		p.invalidate(m, install.Fingerprint())
+		p.collect(m)
+
+		for i := range m.Clones {
+			rec := &m.Clones[i]
+			if rec.free() && !rec.Stale {
+				rec.ReservedAt = time.Now()
+				name, reused = rec.Name, true
+				return nil
+			}
+		}
+		// Reserve the name so the copy can be made without holding the lock
+		name = p.nextName(m)
+		m.Clones = append(m.Clones, cloneRecord{Name: name, ReservedAt: time.Now(), Creating: true})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		logger.LogDebug("Reusing Roblox clone %s", name)
+		return p.clone(name), nil
+	}
+
+	clone := p.clone(name)
+	if onCopy != nil {
+		onCopy()
+	}
+	if err := p.create(install.Path, name); err != nil {
+		if dropErr := p.drop(name); dropErr != nil {
+			logger.LogError("Failed to drop Roblox clone %s from the pool: %v", name, dropErr)
+		}
+		return nil, err
+	}
+	if err := p.update(clone, func(rec *cloneRecord) {
+		rec.Creating = false
+		rec.ReservedAt = time.Now()
+	}); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// Lease binds a clone to the PID running from it
+func (p *Pool) Lease(clone *Clone, pid int) error {
+	start, err := p.StartTime(pid)
+	if err != nil {
+		logger.LogDebug("Couldn't get the start time of PID %d: %v", pid, err)
+	}
+	return p.update(clone, func(rec *cloneRecord) {
+		rec.PID = pid
+		rec.PIDStart = start
+		rec.ReservedAt = time.Now()
+	})
+}
+
+// Release returns a clone to the pool without waiting for GC
+func (p *Pool) Release(clone *Clone) error {
+	return p.update(clone, func(rec *cloneRecord) {
+		rec.PID = 0
+		rec.PIDStart = time.Time{}
+		rec.ReservedAt = time.Time{}
+	})
+}
+
+// GC frees clones whose process has exited, deletes clones of an old Roblox
+// version and any beyond MaxIdle, and removes leftovers of interrupted
+// copies. Returns how many clones were deleted.
+func (p *Pool) GC() (int, error) {
+	// Without an installation there's nothing to compare against, so only
+	// clean up what's no longer in use
+	install, sourceErr := p.source()
+
+	removed := 0
+	err := p.doc.Update(func(m *manifest) error {
+		if sourceErr == nil {
+			p.invalidate(m, install.Fingerprint())
+		}
+		removed = p.collect(m)
+		removed += p.removeOrphans(m)
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+	if removed > 0 {
+		logger.LogInfo("Removed %d unused Roblox clones", removed)
+	}
+	return removed, nil
+}
+
+func (p *Pool) update(clone *Clone, fn func(rec *cloneRecord)) error {
+	return p.doc.Update(func(m *manifest) error {
+		for i := range m.Clones {
+			if m.Clones[i].Name == clone.Name {
+				fn(&m.Clones[i])
+				return nil
+			}
+		}
+		return fmt.Errorf("clone %s is not in the pool", clone.Name)
+	})
+}
+
+// drop removes the record of a clone that couldn't be created
+func (p *Pool) drop(name string) error {
+	return p.doc.Update(func(m *manifest) error {
+		for i := range m.Clones {
+			if m.Clones[i].Name == name {
+				m.Clones = append(m.Clones[:i], m.Clones[i+1:]...)
+				return nil
+			}
+		}
+		return config_store.ErrNoChange
+	})
+}
+
+func (r cloneRecord) free() bool {
+	return r.PID == 0 && r.ReservedAt.IsZero()
+}
+
+// invalidate marks every clone stale when Roblox was updated or another
+// installation was selected
+func (p *Pool) invalidate(m *manifest, fingerprint string) {
+	if m.SourceVersion == fingerprint {
+		return
+	}
+	if m.SourceVersion != "" {
+		logger.LogInfo("Roblox installation changed (%s -> %s), invalidating %d clones", m.SourceVersion, fingerprint, len(m.Clones))
+	}
+	for i := range m.Clones {
+		m.Clones[i].Stale = true
+	}
+	m.SourceVersion = fingerprint
+}
+
+// collect frees clones no longer in use and deletes stale, missing, surplus
+// and never finished ones. Returns how many were deleted.
+func (p *Pool) collect(m *manifest) int {
+	removed := 0
+	idle := 0
+	kept := m.Clones[:0]
+	for _, rec := range m.Clones {
+		if rec.PID > 0 && !p.running(rec) {
+			rec.PID = 0
+			rec.PIDStart = time.Time{}
+			rec.ReservedAt = time.Time{}
+		}
+
+		path := filepath.Join(p.dir, rec.Name)
+		if rec.Creating && time.Since(rec.ReservedAt) > createTimeout {
+			// The run copying it crashed, so it may be incomplete
+			logger.LogInfo("Removing Roblox clone %s left unfinished", rec.Name)
+			err := os.RemoveAll(path + partialExt)
+			if err == nil {
+				err = os.RemoveAll(path)
+			}
+			if err != nil {
+				logger.LogError("Failed to remove Roblox clone %s: %v", rec.Name, err)
+				kept = append(kept, rec)
+				continue
+			}
+			removed++
+			continue
+		}
+		if !rec.Creating && rec.PID == 0 && !rec.ReservedAt.IsZero() && time.Since(rec.ReservedAt) > reserveTimeout {
+			rec.ReservedAt = time.Time{}
+		}
+
+		if rec.free() {
+			_, statErr := os.Stat(path)
+			if rec.Stale || statErr != nil || idle >= p.MaxIdle {
+				if err := os.RemoveAll(path); err != nil {
+					logger.LogError("Failed to remove Roblox clone %s: %v", rec.Name, err)
+					kept = append(kept, rec)
+					continue
+				}
+				if statErr == nil {
+					removed++
+				}
+				continue
+			}
+			idle++
+		}
+		kept = append(kept, rec)
+	}
+	m.Clones = kept
+	return removed
+}
+
+// running reports whether a clone's PID is still the process it was leased
+// to. A PID with another start time has been reused. If the start time can't
+// be read, the process is taken to be the same.
+func (p *Pool) running(rec cloneRecord) bool {
+	if !p.IsAlive(rec.PID) {
+		return false
+	}
+	if rec.PIDStart.IsZero() {
+		return true
+	}
+	start, err := p.StartTime(rec.PID)
+	return err != nil || start.Equal(rec.PIDStart)
+}
+
+// removeOrphans deletes entries of the pool directory the manifest doesn't
+// know about, such as half-finished copies
+func (p *Pool) removeOrphans(m *manifest) int {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return 0
+	}
+
+	known := map[string]bool{manifestFile: true, manifestFile + ".lock": true}
+	for _, rec := range m.Clones {
+		known[rec.Name] = true
+		// Another process may be copying it
+		if rec.Creating {
+			known[rec.Name+partialExt] = true
+		}
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if known[entry.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(p.dir, entry.Name())); err != nil {
+			logger.LogError("Failed to remove %s from clone pool: %v", entry.Name(), err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+func (p *Pool) nextName(m *manifest) string {
+	taken := make(map[string]bool)
+	for _, rec := range m.Clones {
+		taken[rec.Name] = true
+	}
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("Roblox-%d.app", i)
+		if !taken[name] {
+			return name
+		}
+	}
+}
+
+// create clones sourceApp into the pool. The copy is made under a
+// temporary name so an interrupted copy is never handed out.
+func (p *Pool) create(sourceApp, name string) error {
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return err
+	}
+	dest := filepath.Join(p.dir, name)
+	partial := dest + partialExt
+	os.RemoveAll(partial)
+	os.RemoveAll(dest)
+
+	start := time.Now()
+	if err := clonefile(sourceApp, partial); err != nil {
+		logger.LogDebug("clonefile unavailable (%v), copying Roblox app instead", err)
+		os.RemoveAll(partial)
+
+		output, err := exec.Command("cp", "-R", sourceApp, partial).CombinedOutput()
+		if err != nil {
+			os.RemoveAll(partial)
+			return fmt.Errorf("failed to copy Roblox app: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	if err := os.Rename(partial, dest); err != nil {
+		os.RemoveAll(partial)
+		return err
+	}
+	logger.LogInfo("Created Roblox clone %s in %v", name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func (p *Pool) clone(name string) *Clone {
+	return &Clone{Name: name, Path: filepath.Join(p.dir, name)}
+}
+
+// RemoveLegacyCopies deletes the /tmp/RobloxN.app copies made before the
+// pool existed, skipping any a running process was started from
+func RemoveLegacyCopies() {
+	output, err := exec.Command("ps", "-axo", "command=").Output()
+	if err != nil {
+		logger.LogError("Failed to get process list: %v", err)
+		return
+	}
+
+	for i := 2; i <= 10; i++ {
+		path := fmt.Sprintf("/tmp/Roblox%d.app", i)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if strings.Contains(string(output), path+"/") {
+			logger.LogDebug("Skipping %s - still in use", path)
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			logger.LogError("Failed to remove %s: %v", path, err)
+		} else {
+			logger.LogDebug("Removed legacy Roblox copy: %s", path)
+		}
+	}
+}
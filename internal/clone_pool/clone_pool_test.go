@@ -0,0 +1,272 @@
+package clone_pool
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/roblox_install"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeRobloxApp writes a minimal app bundle and returns a source for it
+func fakeRobloxApp(t *testing.T, version string) (string, func() (*roblox_install.Installation, error)) {
+	t.Helper()
+	app := filepath.Join(t.TempDir(), "Roblox.app")
+	writeBundle(t, app, version)
+	return app, func() (*roblox_install.Installation, error) {
+		return roblox_install.Inspect(app)
+	}
+}
+
+func writeBundle(t *testing.T, app, version string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(app, "Contents", "MacOS"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plist := fmt.Sprintf("<plist><dict><key>CFBundleShortVersionString</key><string>%s</string></dict></plist>", version)
+	if err := os.WriteFile(filepath.Join(app, "Contents", "Info.plist"), []byte(plist), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(app, "Contents", "MacOS", "RobloxPlayer"), []byte("player"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolLeasesAndReuses(t *testing.T) {
+	alive := map[int]bool{}
+	_, source := fakeRobloxApp(t, "1.0")
+	pool := New(t.TempDir(), source)
+	pool.IsAlive = func(pid int) bool { return alive[pid] }
+
+	copies := 0
+	onCopy := func() { copies++ }
+	first, err := pool.Acquire(onCopy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copies != 1 {
+		t.Fatalf("new clone reported %d copies, want 1", copies)
+	}
+	if _, err := os.Stat(first.Executable()); err != nil {
+		t.Fatalf("clone has no player binary: %v", err)
+	}
+	alive[100] = true
+	if err := pool.Lease(first, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reserved clone isn't handed out twice, even before it's leased
+	second, err := pool.Acquire(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Name == first.Name {
+		t.Fatalf("leased clone %s handed out again", first.Name)
+	}
+	third, err := pool.Acquire(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Name == first.Name || third.Name == second.Name {
+		t.Fatalf("reserved clone %s handed out again", third.Name)
+	}
+
+	// GC keeps the running clone's files
+	if _, err := pool.GC(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(first.Path); err != nil {
+		t.Fatalf("clone of a running process removed: %v", err)
+	}
+
+	// Once the process exits, the clone is reused
+	alive[100] = false
+	if err := pool.Release(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Release(third); err != nil {
+		t.Fatal(err)
+	}
+	again, err := pool.Acquire(onCopy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Name != first.Name {
+		t.Fatalf("Acquire = %s, want the freed %s", again.Name, first.Name)
+	}
+	if copies != 1 {
+		t.Fatalf("reused clone reported a copy")
+	}
+}
+
+func TestPoolInvalidatesOnVersionChange(t *testing.T) {
+	alive := map[int]bool{}
+	app, source := fakeRobloxApp(t, "1.0")
+	pool := New(t.TempDir(), source)
+	pool.IsAlive = func(pid int) bool { return alive[pid] }
+
+	running, err := pool.Acquire(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alive[7] = true
+	pool.Lease(running, 7)
+
+	idle, err := pool.Acquire(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Release(idle)
+
+	writeBundle(t, app, "2.0")
+	if _, err := pool.GC(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(idle.Path); !os.IsNotExist(err) {
+		t.Fatalf("idle clone of the old version kept: %v", err)
+	}
+	if _, err := os.Stat(running.Path); err != nil {
+		t.Fatalf("old clone removed while still running: %v", err)
+	}
+
+	// The old clone is never handed out again, and goes once its process exits
+	fresh, err := pool.Acquire(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.Name == running.Name {
+		t.Fatal("stale clone handed out")
+	}
+	alive[7] = false
+	if _, err := pool.GC(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(running.Path); !os.IsNotExist(err) {
+		t.Fatalf("stale clone kept after its process exited: %v", err)
+	}
+}
+
+func TestPoolRemovesPartialCopies(t *testing.T) {
+	dir := t.TempDir()
+	_, source := fakeRobloxApp(t, "1.0")
+	pool := New(dir, source)
+	pool.IsAlive = func(int) bool { return false }
+
+	partial := filepath.Join(dir, "Roblox-1.app"+partialExt)
+	if err := os.MkdirAll(partial, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.GC(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Fatal("interrupted copy not removed")
+	}
+}
+
+func TestPoolsSharingADirectory(t *testing.T) {
+	dir := t.TempDir()
+	_, source := fakeRobloxApp(t, "1.0")
+
+	// Pools of separate processes coordinate through the manifest's lock
+	const launches = 4
+	names := make(chan string, launches)
+	errs := make(chan error, launches)
+	for i := 0; i < launches; i++ {
+		go func() {
+			pool := New(dir, source)
+			pool.IsAlive = func(int) bool { return true }
+			clone, err := pool.Acquire(nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			names <- clone.Name
+		}()
+	}
+	seen := map[string]bool{}
+	for i := 0; i < launches; i++ {
+		select {
+		case err := <-errs:
+			t.Fatal(err)
+		case name := <-names:
+			if seen[name] {
+				t.Fatalf("clone %s handed out twice", name)
+			}
+			seen[name] = true
+		}
+	}
+
+	// A copy another process is still making survives GC
+	pool := New(dir, source)
+	pool.IsAlive = func(int) bool { return true }
+	if err := pool.doc.Update(func(m *manifest) error {
+		m.Clones = append(m.Clones, cloneRecord{Name: "Roblox-9.app", ReservedAt: time.Now(), Creating: true})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	partial := filepath.Join(dir, "Roblox-9.app"+partialExt)
+	if err := os.MkdirAll(partial, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.GC(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(partial); err != nil {
+		t.Fatalf("copy in progress removed: %v", err)
+	}
+}
+
+func TestPoolDetectsPIDReuse(t *testing.T) {
+	_, source := fakeRobloxApp(t, "1.0")
+	pool := New(t.TempDir(), source)
+	pool.IsAlive = func(int) bool { return true }
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	pool.StartTime = func(int) (time.Time, error) { return started, nil }
+
+	clone, err := pool.Acquire(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Lease(clone, 42); err != nil {
+		t.Fatal(err)
+	}
+	if next, _ := pool.Acquire(nil); next.Name == clone.Name {
+		t.Fatal("clone of a running process handed out")
+	}
+
+	// Roblox exited and another process got its PID
+	started = started.Add(time.Hour)
+	if next, err := pool.Acquire(nil); err != nil || next.Name != clone.Name {
+		t.Fatalf("Acquire = %+v, %v; want %s freed after its PID was reused", next, err, clone.Name)
+	}
+}
+
+func TestPoolRemovesUnfinishedClones(t *testing.T) {
+	dir := t.TempDir()
+	_, source := fakeRobloxApp(t, "1.0")
+	pool := New(dir, source)
+	pool.IsAlive = func(int) bool { return false }
+
+	// A run crashed while copying, long enough ago that it isn't still going
+	if err := pool.doc.Update(func(m *manifest) error {
+		m.Clones = append(m.Clones, cloneRecord{Name: "Roblox-1.app", ReservedAt: time.Now().Add(-2 * createTimeout), Creating: true})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	half := filepath.Join(dir, "Roblox-1.app")
+	if err := os.MkdirAll(half, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	clone, err := pool.Acquire(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(clone.Executable()); err != nil {
+		t.Fatalf("half-copied clone handed out: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package clone_pool
+
+import "golang.org/x/sys/unix"
+
+// clonefile makes an APFS copy-on-write clone of a directory tree, which
+// takes no extra space until either side is modified
+func clonefile(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
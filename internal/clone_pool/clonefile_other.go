@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package clone_pool
+
+import "errors"
+
+// clonefile is only available on macOS; callers fall back to a full copy
+func clonefile(src, dst string) error {
+	return errors.ErrUnsupported
+}
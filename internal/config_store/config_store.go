@@ -0,0 +1,209 @@
+package config_store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// ErrNoChange, returned from an Update function, skips the save
+var ErrNoChange = errors.New("no change")
+
+var (
+	appDirMu       sync.RWMutex
+	appDirOverride string
+)
+
+// AppDir returns the directory app data is stored in:
+// ~/Library/Application Support/multi_roblox_macos unless overridden
+func AppDir() string {
+	appDirMu.RLock()
+	defer appDirMu.RUnlock()
+
+	if appDirOverride != "" {
+		return appDirOverride
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "Application Support", "multi_roblox_macos")
+}
+
+// SetAppDir overrides the app directory, as tests do; "" restores the default
+func SetAppDir(dir string) {
+	appDirMu.Lock()
+	defer appDirMu.Unlock()
+	appDirOverride = dir
+}
+
+// Path returns the path of a file in the app directory
+func Path(name string) string {
+	return filepath.Join(AppDir(), name)
+}
+
+// WriteFileAtomic writes data to a temp file next to path and renames it into
+// place, so readers and crashes never see a partly written file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// lock takes a flock on a sidecar lock file for path. The data file itself
+// can't be locked since every save replaces it. Locks are per open file, so
+// they also exclude other goroutines in this process.
+func lock(path string, how int) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", filepath.Base(path), err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// WriteFile replaces a file in the app directory with raw data, locked like
+// a document save
+func WriteFile(name string, data []byte) error {
+	path := Path(name)
+	unlock, err := lock(path, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return WriteFileAtomic(path, data, 0600)
+}
+
+// Document is a JSON file in the app directory holding a T. Reads, writes
+// and updates are locked against other goroutines and processes, and writes
+// are atomic.
+//
+// An Update function must not call back into the same document; it would
+// wait on its own lock.
+type Document[T any] struct {
+	name string
+	path string // Set for documents outside the app directory
+}
+
+// NewDocument returns the document stored as name in the app directory
+func NewDocument[T any](name string) *Document[T] {
+	return &Document[T]{name: name}
+}
+
+// NewDocumentAt returns the document stored at path, for data kept outside
+// the app directory
+func NewDocumentAt[T any](path string) *Document[T] {
+	return &Document[T]{name: filepath.Base(path), path: path}
+}
+
+// Path returns where the document is stored
+func (d *Document[T]) Path() string {
+	if d.path != "" {
+		return d.path
+	}
+	return Path(d.name)
+}
+
+// Load reads the document. A missing file loads as T's zero value.
+func (d *Document[T]) Load() (T, error) {
+	path := d.Path()
+	unlock, err := lock(path, syscall.LOCK_SH)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer unlock()
+	return d.read(path)
+}
+
+// Save replaces the document with v
+func (d *Document[T]) Save(v T) error {
+	path := d.Path()
+	unlock, err := lock(path, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return d.write(path, v)
+}
+
+// Update reads the document, applies fn and saves the result, holding the
+// lock throughout so concurrent updates can't lose each other's changes.
+// If fn returns an error nothing is saved; ErrNoChange isn't reported.
+func (d *Document[T]) Update(fn func(v *T) error) error {
+	path := d.Path()
+	unlock, err := lock(path, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	v, err := d.read(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(&v); err != nil {
+		if errors.Is(err, ErrNoChange) {
+			return nil
+		}
+		return err
+	}
+	return d.write(path, v)
+}
+
+func (d *Document[T]) read(path string) (T, error) {
+	var v T
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("failed to parse %s: %w", d.name, err)
+	}
+	return v, nil
+}
+
+func (d *Document[T]) write(path string, v T) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, 0600) // Secure permissions - owner only
+}
@@ -0,0 +1,138 @@
+package config_store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type counter struct {
+	Count int `json:"count"`
+}
+
+func useTempAppDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	SetAppDir(dir)
+	t.Cleanup(func() { SetAppDir("") })
+	return dir
+}
+
+func TestDocumentLoadSave(t *testing.T) {
+	dir := useTempAppDir(t)
+	doc := NewDocument[counter]("counter.json")
+
+	if got := doc.Path(); got != filepath.Join(dir, "counter.json") {
+		t.Fatalf("Path() = %s", got)
+	}
+
+	// Missing files load as the zero value
+	if c, err := doc.Load(); err != nil || c.Count != 0 {
+		t.Fatalf("Load() of missing file = %+v, %v", c, err)
+	}
+
+	if err := doc.Save(counter{Count: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := doc.Load(); err != nil || c.Count != 3 {
+		t.Fatalf("Load() = %+v, %v", c, err)
+	}
+
+	info, err := os.Stat(doc.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("saved with mode %v, want 0600", perm)
+	}
+
+	// Nothing is left behind but the document and its lock
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Fatalf("temp file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestDocumentUpdateIsSerialized(t *testing.T) {
+	useTempAppDir(t)
+	doc := NewDocument[counter]("counter.json")
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := doc.Update(func(c *counter) error {
+				c.Count++
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c, _ := doc.Load(); c.Count != writers {
+		t.Fatalf("count = %d after %d concurrent updates", c.Count, writers)
+	}
+}
+
+func TestDocumentUpdateErrors(t *testing.T) {
+	useTempAppDir(t)
+	doc := NewDocument[counter]("counter.json")
+	if err := doc.Save(counter{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// ErrNoChange skips the save without an error
+	if err := doc.Update(func(c *counter) error {
+		c.Count = 99
+		return ErrNoChange
+	}); err != nil {
+		t.Fatalf("Update with ErrNoChange = %v", err)
+	}
+
+	failure := errors.New("invalid")
+	if err := doc.Update(func(c *counter) error {
+		c.Count = 99
+		return failure
+	}); !errors.Is(err, failure) {
+		t.Fatalf("Update = %v, want %v", err, failure)
+	}
+
+	if c, _ := doc.Load(); c.Count != 1 {
+		t.Fatalf("count = %d, want unchanged 1", c.Count)
+	}
+
+	// A corrupt file is reported, not silently replaced
+	if err := os.WriteFile(doc.Path(), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.Load(); err == nil {
+		t.Fatal("Load() of corrupt file succeeded")
+	}
+}
+
+func TestDocumentAtPath(t *testing.T) {
+	useTempAppDir(t)
+	path := filepath.Join(t.TempDir(), "pool", "pool.json")
+	doc := NewDocumentAt[counter](path)
+	if doc.Path() != path {
+		t.Fatalf("Path() = %s, want %s", doc.Path(), path)
+	}
+	if err := doc.Update(func(c *counter) error {
+		c.Count = 2
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("document not written to its path: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package cookie_manager
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/browser_source"
+	"insadem/multi_roblox_macos/internal/chromium_cookies"
+	"insadem/multi_roblox_macos/internal/config_store"
+	"insadem/multi_roblox_macos/internal/logger"
+)
+
+// BrowserSelection is the browser and profile cookies are captured from
+type BrowserSelection struct {
+	Browser string `json:"browser"`
+	Profile string `json:"profile"` // Profile directory; empty means the browser's first profile
+}
+
+// safeStoragePasswords supplies the browser's Safe Storage password for
+// cookie decryption. Tests replace it to read fixture databases.
+var safeStoragePasswords chromium_cookies.PasswordProvider = chromium_cookies.KeychainPasswordProvider{}
+
+// SetSafeStoragePasswordProvider replaces the provider used to decrypt browser cookies
+func SetSafeStoragePasswordProvider(provider chromium_cookies.PasswordProvider) {
+	safeStoragePasswords = provider
+}
+
+var browserSelectionDoc = config_store.NewDocument[BrowserSelection]("browser.json")
+
+// GetBrowserSelection returns the saved browser selection. Without one it
+// picks Vivaldi if installed (the only browser older versions supported),
+// otherwise the first installed browser.
+func GetBrowserSelection() BrowserSelection {
+	if selection, err := browserSelectionDoc.Load(); err == nil && selection.Browser != "" {
+		return selection
+	}
+
+	installed := browser_source.Installed()
+	if len(installed) == 0 {
+		return BrowserSelection{Browser: browser_source.Vivaldi().Name()}
+	}
+	return BrowserSelection{Browser: installed[0].Name()}
+}
+
+// SetBrowserSelection saves the browser and profile to capture cookies from
+func SetBrowserSelection(selection BrowserSelection) error {
+	logger.LogInfo("Browser selection set to %s (%s)", selection.Browser, selection.Profile)
+	return browserSelectionDoc.Save(selection)
+}
+
+// GetBrowser returns the named browser source, wired to the configured
+// Safe Storage password provider
+func GetBrowser(name string) (browser_source.BrowserSource, error) {
+	source, err := browser_source.ByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if chromium, ok := source.(*browser_source.ChromiumSource); ok {
+		chromium.Passwords = safeStoragePasswords
+	}
+	return source, nil
+}
+
+// GetSelectedBrowser resolves the saved selection to a browser and profile
+func GetSelectedBrowser() (browser_source.BrowserSource, browser_source.Profile, error) {
+	selection := GetBrowserSelection()
+
+	source, err := GetBrowser(selection.Browser)
+	if err != nil {
+		return nil, browser_source.Profile{}, err
+	}
+
+	profile, err := browser_source.FindProfile(source, selection.Profile)
+	if err != nil {
+		return source, browser_source.Profile{}, err
+	}
+	return source, profile, nil
+}
+
+// GetRobloxCookieFrom reads the .ROBLOSECURITY cookie from a browser profile
+func GetRobloxCookieFrom(source browser_source.BrowserSource, profile browser_source.Profile) (*RobloxCookie, error) {
+	logger.LogInfo("Reading .ROBLOSECURITY cookie from %s (%s)", source.Name(), profile.Name)
+
+	cookie, err := source.ReadRobloxCookie(profile)
+	if err != nil {
+		return nil, fmt.Errorf("%w - are you logged into Roblox in %s?", err, source.Name())
+	}
+
+	var expiresUTC int64
+	if !cookie.Expires.IsZero() {
+		expiresUTC = cookie.Expires.Unix()
+	}
+
+	logger.LogInfo("Successfully read .ROBLOSECURITY cookie (length: %d)", len(cookie.Value))
+
+	return &RobloxCookie{
+		Value:      cookie.Value,
+		ExpiresUTC: expiresUTC,
+	}, nil
+}
+
+// GetCurrentRobloxCookie reads the current .ROBLOSECURITY cookie from the selected browser
+func GetCurrentRobloxCookie() (*RobloxCookie, error) {
+	source, profile, err := GetSelectedBrowser()
+	if err != nil {
+		return nil, err
+	}
+	return GetRobloxCookieFrom(source, profile)
+}
+
+// IsBrowserRunning reports whether the selected browser is running
+func IsBrowserRunning() bool {
+	source, err := GetBrowser(GetBrowserSelection().Browser)
+	if err != nil {
+		return false
+	}
+	return source.IsRunning()
+}
+
+// GetSelectedBrowserName returns the display name of the selected browser
+func GetSelectedBrowserName() string {
+	return GetBrowserSelection().Browser
+}
+
+// ClearBrowserRobloxCookies clears Roblox cookies from the selected browser,
+// quitting it first. This forces the user to log in again when visiting Roblox.
+func ClearBrowserRobloxCookies() error {
+	source, profile, err := GetSelectedBrowser()
+	if err != nil {
+		return err
+	}
+
+	logger.LogInfo("Clearing Roblox cookies from %s (%s)...", source.Name(), profile.Name)
+
+	// The browser writes its in-memory cookies back on exit, so quit it first
+	if err := source.Quit(); err != nil {
+		logger.LogError("%v", err)
+	}
+
+	if err := source.ClearRobloxCookies(profile); err != nil {
+		logger.LogError("Failed to clear %s cookies: %v", source.Name(), err)
+		return fmt.Errorf("failed to clear cookies: %w", err)
+	}
+
+	logger.LogInfo("%s Roblox cookies cleared successfully", source.Name())
+	return nil
+}
+
+// QuitSelectedBrowser quits the selected browser if it is running
+func QuitSelectedBrowser() error {
+	source, err := GetBrowser(GetBrowserSelection().Browser)
+	if err != nil {
+		return err
+	}
+	return source.Quit()
+}
@@ -0,0 +1,178 @@
+package cookie_manager
+
+import (
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/fake_roblox"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"insadem/multi_roblox_macos/internal/secret_store"
+	"testing"
+	"time"
+)
+
+func TestValidateCookieForAccount(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 1, Name: "Builderman", Cookie: "good"})
+
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+
+	if result := ValidateCookieForAccount("account_1"); result.Status != CookieStatusNone {
+		t.Fatalf("no cookie: got status %v", result.Status)
+	}
+
+	if err := SaveCookieForAccount("account_1", &RobloxCookie{Value: "good"}); err != nil {
+		t.Fatal(err)
+	}
+	if result := ValidateCookieForAccount("account_1"); result.Status != CookieStatusValid || result.Username != "Builderman" {
+		t.Fatalf("valid cookie: got %+v", result)
+	}
+
+	if err := SaveCookieForAccount("account_1", &RobloxCookie{Value: "revoked"}); err != nil {
+		t.Fatal(err)
+	}
+	if result := ValidateCookieForAccount("account_1"); result.Status != CookieStatusExpired {
+		t.Fatalf("revoked cookie: got %+v", result)
+	}
+
+	server.Close()
+	if result := ValidateCookieForAccount("account_1"); result.Status != CookieStatusError {
+		t.Fatalf("unreachable server: got %+v", result)
+	}
+}
+
+func TestCookieExpiryMetadata(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 7, Name: "Builderman", Cookie: "good"})
+
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+
+	expires := time.Now().Add(3*24*time.Hour + time.Hour)
+	if err := SaveCookieForAccount("account_1", &RobloxCookie{Value: "good", ExpiresUTC: expires.Unix()}); err != nil {
+		t.Fatal(err)
+	}
+
+	cookie, err := GetCookieForAccount("account_1")
+	if err != nil || cookie.ExpiresUTC != expires.Unix() {
+		t.Fatalf("GetCookieForAccount = %+v, %v", cookie, err)
+	}
+
+	result := ValidateCookieForAccount("account_1")
+	if result.Status != CookieStatusValid || result.DaysUntilExpiry != 3 || !result.ExpiresWarning || result.UserID != 7 {
+		t.Fatalf("ValidateCookieForAccount = %+v", result)
+	}
+
+	meta, err := GetCookieMetadata("account_1")
+	if err != nil || meta.LastValidatedUserID != 7 || meta.LastValidatedUsername != "Builderman" || meta.CapturedAt.IsZero() {
+		t.Fatalf("GetCookieMetadata = %+v, %v", meta, err)
+	}
+
+	results := AutoRefreshExpiredCookies([]account_manager.Account{{ID: "account_1", Username: "Builderman"}})
+	if len(results) != 1 || !results[0].ExpiresSoon || results[0].DaysUntilExpiry != 3 {
+		t.Fatalf("AutoRefreshExpiredCookies = %+v", results)
+	}
+
+	if err := ClearSavedCookie("account_1"); err != nil {
+		t.Fatal(err)
+	}
+	if meta, _ := GetCookieMetadata("account_1"); !meta.ExpiresAt.IsZero() {
+		t.Fatalf("metadata survived ClearSavedCookie")
+	}
+}
+
+func TestCookieBindsAccountByUserID(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 7, Name: "Builderman", Cookie: "mine"})
+	server.AddUser(fake_roblox.User{ID: 8, Name: "Someone", Cookie: "theirs"})
+
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+
+	if err := account_manager.AddAccount("builderman", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	accounts, _ := account_manager.LoadAccounts()
+	account := accounts[0]
+	if account.UserID != 7 || account.Username != "Builderman" {
+		t.Fatalf("AddAccount stored %+v", account)
+	}
+
+	// A cookie for another user must not be accepted for this account
+	if err := SaveCookieForAccount(account.ID, &RobloxCookie{Value: "theirs"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PreLaunchCookieCheck(account); err == nil {
+		t.Fatalf("PreLaunchCookieCheck accepted another user's cookie")
+	}
+
+	// A rename upstream is followed on the next validation
+	server.AddUser(fake_roblox.User{ID: 7, Name: "Builderman2", Cookie: "mine"})
+	if err := SaveCookieForAccount(account.ID, &RobloxCookie{Value: "mine"}); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := PreLaunchCookieCheck(account); err != nil || value != "mine" {
+		t.Fatalf("PreLaunchCookieCheck = %q, %v", value, err)
+	}
+	if renamed, _ := account_manager.GetAccount(account.ID); renamed.Username != "Builderman2" || renamed.UserID != 7 {
+		t.Fatalf("account not re-synced: %+v", renamed)
+	}
+}
+
+// countingStore counts writes to the secret store
+type countingStore struct {
+	secret_store.SecretStore
+	sets int
+}
+
+func (s *countingStore) Set(service, account, secret string) error {
+	s.sets++
+	return s.SecretStore.Set(service, account, secret)
+}
+
+func TestValidationSavesOnlyChanges(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store := &countingStore{SecretStore: secret_store.NewMemoryStore()}
+	secret_store.SetDefault(store)
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 7, Name: "Builderman", Cookie: "good"})
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+
+	if err := SaveCookieForAccount("account_1", &RobloxCookie{Value: "good"}); err != nil {
+		t.Fatal(err)
+	}
+	ValidateCookieForAccount("account_1")
+	saved := store.sets
+
+	// Nothing new to record
+	ValidateCookieForAccount("account_1")
+	if store.sets != saved {
+		t.Fatalf("unchanged validation wrote %d secrets", store.sets-saved)
+	}
+
+	// A stale validation time is refreshed
+	meta, _ := GetCookieMetadata("account_1")
+	meta.LastValidatedAt = time.Now().Add(-validationSaveInterval - time.Minute)
+	if err := saveCookieMetadata("account_1", meta); err != nil {
+		t.Fatal(err)
+	}
+	saved = store.sets
+	ValidateCookieForAccount("account_1")
+	if store.sets != saved+1 {
+		t.Fatalf("stale validation wrote %d secrets, want 1", store.sets-saved)
+	}
+}
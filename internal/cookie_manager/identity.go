@@ -0,0 +1,51 @@
+package cookie_manager
+
+import (
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"strings"
+)
+
+// UserMatchesAccount reports whether a cookie's Roblox user is the account's
+// user. Accounts are compared by user ID; the username is only consulted for
+// accounts saved before IDs were tracked.
+func UserMatchesAccount(user *roblox_api.UserInfo, account account_manager.Account) bool {
+	if user == nil {
+		return false
+	}
+	if account.UserID != 0 {
+		return account.UserID == user.UserID
+	}
+	return strings.EqualFold(account.Username, user.Username)
+}
+
+// VerifyCookieUser returns the Roblox user a cookie belongs to
+func VerifyCookieUser(cookieValue string) (*roblox_api.UserInfo, error) {
+	logger.LogInfo("Verifying cookie against Roblox API...")
+	return roblox_api.GetAuthenticatedUser(cookieValue)
+}
+
+// GetCurrentBrowserCookieUser gets the Roblox user of the selected browser's session
+func GetCurrentBrowserCookieUser() (*roblox_api.UserInfo, error) {
+	cookie, err := GetCurrentRobloxCookie()
+	if err != nil {
+		return nil, err
+	}
+	return VerifyCookieUser(cookie.Value)
+}
+
+// syncAccountIdentity binds the account to the cookie's user ID and follows
+// upstream username changes, if the cookie is the account's
+func syncAccountIdentity(accountID string, user *roblox_api.UserInfo) {
+	account, err := account_manager.GetAccount(accountID)
+	if err != nil || !UserMatchesAccount(user, *account) {
+		return
+	}
+	if account.UserID == user.UserID && account.Username == user.Username {
+		return
+	}
+	if err := account_manager.SyncAccountIdentity(accountID, user.UserID, user.Username); err != nil {
+		logger.LogError("Failed to sync account %s: %v", accountID, err)
+	}
+}
@@ -0,0 +1,90 @@
+package cookie_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"insadem/multi_roblox_macos/internal/secret_store"
+	"time"
+)
+
+// cookieMetaService is the secret store service for cookie metadata, kept
+// under the same account key as the cookie itself
+const cookieMetaService = "multi-roblox-cookie-meta"
+
+// ExpiryWarningDays is how many days before expiry a cookie is flagged
+var ExpiryWarningDays = 7
+
+// validationSaveInterval is how old LastValidatedAt may get before a
+// validation that found nothing new is saved anyway. Each save is a
+// Keychain write, and cookies are validated before every launch.
+const validationSaveInterval = 24 * time.Hour
+
+// CookieMetadata describes a saved cookie
+type CookieMetadata struct {
+	CapturedAt            time.Time `json:"capturedAt"`
+	ExpiresAt             time.Time `json:"expiresAt"`             // From the browser; zero if unknown
+	LastValidatedAt       time.Time `json:"lastValidatedAt"`       // Last time Roblox accepted the cookie
+	LastValidatedUsername string    `json:"lastValidatedUsername"` // Username Roblox reported at that time
+	LastValidatedUserID   int64     `json:"lastValidatedUserId"`
+}
+
+// GetCookieMetadata returns the metadata saved with an account's cookie.
+// Cookies saved by older versions have none; a zero value is returned.
+func GetCookieMetadata(accountID string) (*CookieMetadata, error) {
+	data, err := secret_store.Default().Get(cookieMetaService, accountID)
+	if errors.Is(err, secret_store.ErrNotFound) {
+		return &CookieMetadata{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var meta CookieMetadata
+	if err := json.Unmarshal([]byte(data), &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse cookie metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// saveCookieMetadata stores metadata next to an account's cookie
+func saveCookieMetadata(accountID string, meta *CookieMetadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return secret_store.Default().Set(cookieMetaService, accountID, string(data))
+}
+
+// recordValidation notes that Roblox accepted the cookie as user. It
+// reports whether anything changed enough to be worth saving.
+func (m *CookieMetadata) recordValidation(user *roblox_api.UserInfo, now time.Time) bool {
+	if m.LastValidatedUsername == user.Username && m.LastValidatedUserID == user.UserID &&
+		now.Sub(m.LastValidatedAt) < validationSaveInterval {
+		return false
+	}
+	m.LastValidatedAt = now
+	m.LastValidatedUsername = user.Username
+	m.LastValidatedUserID = user.UserID
+	return true
+}
+
+// DaysUntilExpiry returns whole days left before the cookie expires,
+// 0 if it already has, or -1 if the expiry is unknown
+func (m *CookieMetadata) DaysUntilExpiry() int {
+	if m.ExpiresAt.IsZero() {
+		return -1
+	}
+	days := int(time.Until(m.ExpiresAt).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// ExpiresSoon reports whether the cookie expires within ExpiryWarningDays
+func (m *CookieMetadata) ExpiresSoon() bool {
+	days := m.DaysUntilExpiry()
+	return days >= 0 && days <= ExpiryWarningDays
+}
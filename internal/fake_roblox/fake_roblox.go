@@ -0,0 +1,496 @@
+// fake_roblox is an in-process stand-in for the Roblox web APIs, for tests
+// that must not touch the network. One httptest server answers for every
+// host (users, games, presence, thumbnails, apis, auth, gamejoin); point a
+// roblox_api client at it with roblox_api.NewClientWithBaseURL(server.URL).
+package fake_roblox
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CSRFToken is the token the fake auth endpoint demands on its 403 challenge
+const CSRFToken = "fake-csrf-token"
+
+// User is a canned Roblox account
+type User struct {
+	ID          int64
+	Name        string
+	DisplayName string
+	Cookie      string // .ROBLOSECURITY value that authenticates as this user
+}
+
+// Game is a canned experience
+type Game struct {
+	PlaceID     int64
+	UniverseID  int64
+	Name        string
+	Description string
+}
+
+// Presence is a canned presence entry
+type Presence struct {
+	UserPresenceType int // 0=Offline, 1=Online, 2=InGame, 3=InStudio
+	LastLocation     string
+	PlaceID          int64
+	RootPlaceID      int64
+	UniverseID       int64
+	GameID           string
+}
+
+// GameServer is a canned public server
+type GameServer struct {
+	JobID      string
+	Playing    int
+	MaxPlayers int
+	Ping       int
+	FPS        float64
+}
+
+// ShareLink is what a share code resolves to
+type ShareLink struct {
+	PlaceID               int64
+	PrivateServerLinkCode string
+	PrivateServerID       int64
+}
+
+// Server is a fake Roblox API server with mutable canned data
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	users      map[int64]User
+	games      map[int64]Game // by place ID
+	presence   map[int64]Presence
+	shareLinks map[string]ShareLink
+	noFollow   map[int64]bool
+	servers    map[int64][]GameServer // by place ID
+	tickets    int
+	requests   map[string]int // Hits per path
+}
+
+// NewServer starts a fake server; callers must Close it
+func NewServer() *Server {
+	s := &Server{
+		users:      make(map[int64]User),
+		games:      make(map[int64]Game),
+		presence:   make(map[int64]Presence),
+		shareLinks: make(map[string]ShareLink),
+		noFollow:   make(map[int64]bool),
+		servers:    make(map[int64][]GameServer),
+		requests:   make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/usernames/users", s.handleUsernames)
+	mux.HandleFunc("/v1/users/authenticated", s.handleAuthenticated)
+	mux.HandleFunc("/v1/users/", s.handleUser)
+	mux.HandleFunc("/v1/games", s.handleGames)
+	mux.HandleFunc("/v1/games/icons", s.handleGameIcons)
+	mux.HandleFunc("/v1/games/", s.handlePublicServers)
+	mux.HandleFunc("/v1/users/avatar-headshot", s.handleAvatar)
+	mux.HandleFunc("/universes/v1/places/", s.handleUniverse)
+	mux.HandleFunc("/v1/presence/users", s.handlePresence)
+	mux.HandleFunc("/share-links/v1/resolve-link", s.handleShareLink)
+	mux.HandleFunc("/v1/authentication-ticket", s.handleAuthTicket)
+	mux.HandleFunc("/v1/join-private-game", s.handleJoinPrivateGame)
+	mux.HandleFunc("/v1/play-with-user", s.handlePlayWithUser)
+	mux.HandleFunc("/images/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	})
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// RequestCount returns how many requests were made to path, for asserting
+// on call patterns
+func (s *Server) RequestCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// AddUser registers a user; a non-empty Cookie authenticates as them
+func (s *Server) AddUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u.DisplayName == "" {
+		u.DisplayName = u.Name
+	}
+	s.users[u.ID] = u
+}
+
+// AddGame registers a place and its universe
+func (s *Server) AddGame(g Game) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.games[g.PlaceID] = g
+}
+
+// SetPresence sets what the presence API reports for a user
+func (s *Server) SetPresence(userID int64, p Presence) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.presence[userID] = p
+}
+
+// AddShareLink registers a share code
+func (s *Server) AddShareLink(code string, link ShareLink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shareLinks[code] = link
+}
+
+// userByCookie finds the user a request's .ROBLOSECURITY cookie belongs to
+func (s *Server) userByCookie(r *http.Request) (User, bool) {
+	cookie, err := r.Cookie(".ROBLOSECURITY")
+	if err != nil || cookie.Value == "" {
+		return User{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Cookie == cookie.Value {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+func (s *Server) handleUsernames(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Usernames []string `json:"usernames"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	type entry struct {
+		RequestedUsername string `json:"requestedUsername"`
+		ID                int64  `json:"id"`
+		Name              string `json:"name"`
+		DisplayName       string `json:"displayName"`
+	}
+	data := []entry{}
+
+	s.mu.Lock()
+	for _, name := range req.Usernames {
+		for _, u := range s.users {
+			if strings.EqualFold(u.Name, name) {
+				data = append(data, entry{name, u.ID, u.Name, u.DisplayName})
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]any{"data": data})
+}
+
+func (s *Server) handleAuthenticated(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.userByCookie(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, map[string]any{"id": u.ID, "name": u.Name, "displayName": u.DisplayName})
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/v1/users/"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	u, ok := s.users[id]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]any{"id": u.ID, "name": u.Name, "displayName": u.DisplayName})
+}
+
+func (s *Server) handleUniverse(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/universes/v1/places/")
+	placeID, err := strconv.ParseInt(strings.TrimSuffix(rest, "/universe"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	g, ok := s.games[placeID]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]any{"universeId": g.UniverseID})
+}
+
+func (s *Server) handleGames(w http.ResponseWriter, r *http.Request) {
+	ids := parseIDs(r.URL.Query().Get("universeIds"))
+	data := []map[string]any{}
+
+	s.mu.Lock()
+	for _, g := range s.games {
+		if ids[g.UniverseID] {
+			data = append(data, map[string]any{
+				"id":          g.UniverseID,
+				"rootPlaceId": g.PlaceID,
+				"name":        g.Name,
+				"description": g.Description,
+			})
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]any{"data": data})
+}
+
+func (s *Server) handleGameIcons(w http.ResponseWriter, r *http.Request) {
+	data := []map[string]any{}
+	for id := range parseIDs(r.URL.Query().Get("universeIds")) {
+		data = append(data, map[string]any{
+			"targetId": id,
+			"imageUrl": fmt.Sprintf("%s/images/game-%d.png", s.URL, id),
+		})
+	}
+	writeJSON(w, map[string]any{"data": data})
+}
+
+func (s *Server) handleAvatar(w http.ResponseWriter, r *http.Request) {
+	data := []map[string]any{}
+	for id := range parseIDs(r.URL.Query().Get("userIds")) {
+		data = append(data, map[string]any{
+			"targetId": id,
+			"imageUrl": fmt.Sprintf("%s/images/avatar-%d.png", s.URL, id),
+		})
+	}
+	writeJSON(w, map[string]any{"data": data})
+}
+
+func (s *Server) handlePresence(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserIDs []int64 `json:"userIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	presences := []map[string]any{}
+	s.mu.Lock()
+	for _, id := range req.UserIDs {
+		p := s.presence[id]
+		presences = append(presences, map[string]any{
+			"userId":           id,
+			"userPresenceType": p.UserPresenceType,
+			"lastLocation":     p.LastLocation,
+			"placeId":          p.PlaceID,
+			"rootPlaceId":      p.RootPlaceID,
+			"universeId":       p.UniverseID,
+			"gameId":           p.GameID,
+		})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]any{"userPresences": presences})
+}
+
+func (s *Server) handleShareLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		LinkID string `json:"linkId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	link, ok := s.shareLinks[req.LinkID]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"errors":[{"message":"link not found"}]}`, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"placeId":               link.PlaceID,
+		"privateServerLinkCode": link.PrivateServerLinkCode,
+		"privateServerId":       link.PrivateServerID,
+	})
+}
+
+func (s *Server) handleAuthTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	u, ok := s.userByCookie(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Like the real endpoint, the first call is answered with a CSRF challenge
+	if r.Header.Get("X-CSRF-TOKEN") != CSRFToken {
+		w.Header().Set("x-csrf-token", CSRFToken)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	s.tickets++
+	ticket := fmt.Sprintf("ticket-%d-%d", u.ID, s.tickets)
+	s.mu.Unlock()
+
+	w.Header().Set("rbx-authentication-ticket", ticket)
+	w.WriteHeader(http.StatusOK)
+}
+
+// AddServers adds public servers to a place
+func (s *Server) AddServers(placeID int64, servers ...GameServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servers[placeID] = append(s.servers[placeID], servers...)
+}
+
+// handlePublicServers serves /v1/games/{placeId}/servers/Public. Cursors
+// are plain offsets.
+func (s *Server) handlePublicServers(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 5 || parts[3] != "servers" || parts[4] != "Public" {
+		http.NotFound(w, r)
+		return
+	}
+	placeID, _ := strconv.ParseInt(parts[2], 10, 64)
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 10
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+
+	s.mu.Lock()
+	servers := s.servers[placeID]
+	s.mu.Unlock()
+
+	data := []map[string]any{}
+	end := min(offset+limit, len(servers))
+	for _, gs := range servers[min(offset, end):end] {
+		data = append(data, map[string]any{
+			"id":         gs.JobID,
+			"playing":    gs.Playing,
+			"maxPlayers": gs.MaxPlayers,
+			"ping":       gs.Ping,
+			"fps":        gs.FPS,
+		})
+	}
+
+	var next any
+	if end < len(servers) {
+		next = strconv.Itoa(end)
+	}
+	writeJSON(w, map[string]any{"previousPageCursor": nil, "nextPageCursor": next, "data": data})
+}
+
+// BlockFollow makes follow requests for a user fail, as if their privacy
+// settings didn't allow joining
+func (s *Server) BlockFollow(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noFollow[userID] = true
+}
+
+func (s *Server) handlePlayWithUser(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.userByCookie(r); !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("X-CSRF-TOKEN") != CSRFToken {
+		w.Header().Set("x-csrf-token", CSRFToken)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		UserIDToFollow int64 `json:"userIdToFollow"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	p := s.presence[req.UserIDToFollow]
+	blocked := s.noFollow[req.UserIDToFollow]
+	s.mu.Unlock()
+
+	switch {
+	case blocked:
+		writeJSON(w, map[string]any{"status": 12, "message": "You don't have permission to join this game."})
+	case p.UserPresenceType != 2:
+		writeJSON(w, map[string]any{"status": 10, "message": "The user you are trying to join is not in a game."})
+	default:
+		writeJSON(w, map[string]any{
+			"jobId":      p.GameID,
+			"status":     2,
+			"joinScript": map[string]any{"PlaceId": p.PlaceID, "GameId": p.GameID},
+		})
+	}
+}
+
+func (s *Server) handleJoinPrivateGame(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.userByCookie(r); !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		PlaceID    int64  `json:"placeId"`
+		AccessCode string `json:"accessCode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"jobId":      fmt.Sprintf("job-%d-%s", req.PlaceID, req.AccessCode),
+		"status":     2,
+		"joinScript": "",
+	})
+}
+
+// parseIDs parses a comma-separated ID list into a set
+func parseIDs(list string) map[int64]bool {
+	ids := make(map[int64]bool)
+	for _, part := range strings.Split(list, ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
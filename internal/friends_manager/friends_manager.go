@@ -1,11 +1,11 @@
 package friends_manager
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"insadem/multi_roblox_macos/internal/logger"
-	"os"
-	"path/filepath"
+	"insadem/multi_roblox_macos/internal/state_db"
 	"sync"
 	"time"
 )
@@ -27,6 +27,8 @@ type Friend struct {
 	DisplayName string    `json:"display_name,omitempty"`
 	AddedAt     time.Time `json:"added_at"`
 	Notes       string    `json:"notes,omitempty"`
+
+	LastAccountUsed string `json:"last_account_used,omitempty"` // Account last used to join this friend
 }
 
 // FriendStatus represents current status of a friend
@@ -39,7 +41,7 @@ type FriendStatus struct {
 	LastUpdated time.Time    `json:"last_updated"`
 }
 
-// FriendsConfig stores all saved friends
+// FriendsConfig is the format of the friends JSON file
 type FriendsConfig struct {
 	Friends []Friend `json:"friends"`
 }
@@ -49,124 +51,143 @@ var (
 	statusCacheLock sync.RWMutex
 )
 
-// GetConfigPath returns the path to the friends config file
-func GetConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	configDir := filepath.Join(homeDir, "Library", "Application Support", "multi_roblox_macos")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return "", err
-	}
-	return filepath.Join(configDir, "friends.json"), nil
+// friendsFile is the JSON file friends were kept in before the state database
+const friendsFile = "friends.json"
+
+func init() {
+	state_db.RegisterJSONSource(state_db.JSONSource{
+		File: friendsFile,
+		Import: func(tx *sql.Tx, data []byte) error {
+			var config FriendsConfig
+			if err := json.Unmarshal(data, &config); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("DELETE FROM friends"); err != nil {
+				return err
+			}
+			for _, f := range config.Friends {
+				if err := insertFriend(tx, f); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Export: func(db *sql.DB) ([]byte, error) {
+			friends, err := queryFriends(db)
+			if err != nil {
+				return nil, err
+			}
+			return json.MarshalIndent(FriendsConfig{Friends: friends}, "", "  ")
+		},
+	})
 }
 
-// LoadFriends loads friends from config file
+// LoadFriends loads all saved friends in the order they were added
 func LoadFriends() ([]Friend, error) {
-	configPath, err := GetConfigPath()
+	db, err := state_db.Default()
 	if err != nil {
 		return nil, err
 	}
+	return queryFriends(db)
+}
 
-	data, err := os.ReadFile(configPath)
+func queryFriends(db *sql.DB) ([]Friend, error) {
+	rows, err := db.Query(`SELECT user_id, username, display_name, added_at, notes, last_account_used
+		FROM friends ORDER BY seq`)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []Friend{}, nil
-		}
 		return nil, err
 	}
+	defer rows.Close()
 
-	var config FriendsConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+	friends := []Friend{}
+	for rows.Next() {
+		var f Friend
+		if err := rows.Scan(&f.UserID, &f.Username, &f.DisplayName, &f.AddedAt, &f.Notes, &f.LastAccountUsed); err != nil {
+			return nil, err
+		}
+		friends = append(friends, f)
 	}
-
-	return config.Friends, nil
+	return friends, rows.Err()
 }
 
-// SaveFriends saves friends to config file
-func SaveFriends(friends []Friend) error {
-	configPath, err := GetConfigPath()
-	if err != nil {
-		return err
-	}
-
-	config := FriendsConfig{Friends: friends}
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(configPath, data, 0600) // Secure permissions - owner only
+func insertFriend(tx *sql.Tx, f Friend) error {
+	_, err := tx.Exec(`INSERT INTO friends (user_id, username, display_name, added_at, notes, last_account_used)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		f.UserID, f.Username, f.DisplayName, f.AddedAt, f.Notes, f.LastAccountUsed)
+	return err
 }
 
 // AddFriend adds a new friend
 func AddFriend(userID int64, username, displayName string) error {
-	friends, err := LoadFriends()
-	if err != nil {
-		return err
-	}
-
-	// Check if already exists
-	for _, f := range friends {
-		if f.UserID == userID {
+	err := state_db.Update(func(tx *sql.Tx) error {
+		// Check if already exists
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM friends WHERE user_id = ?", userID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists > 0 {
 			return fmt.Errorf("friend with user ID %d already exists", userID)
 		}
-	}
 
-	friend := Friend{
-		UserID:      userID,
-		Username:    username,
-		DisplayName: displayName,
-		AddedAt:     time.Now(),
+		return insertFriend(tx, Friend{
+			UserID:      userID,
+			Username:    username,
+			DisplayName: displayName,
+			AddedAt:     time.Now(),
+		})
+	})
+	if err != nil {
+		return err
 	}
 
-	friends = append(friends, friend)
 	logger.LogInfo("Added friend: %s (ID: %d)", username, userID)
-	return SaveFriends(friends)
+	return nil
 }
 
 // RemoveFriend removes a friend by user ID
 func RemoveFriend(userID int64) error {
-	friends, err := LoadFriends()
-	if err != nil {
+	if err := updateFriend("DELETE FROM friends WHERE user_id = ?", userID); err != nil {
 		return err
 	}
+	logger.LogInfo("Removed friend with ID: %d", userID)
+	return nil
+}
 
-	var newFriends []Friend
-	found := false
-	for _, f := range friends {
-		if f.UserID == userID {
-			found = true
-			continue
-		}
-		newFriends = append(newFriends, f)
+// updateFriend runs a statement whose last argument is a friend's user ID,
+// failing if there's no such friend
+func updateFriend(query string, args ...any) error {
+	db, err := state_db.Default()
+	if err != nil {
+		return err
 	}
-
-	if !found {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
 		return fmt.Errorf("friend not found")
 	}
-
-	logger.LogInfo("Removed friend with ID: %d", userID)
-	return SaveFriends(newFriends)
+	return nil
 }
 
 // UpdateFriendNotes updates notes for a friend
 func UpdateFriendNotes(userID int64, notes string) error {
-	friends, err := LoadFriends()
+	return updateFriend("UPDATE friends SET notes = ? WHERE user_id = ?", notes, userID)
+}
+
+// UpdateFriendLastAccount records the account last used to join a friend
+func UpdateFriendLastAccount(userID int64, accountID string) error {
+	return updateFriend("UPDATE friends SET last_account_used = ? WHERE user_id = ?", accountID, userID)
+}
+
+// RekeyAccount updates friends that were last joined with an old account ID
+func RekeyAccount(oldID, newID string) error {
+	db, err := state_db.Default()
 	if err != nil {
 		return err
 	}
-
-	for i, f := range friends {
-		if f.UserID == userID {
-			friends[i].Notes = notes
-			return SaveFriends(friends)
-		}
-	}
-
-	return fmt.Errorf("friend not found")
+	_, err = db.Exec("UPDATE friends SET last_account_used = ? WHERE last_account_used = ?", newID, oldID)
+	return err
 }
 
 // GetCachedStatus returns cached status for a friend
@@ -1,155 +1,268 @@
 package instance_account_tracker
 
 import (
+	"database/sql"
 	"encoding/json"
-	"os"
-	"path/filepath"
-	"sync"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/state_db"
 	"time"
 )
 
 // InstanceAccountMap tracks which account is used by each instance
 type InstanceAccountMap struct {
-	PID        int       `json:"pid"`
-	AccountID  string    `json:"account_id"`
-	LaunchedAt time.Time `json:"launched_at"`
+	PID        int              `json:"pid"`
+	AccountID  string           `json:"account_id"`
+	LaunchedAt time.Time        `json:"launched_at"`
+	PlaceID    int64            `json:"place_id,omitempty"`
+	JobID      string           `json:"job_id,omitempty"`   // Server the instance was sent to, if known
+	LogPath    string           `json:"log_path,omitempty"` // The instance's client log, once found
+	KeepAlive  *KeepAlivePolicy `json:"keep_alive,omitempty"`
 }
 
-var (
-	mu      sync.Mutex
-	mapping []InstanceAccountMap
-)
-
-// GetMappingPath returns the path to the instance-account mapping file
-func GetMappingPath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, "Library", "Application Support", "multi_roblox_macos", "instance_accounts.json")
+// KeepAlivePolicy relaunches an instance with the same account when it
+// crashes or is disconnected
+type KeepAlivePolicy struct {
+	PresetName string `json:"preset_name"`
+	PlaceID    int64  `json:"place_id"`
+	MaxRetries int    `json:"max_retries"`
+	// BackoffSeconds is the wait before the first relaunch; it doubles with
+	// each consecutive one
+	BackoffSeconds float64 `json:"backoff_seconds"`
+	Retries        int     `json:"retries,omitempty"` // Consecutive relaunches so far
 }
 
-// LoadMappings loads instance-account mappings from disk
-func LoadMappings() ([]InstanceAccountMap, error) {
-	mu.Lock()
-	defer mu.Unlock()
+// mappingsFile is the JSON file mappings were kept in before the state database
+const mappingsFile = "instance_accounts.json"
 
-	path := GetMappingPath()
+func init() {
+	state_db.RegisterJSONSource(state_db.JSONSource{
+		File: mappingsFile,
+		Import: func(tx *sql.Tx, data []byte) error {
+			var maps []InstanceAccountMap
+			if err := json.Unmarshal(data, &maps); err != nil {
+				return err
+			}
+			return replaceMappings(tx, maps)
+		},
+		Export: func(db *sql.DB) ([]byte, error) {
+			maps, err := queryMappings(db, "")
+			if err != nil {
+				return nil, err
+			}
+			return json.MarshalIndent(maps, "", "  ")
+		},
+	})
+}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return []InstanceAccountMap{}, nil
+// queryMappings returns the mappings matching where, or all if it's empty
+func queryMappings(q state_db.Querier, where string, args ...any) ([]InstanceAccountMap, error) {
+	query := "SELECT pid, account_id, launched_at, place_id, job_id, log_path, keep_alive FROM instance_accounts"
+	if where != "" {
+		query += " WHERE " + where
 	}
-
-	data, err := os.ReadFile(path)
+	rows, err := q.Query(query+" ORDER BY pid", args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var maps []InstanceAccountMap
-	if err := json.Unmarshal(data, &maps); err != nil {
-		return nil, err
+	maps := []InstanceAccountMap{}
+	for rows.Next() {
+		var m InstanceAccountMap
+		var keepAlive sql.NullString
+		if err := rows.Scan(&m.PID, &m.AccountID, &m.LaunchedAt, &m.PlaceID, &m.JobID, &m.LogPath, &keepAlive); err != nil {
+			return nil, err
+		}
+		if keepAlive.Valid {
+			m.KeepAlive = &KeepAlivePolicy{}
+			if err := json.Unmarshal([]byte(keepAlive.String), m.KeepAlive); err != nil {
+				return nil, fmt.Errorf("bad keep-alive policy for PID %d: %w", m.PID, err)
+			}
+		}
+		maps = append(maps, m)
 	}
-
-	mapping = maps
-	return maps, nil
+	return maps, rows.Err()
 }
 
-// SaveMappings saves mappings to disk
-func SaveMappings(maps []InstanceAccountMap) error {
-	mu.Lock()
-	defer mu.Unlock()
-
-	path := GetMappingPath()
-	dir := filepath.Dir(path)
-
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+// keepAliveValue is how a policy is stored: JSON, or NULL for none
+func keepAliveValue(policy *KeepAlivePolicy) (any, error) {
+	if policy == nil {
+		return nil, nil
 	}
-
-	data, err := json.MarshalIndent(maps, "", "  ")
+	data, err := json.Marshal(policy)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	mapping = maps
-	return os.WriteFile(path, data, 0600)
+	return string(data), nil
 }
 
-// TrackInstance records which account was used to launch an instance
-func TrackInstance(pid int, accountID string) error {
-	maps, err := LoadMappings()
+func insertMapping(tx *sql.Tx, m InstanceAccountMap) error {
+	keepAlive, err := keepAliveValue(m.KeepAlive)
 	if err != nil {
 		return err
 	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO instance_accounts
+		(pid, account_id, launched_at, place_id, job_id, log_path, keep_alive) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		m.PID, m.AccountID, m.LaunchedAt, m.PlaceID, m.JobID, m.LogPath, keepAlive)
+	return err
+}
 
-	// Remove old mapping for this PID if exists
-	filtered := []InstanceAccountMap{}
+func replaceMappings(tx *sql.Tx, maps []InstanceAccountMap) error {
+	if _, err := tx.Exec("DELETE FROM instance_accounts"); err != nil {
+		return err
+	}
 	for _, m := range maps {
-		if m.PID != pid {
-			filtered = append(filtered, m)
+		if err := insertMapping(tx, m); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// Add new mapping
-	filtered = append(filtered, InstanceAccountMap{
-		PID:        pid,
-		AccountID:  accountID,
-		LaunchedAt: time.Now(),
+// exec runs a statement on the state database. It goes through
+// state_db.Update so readers caching mappings see the change.
+func exec(query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := state_db.Update(func(tx *sql.Tx) error {
+		var err error
+		result, err = tx.Exec(query, args...)
+		return err
 	})
+	return result, err
+}
+
+// LoadMappings loads all instance-account mappings
+func LoadMappings() ([]InstanceAccountMap, error) {
+	db, err := state_db.Default()
+	if err != nil {
+		return nil, err
+	}
+	return queryMappings(db, "")
+}
 
-	return SaveMappings(filtered)
+// SaveMappings replaces all mappings
+func SaveMappings(maps []InstanceAccountMap) error {
+	return state_db.Update(func(tx *sql.Tx) error {
+		return replaceMappings(tx, maps)
+	})
+}
+
+// TrackInstance records which account was used to launch an instance
+func TrackInstance(pid int, accountID string) error {
+	return TrackLaunch(pid, accountID, 0, "")
+}
+
+// TrackLaunch records the account, place and server an instance was
+// launched into, replacing anything recorded for the PID before
+func TrackLaunch(pid int, accountID string, placeID int64, jobID string) error {
+	return state_db.Update(func(tx *sql.Tx) error {
+		return insertMapping(tx, InstanceAccountMap{
+			PID:        pid,
+			AccountID:  accountID,
+			LaunchedAt: time.Now(),
+			PlaceID:    placeID,
+			JobID:      jobID,
+		})
+	})
 }
 
 // GetAccountForInstance returns the account ID for a given PID
 func GetAccountForInstance(pid int) (string, bool) {
-	maps, err := LoadMappings()
+	m, found := GetInstance(pid)
+	return m.AccountID, found
+}
+
+// GetInstance returns everything recorded about an instance
+func GetInstance(pid int) (InstanceAccountMap, bool) {
+	db, err := state_db.Default()
 	if err != nil {
-		return "", false
+		return InstanceAccountMap{}, false
 	}
 
-	for _, m := range maps {
-		if m.PID == pid {
-			return m.AccountID, true
-		}
+	maps, err := queryMappings(db, "pid = ?", pid)
+	if err != nil || len(maps) == 0 {
+		return InstanceAccountMap{}, false
 	}
-
-	return "", false
+	return maps[0], true
 }
 
-// CleanupStaleInstances removes mappings for PIDs that no longer exist
+// CleanupStaleInstances removes mappings for PIDs that no longer exist.
+// Mappings with a keep-alive policy are kept for the supervisor to relaunch.
 func CleanupStaleInstances(activePIDs []int) error {
-	maps, err := LoadMappings()
-	if err != nil {
-		return err
-	}
-
 	// Create map for fast lookup
 	pidMap := make(map[int]bool)
 	for _, pid := range activePIDs {
 		pidMap[pid] = true
 	}
 
-	// Keep only active instances
-	filtered := []InstanceAccountMap{}
-	for _, m := range maps {
-		if pidMap[m.PID] {
-			filtered = append(filtered, m)
+	return state_db.Update(func(tx *sql.Tx) error {
+		maps, err := queryMappings(tx, "keep_alive IS NULL")
+		if err != nil {
+			return err
 		}
-	}
-
-	return SaveMappings(filtered)
+		for _, m := range maps {
+			if pidMap[m.PID] {
+				continue
+			}
+			if _, err := tx.Exec("DELETE FROM instance_accounts WHERE pid = ?", m.PID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // UntrackInstance removes tracking for a specific PID
 func UntrackInstance(pid int) error {
-	maps, err := LoadMappings()
+	_, err := exec("DELETE FROM instance_accounts WHERE pid = ?", pid)
+	return err
+}
+
+// RekeyAccount points mappings for an old account ID at a new one
+func RekeyAccount(oldID, newID string) error {
+	_, err := exec("UPDATE instance_accounts SET account_id = ? WHERE account_id = ?", newID, oldID)
+	return err
+}
+
+// updateTracked runs a statement whose last argument is a PID, failing if
+// the PID isn't tracked
+func updateTracked(pid int, query string, args ...any) error {
+	result, err := exec(query, append(args, pid)...)
 	if err != nil {
 		return err
 	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("instance %d is not tracked", pid)
+	}
+	return nil
+}
 
-	filtered := []InstanceAccountMap{}
-	for _, m := range maps {
-		if m.PID != pid {
-			filtered = append(filtered, m)
-		}
+// SetKeepAlive sets or, with nil, clears an instance's keep-alive policy
+func SetKeepAlive(pid int, policy *KeepAlivePolicy) error {
+	keepAlive, err := keepAliveValue(policy)
+	if err != nil {
+		return err
 	}
+	return updateTracked(pid, "UPDATE instance_accounts SET keep_alive = ? WHERE pid = ?", keepAlive)
+}
+
+// SetLogPath records the client log an instance writes to
+func SetLogPath(pid int, path string) error {
+	return updateTracked(pid, "UPDATE instance_accounts SET log_path = ? WHERE pid = ?", path)
+}
+
+// KeepAliveInstances returns the mappings that have a keep-alive policy
+func KeepAliveInstances() ([]InstanceAccountMap, error) {
+	db, err := state_db.Default()
+	if err != nil {
+		return nil, err
+	}
+	return queryMappings(db, "keep_alive IS NOT NULL")
+}
 
-	return SaveMappings(filtered)
+// ClearKeepAlive removes every keep-alive policy, so instances closed on
+// purpose aren't relaunched
+func ClearKeepAlive() error {
+	_, err := exec("UPDATE instance_accounts SET keep_alive = NULL")
+	return err
 }
@@ -14,55 +14,41 @@ type Instance struct {
 	Name      string
 	Label     string
 	Color     string
+	Launch    *LaunchInfo // Nil if the process couldn't be read
+	Tracking  *Tracking   // Nil if no account is tracked for it
+	// Suggested is an unconfirmed guess at the account of an untracked
+	// instance; nil once tracked or when there's no good guess
+	Suggested *Suggestion
 }
 
-// GetRunningInstances returns a list of all running Roblox instances with labels
-func GetRunningInstances() ([]Instance, error) {
-	processes, err := ps_darwin.Processes()
-	if err != nil {
-		return nil, err
-	}
-
-	var instances []Instance
-	var pids []int
-
-	for _, proc := range processes {
-		// Only count actual RobloxPlayer, not RobloxCrashHandler or other helpers
-		// ps_darwin truncates names to 16 chars, so check prefix
-		execName := proc.Executable()
-		if execName == "RobloxPlayer" {
-			pid := proc.Pid()
-			pids = append(pids, pid)
-
-			// Get label if exists
-			label, hasLabel := label_manager.GetLabel(pid)
-			labelText := ""
-			color := ""
-			if hasLabel {
-				labelText = label.Label
-				color = label.Color
-			}
+// Tracking is what the account tracker knows about an instance
+type Tracking struct {
+	AccountID   string
+	AccountName string // The account's label, or its username
+	JobID       string
+	KeepAlive   *instance_account_tracker.KeepAlivePolicy
+}
 
-			instances = append(instances, Instance{
-				PID:       pid,
-				StartTime: time.Now(), // TODO: Get actual start time from process
-				Name:      proc.Executable(),
-				Label:     labelText,
-				Color:     color,
-			})
-		}
+func (t *Tracking) equal(other *Tracking) bool {
+	if t == nil || other == nil {
+		return t == other
 	}
-
-	// Cleanup stale labels and instance tracking
-	if len(pids) > 0 {
-		label_manager.CleanupStaleLabels(pids)
-		instance_account_tracker.CleanupStaleInstances(pids)
-	} else {
-		// No instances running - clean up all tracking
-		instance_account_tracker.CleanupStaleInstances([]int{})
+	if (t.KeepAlive == nil) != (other.KeepAlive == nil) ||
+		(t.KeepAlive != nil && *t.KeepAlive != *other.KeepAlive) {
+		return false
 	}
+	return t.AccountID == other.AccountID && t.AccountName == other.AccountName && t.JobID == other.JobID
+}
 
-	return instances, nil
+// GetRunningInstances lists processes now and returns the running Roblox
+// instances with labels. Prefer Default().Instances() when the registry is
+// running, which doesn't list processes.
+func GetRunningInstances() ([]Instance, error) {
+	registry := Default()
+	if err := registry.Refresh(); err != nil {
+		return nil, err
+	}
+	return registry.Instances(), nil
 }
 
 // GetInstanceCount returns the number of running Roblox instances
@@ -81,5 +67,8 @@ func CloseInstance(pid int) error {
 	instance_account_tracker.UntrackInstance(pid)
 
 	// Use forceful kill
-	return ps_darwin.ForceKillProcess(pid)
+	if err := ps_darwin.ForceKillProcess(pid); err != nil {
+		return err
+	}
+	return Default().Refresh()
 }
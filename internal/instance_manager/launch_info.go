@@ -0,0 +1,205 @@
+package instance_manager
+
+import (
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/clone_pool"
+	"insadem/multi_roblox_macos/internal/cookie_manager"
+	"insadem/multi_roblox_macos/internal/friends_manager"
+	"insadem/multi_roblox_macos/internal/launch_uri"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/preset_manager"
+	"insadem/multi_roblox_macos/internal/ps_darwin"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"insadem/multi_roblox_macos/internal/roblox_install"
+	"path/filepath"
+	"strings"
+)
+
+// LaunchSource is which copy of Roblox an instance runs from
+type LaunchSource string
+
+const (
+	SourceInstall LaunchSource = "installed app" // A Roblox.app install, such as /Applications
+	SourceClone   LaunchSource = "clone"         // A copy from the clone pool
+	SourceOther   LaunchSource = "other"         // Somewhere this app doesn't know
+)
+
+// LaunchInfo is what a running player's executable path and arguments say
+// about how it was started
+type LaunchInfo struct {
+	Path      string // The player executable
+	Source    LaunchSource
+	CloneName string // For SourceClone
+	// Request is the -protocolString the player was started with, without
+	// its auth ticket. Nil when started without one, as when macOS opens a
+	// link from the browser.
+	Request *launch_uri.LaunchRequest
+}
+
+// playerInBundle is the player executable's path inside an app bundle
+var playerInBundle = filepath.Join("Contents", "MacOS", "RobloxPlayer")
+
+// parseLaunchInfo interprets a player's executable path and argv. cloneDir
+// is the clone pool's directory; installs are the Roblox.app bundles the
+// app knows about.
+func parseLaunchInfo(path string, args []string, cloneDir string, installs []string) *LaunchInfo {
+	info := &LaunchInfo{Path: path, Source: SourceOther}
+
+	if rel, err := filepath.Rel(cloneDir, path); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+		info.Source = SourceClone
+		info.CloneName = strings.Split(rel, string(filepath.Separator))[0]
+	} else {
+		for _, install := range installs {
+			if path == filepath.Join(install, playerInBundle) {
+				info.Source = SourceInstall
+				break
+			}
+		}
+	}
+
+	for i, arg := range args {
+		if arg != "-protocolString" || i+1 >= len(args) {
+			continue
+		}
+		request, err := launch_uri.Parse(args[i+1])
+		if err != nil {
+			logger.LogDebug("Unreadable protocol string for %s: %v", path, err)
+			break
+		}
+		request.AuthTicket = ""
+		info.Request = request
+		break
+	}
+	return info
+}
+
+// identify reads how a process was launched. Processes of other users, or
+// ones that just exited, can't be read and have no launch info.
+func identify(proc ps_darwin.Process) *LaunchInfo {
+	path, err := proc.Path()
+	if err != nil {
+		logger.LogDebug("Failed to read executable path of PID %d: %v", proc.Pid(), err)
+		return nil
+	}
+	args, err := proc.Args()
+	if err != nil {
+		logger.LogDebug("Failed to read arguments of PID %d: %v", proc.Pid(), err)
+	}
+
+	installs := roblox_install.DefaultLocations()
+	if custom := roblox_install.CustomPath(); custom != "" {
+		installs = append(installs, custom)
+	}
+	return parseLaunchInfo(path, args, clone_pool.DefaultDir(), installs)
+}
+
+// Suggestion is the account an untracked instance was probably launched
+// with. It is a guess until presence shows that account in the instance's
+// place, and only then is the instance tracked.
+type Suggestion struct {
+	AccountID   string
+	AccountName string // The account's label, or its username
+	Source      string // What the guess was based on, for display
+}
+
+// suggestAccount guesses the account an untracked instance runs as: the
+// last account used for the preset matching its protocol string, or for the
+// friend it followed. There is no suggestion when the match is ambiguous or
+// that account is already running elsewhere.
+func suggestAccount(instance Instance, tracking map[int]*Tracking) *Suggestion {
+	if instance.Launch == nil || instance.Launch.Request == nil {
+		return nil
+	}
+	accountID, source := likelyAccount(instance.Launch.Request)
+	if accountID == "" {
+		return nil
+	}
+	account, err := account_manager.GetAccount(accountID)
+	if err != nil {
+		return nil
+	}
+	for pid, other := range tracking {
+		if pid != instance.PID && other.AccountID == accountID {
+			return nil
+		}
+	}
+
+	name := account.Username
+	if account.Label != "" {
+		name = account.Label
+	}
+	return &Suggestion{AccountID: accountID, AccountName: name, Source: source}
+}
+
+// likelyAccount returns the last account used for what a launch request
+// targets, and a description of where it came from
+func likelyAccount(request *launch_uri.LaunchRequest) (string, string) {
+	if request.RequestType() == launch_uri.RequestFollowUser {
+		friends, err := friends_manager.LoadFriends()
+		if err != nil {
+			return "", ""
+		}
+		for _, friend := range friends {
+			if friend.UserID == request.FollowUserID {
+				return friend.LastAccountUsed, "following " + friend.Username
+			}
+		}
+		return "", ""
+	}
+
+	presets, err := preset_manager.LoadPresets()
+	if err != nil {
+		return "", ""
+	}
+	var matches []preset_manager.Preset
+	for _, preset := range presets {
+		if presetMatches(preset, request) {
+			matches = append(matches, preset)
+		}
+	}
+	// Server browser and party launches pick their own server, so the job ID
+	// only breaks ties between presets for the same place
+	if len(matches) > 1 {
+		var sameServer []preset_manager.Preset
+		for _, preset := range matches {
+			if preset.JobID == request.JobID {
+				sameServer = append(sameServer, preset)
+			}
+		}
+		matches = sameServer
+	}
+	if len(matches) != 1 {
+		return "", ""
+	}
+	return matches[0].LastAccountUsed, "preset " + matches[0].Name
+}
+
+// presetMatches reports whether launching preset leads to request's place
+// and private server
+func presetMatches(preset preset_manager.Preset, request *launch_uri.LaunchRequest) bool {
+	placeID := preset.PlaceID
+	if placeID == 0 {
+		placeID, _ = roblox_api.ExtractPlaceID(preset.URL)
+	}
+	return placeID == request.PlaceID && preset.PrivateServerLinkCode == request.LinkCode
+}
+
+// presenceConfirms reports whether presence shows an account's user in
+// placeID, using the account's saved cookie
+func presenceConfirms(accountID string, placeID int64) bool {
+	account, err := account_manager.GetAccount(accountID)
+	if err != nil || account.UserID == 0 {
+		return false
+	}
+	cookie, err := cookie_manager.GetCookieForAccount(accountID)
+	if err != nil {
+		return false
+	}
+	presences, err := roblox_api.GetUserPresence([]int64{account.UserID}, cookie.Value)
+	if err != nil || len(presences) == 0 {
+		logger.LogDebug("No presence to confirm account %s: %v", accountID, err)
+		return false
+	}
+	p := presences[0]
+	return p.UserPresenceType == 2 && (p.PlaceID == placeID || p.RootPlaceID == placeID)
+}
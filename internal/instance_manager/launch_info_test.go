@@ -0,0 +1,126 @@
+package instance_manager
+
+import (
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/clone_pool"
+	"insadem/multi_roblox_macos/internal/instance_account_tracker"
+	"insadem/multi_roblox_macos/internal/launch_uri"
+	"insadem/multi_roblox_macos/internal/preset_manager"
+	"insadem/multi_roblox_macos/internal/ps_darwin"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLaunchInfo(t *testing.T) {
+	cloneDir := "/Users/me/Library/Application Support/multi_roblox_macos/clones"
+	installs := []string{"/Applications/Roblox.app"}
+	uri := launch_uri.LaunchRequest{PlaceID: 606849621, JobID: "abc-123", AuthTicket: "secret"}.PlayerURI()
+
+	info := parseLaunchInfo(filepath.Join(cloneDir, "roblox-2.app", playerInBundle),
+		[]string{"RobloxPlayer", "-protocolString", uri}, cloneDir, installs)
+	if info.Source != SourceClone || info.CloneName != "roblox-2.app" {
+		t.Fatalf("clone launch = %s %q", info.Source, info.CloneName)
+	}
+	if info.Request == nil || info.Request.PlaceID != 606849621 || info.Request.JobID != "abc-123" {
+		t.Fatalf("request = %+v", info.Request)
+	}
+	if info.Request.RequestType() != launch_uri.RequestGameJob {
+		t.Fatalf("request type = %s", info.Request.RequestType())
+	}
+	if info.Request.AuthTicket != "" {
+		t.Fatal("auth ticket kept")
+	}
+
+	// Opened by macOS from a link: no protocol string in argv
+	info = parseLaunchInfo("/Applications/Roblox.app/Contents/MacOS/RobloxPlayer",
+		[]string{"/Applications/Roblox.app/Contents/MacOS/RobloxPlayer"}, cloneDir, installs)
+	if info.Source != SourceInstall || info.Request != nil {
+		t.Fatalf("installed launch = %s %+v", info.Source, info.Request)
+	}
+
+	info = parseLaunchInfo("/tmp/Roblox.app/Contents/MacOS/RobloxPlayer",
+		[]string{"RobloxPlayer", "-protocolString", "not a uri"}, cloneDir, installs)
+	if info.Source != SourceOther || info.Request != nil {
+		t.Fatalf("unknown launch = %s %+v", info.Source, info.Request)
+	}
+}
+
+func TestRegistrySuggestsThenConfirmsAccounts(t *testing.T) {
+	var running []ps_darwin.Process
+	r := newTestRegistry(t, &running)
+	inGame := map[string]int64{"alt": 100}
+	r.confirm = func(accountID string, placeID int64) bool {
+		return inGame[accountID] == placeID
+	}
+
+	if err := account_manager.SaveAccounts([]account_manager.Account{
+		{ID: "main", Username: "MainPlayer"},
+		{ID: "alt", Username: "AltPlayer", Label: "Alt"},
+		{ID: "third", Username: "ThirdPlayer"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := preset_manager.SavePresets([]preset_manager.Preset{
+		{Name: "Farm", PlaceID: 100, LastAccountUsed: "main"},
+		{Name: "Farm server", PlaceID: 100, JobID: "job-1", LastAccountUsed: "alt"},
+		{Name: "Obby", PlaceID: 300, LastAccountUsed: "third"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	player := filepath.Join(clone_pool.DefaultDir(), "roblox-1.app", playerInBundle)
+	launch := func(pid int, request launch_uri.LaunchRequest) ps_darwin.Process {
+		request.AuthTicket = "ticket"
+		return fakeProcess{pid: pid, name: "RobloxPlayer", path: player,
+			args: []string{"RobloxPlayer", "-protocolString", request.PlayerURI()}}
+	}
+	running = []ps_darwin.Process{
+		// Two presets for place 100; the job ID breaks the tie
+		launch(10, launch_uri.LaunchRequest{PlaceID: 100, JobID: "job-1"}),
+		launch(11, launch_uri.LaunchRequest{PlaceID: 200}),
+		// Joined a server picked in the browser, not the preset's pinned one
+		launch(12, launch_uri.LaunchRequest{PlaceID: 300, JobID: "other-job"}),
+	}
+	events, unsubscribe := r.Subscribe()
+	defer unsubscribe()
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	instances := r.Instances()
+	if s := instances[0].Suggested; s == nil || s.AccountID != "alt" || s.AccountName != "Alt" {
+		t.Fatalf("PID 10 suggestion = %+v", s)
+	}
+	if s := instances[1].Suggested; s != nil {
+		t.Fatalf("PID 11 suggestion = %+v; no preset is for place 200", s)
+	}
+	if s := instances[2].Suggested; s == nil || s.AccountID != "third" {
+		t.Fatalf("PID 12 suggestion = %+v", s)
+	}
+	if instances[0].Launch == nil || instances[0].Launch.CloneName != "roblox-1.app" {
+		t.Fatalf("launch info = %+v", instances[0].Launch)
+	}
+
+	// Presence shows the alt in place 100, so PID 10 is tracked and its
+	// suggestion cleared. The third account isn't in game, so PID 12 stays
+	// a suggestion.
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Kind != EventUpdated || e.Instance.PID != 10 {
+				continue
+			}
+			if e.Instance.Tracking == nil || e.Instance.Tracking.AccountID != "alt" || e.Instance.Suggested != nil {
+				t.Fatalf("PID 10 after confirmation = %+v", e.Instance)
+			}
+			if _, ok := instance_account_tracker.GetInstance(12); ok {
+				t.Fatal("unconfirmed suggestion was tracked")
+			}
+			return
+		case <-deadline:
+			t.Fatal("PID 10 was never confirmed")
+		}
+	}
+}
@@ -0,0 +1,433 @@
+package instance_manager
+
+import (
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/instance_account_tracker"
+	"insadem/multi_roblox_macos/internal/label_manager"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/ps_darwin"
+	"insadem/multi_roblox_macos/internal/state_db"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventKind is what happened to an instance
+type EventKind string
+
+const (
+	EventAdded   EventKind = "added"
+	EventRemoved EventKind = "removed"
+	EventUpdated EventKind = "updated"
+)
+
+// Event is a change to the set of running instances. Removed events carry
+// the instance as it was last seen.
+type Event struct {
+	Kind     EventKind
+	Instance Instance
+}
+
+const (
+	// DefaultScanInterval is how often the shared registry lists processes
+	DefaultScanInterval = 2 * time.Second
+
+	// subscriberBuffer is how many events a slow subscriber may fall behind
+	subscriberBuffer = 64
+
+	// A suggested account is checked this many times, this far apart, since
+	// a new player takes a while to join its game
+	confirmAttempts = 4
+	confirmInterval = 30 * time.Second
+)
+
+// InstanceRegistry keeps the running instances in memory. Each scan is
+// diffed against the previous one, and only changes are published to
+// subscribers and written to the state database. Account tracking is cached
+// until the state database changes, so readers of Instances need no database
+// access.
+type InstanceRegistry struct {
+	scanMu      sync.Mutex // Serializes Refresh so each change is seen once
+	mu          sync.Mutex
+	instances   map[int]Instance
+	scanned     bool
+	subscribers map[int]chan Event
+	nextSub     int
+
+	// Cached by Refresh along with the state_db.Generation it was read at
+	tracking    map[int]*Tracking
+	trackingGen uint64
+
+	// Process listing, tracking and presence checks; replaced in tests
+	list            func() ([]ps_darwin.Process, error)
+	readTracking    func() (map[int]*Tracking, error)
+	confirm         func(accountID string, placeID int64) bool
+	confirmInterval time.Duration
+}
+
+// NewRegistry returns an empty registry; Refresh fills it
+func NewRegistry() *InstanceRegistry {
+	return &InstanceRegistry{
+		instances:       make(map[int]Instance),
+		subscribers:     make(map[int]chan Event),
+		list:            ps_darwin.Processes,
+		readTracking:    readTracking,
+		confirm:         presenceConfirms,
+		confirmInterval: confirmInterval,
+	}
+}
+
+// Refresh lists processes once and applies the differences
+func (r *InstanceRegistry) Refresh() error {
+	r.scanMu.Lock()
+	defer r.scanMu.Unlock()
+
+	processes, err := r.list()
+	if err != nil {
+		return err
+	}
+
+	running := make(map[int]ps_darwin.Process)
+	for _, proc := range processes {
+		// Only count actual RobloxPlayer, not RobloxCrashHandler or other helpers
+		if proc.Executable() == "RobloxPlayer" {
+			running[proc.Pid()] = proc
+		}
+	}
+
+	r.mu.Lock()
+	firstScan := !r.scanned
+	r.scanned = true
+
+	var events []Event
+	var removed, reused []int
+	for pid, instance := range r.instances {
+		proc, ok := running[pid]
+		// A PID reused by a new player shows up with a different start time
+		if ok && proc.StartTime().Equal(instance.StartTime) {
+			continue
+		}
+		delete(r.instances, pid)
+		removed = append(removed, pid)
+		if ok {
+			reused = append(reused, pid)
+		}
+		events = append(events, Event{Kind: EventRemoved, Instance: instance})
+	}
+	var added []int
+	for pid := range running {
+		if _, ok := r.instances[pid]; !ok {
+			added = append(added, pid)
+		}
+	}
+	r.mu.Unlock()
+
+	// The previous player's label doesn't carry over to a reused PID
+	for _, pid := range reused {
+		if err := label_manager.DeleteLabel(pid); err != nil {
+			logger.LogError("Failed to delete label for reused PID %d: %v", pid, err)
+		}
+	}
+
+	runningPIDs := make([]int, 0, len(running))
+	for pid := range running {
+		runningPIDs = append(runningPIDs, pid)
+	}
+
+	// New instances are read once, outside the lock
+	sort.Ints(added)
+	newInstances := make([]Instance, 0, len(added))
+	for _, pid := range added {
+		instance := Instance{
+			PID:       pid,
+			StartTime: running[pid].StartTime(),
+			Name:      running[pid].Executable(),
+			Launch:    identify(running[pid]),
+		}
+		dropEarlierMapping(instance)
+		if label, ok := label_manager.GetLabel(pid); ok {
+			instance.Label = label.Label
+			instance.Color = label.Color
+		}
+		newInstances = append(newInstances, instance)
+	}
+
+	tracking, trackingErr := r.loadTracking()
+	if trackingErr != nil {
+		logger.LogError("Failed to load instance tracking: %v", trackingErr)
+	}
+	var toConfirm []Instance
+	for i, instance := range newInstances {
+		if tracking[instance.PID] != nil {
+			continue
+		}
+		newInstances[i].Suggested = suggestAccount(instance, tracking)
+		if newInstances[i].Suggested != nil && instance.Launch.Request.PlaceID > 0 {
+			toConfirm = append(toConfirm, newInstances[i])
+		}
+	}
+
+	r.mu.Lock()
+	// Tracking changes as launches finish and keep-alive is set, so existing
+	// instances are compared too
+	if trackingErr == nil {
+		for pid, instance := range r.instances {
+			if !instance.Tracking.equal(tracking[pid]) {
+				instance.Tracking = tracking[pid]
+				if instance.Tracking != nil {
+					instance.Suggested = nil
+				}
+				r.instances[pid] = instance
+				events = append(events, Event{Kind: EventUpdated, Instance: instance})
+			}
+		}
+	}
+	for _, instance := range newInstances {
+		instance.Tracking = tracking[instance.PID]
+		r.instances[instance.PID] = instance
+		events = append(events, Event{Kind: EventAdded, Instance: instance})
+	}
+	r.mu.Unlock()
+
+	// Records of earlier sessions can't be matched to a removal, so the first
+	// scan clears whatever isn't running
+	if firstScan || len(removed) > 0 {
+		r.forget(runningPIDs)
+	}
+
+	r.publish(events)
+	for _, instance := range toConfirm {
+		go r.confirmSuggestion(instance)
+	}
+	return nil
+}
+
+// confirmSuggestion tracks an instance with its suggested account once
+// presence shows that account in the instance's place
+func (r *InstanceRegistry) confirmSuggestion(instance Instance) {
+	suggestion, request := instance.Suggested, instance.Launch.Request
+	for attempt := 0; attempt < confirmAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(r.confirmInterval)
+		}
+
+		// Stop if the instance exited, or was tracked some other way
+		r.mu.Lock()
+		current, ok := r.instances[instance.PID]
+		r.mu.Unlock()
+		if !ok || !current.StartTime.Equal(instance.StartTime) || current.Tracking != nil {
+			return
+		}
+
+		if !r.confirm(suggestion.AccountID, request.PlaceID) {
+			continue
+		}
+		if err := instance_account_tracker.TrackLaunch(instance.PID, suggestion.AccountID, request.PlaceID, request.JobID); err != nil {
+			logger.LogError("Failed to track PID %d: %v", instance.PID, err)
+			return
+		}
+		logger.LogInfo("Presence confirmed PID %d is account %s (%s)", instance.PID, suggestion.AccountID, suggestion.Source)
+		if err := r.Refresh(); err != nil {
+			logger.LogError("Failed to list instances: %v", err)
+		}
+		return
+	}
+}
+
+// forget drops the labels and account mappings of instances that aren't
+// running; keep-alive mappings stay for the supervisor
+func (r *InstanceRegistry) forget(pids []int) {
+	if err := label_manager.CleanupStaleLabels(pids); err != nil {
+		logger.LogError("Failed to clean up stale labels: %v", err)
+	}
+	if err := instance_account_tracker.CleanupStaleInstances(pids); err != nil {
+		logger.LogError("Failed to clean up stale instances: %v", err)
+	}
+}
+
+// loadTracking returns every tracked instance and its account's name,
+// reading them again only if the state database changed since the last call.
+// Callers hold scanMu.
+func (r *InstanceRegistry) loadTracking() (map[int]*Tracking, error) {
+	generation := state_db.Generation()
+	if r.tracking != nil && generation == r.trackingGen {
+		return r.tracking, nil
+	}
+	tracking, err := r.readTracking()
+	if err != nil {
+		return nil, err
+	}
+	r.tracking, r.trackingGen = tracking, generation
+	return tracking, nil
+}
+
+// readTracking reads every tracked instance and its account's name
+func readTracking() (map[int]*Tracking, error) {
+	mappings, err := instance_account_tracker.LoadMappings()
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := account_manager.LoadAccounts()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		names[account.ID] = account.Username
+		if account.Label != "" {
+			names[account.ID] = account.Label
+		}
+	}
+
+	tracking := make(map[int]*Tracking, len(mappings))
+	for _, m := range mappings {
+		tracking[m.PID] = &Tracking{
+			AccountID:   m.AccountID,
+			AccountName: names[m.AccountID],
+			JobID:       m.JobID,
+			KeepAlive:   m.KeepAlive,
+		}
+	}
+	return tracking, nil
+}
+
+// dropEarlierMapping untracks an instance whose account mapping was made
+// before it started, so belonged to an earlier process with the same PID
+func dropEarlierMapping(instance Instance) {
+	mapping, ok := instance_account_tracker.GetInstance(instance.PID)
+	if !ok || !mapping.LaunchedAt.Before(instance.StartTime) {
+		return
+	}
+	logger.LogInfo("PID %d was reused; dropping its mapping to account %s", instance.PID, mapping.AccountID)
+	if err := instance_account_tracker.UntrackInstance(instance.PID); err != nil {
+		logger.LogError("Failed to untrack reused PID %d: %v", instance.PID, err)
+	}
+}
+
+// Instances returns the running instances, ordered by PID
+func (r *InstanceRegistry) Instances() []Instance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	instances := make([]Instance, 0, len(r.instances))
+	for _, instance := range r.instances {
+		instances = append(instances, instance)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].PID < instances[j].PID })
+	return instances
+}
+
+// Count returns how many instances are running
+func (r *InstanceRegistry) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.instances)
+}
+
+// SetLabel saves an instance's label and publishes the update. An empty
+// label removes it.
+func (r *InstanceRegistry) SetLabel(pid int, labelText, color string) error {
+	var err error
+	if labelText == "" {
+		color = ""
+		err = label_manager.DeleteLabel(pid)
+	} else {
+		err = label_manager.SetLabel(pid, labelText, color)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	instance, ok := r.instances[pid]
+	if ok {
+		instance.Label = labelText
+		instance.Color = color
+		r.instances[pid] = instance
+	}
+	r.mu.Unlock()
+
+	if ok {
+		r.publish([]Event{{Kind: EventUpdated, Instance: instance}})
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving every change and a function that
+// unsubscribes. Events are dropped for a subscriber that falls too far
+// behind, so use Instances for the full picture.
+func (r *InstanceRegistry) Subscribe() (<-chan Event, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextSub++
+	id := r.nextSub
+	ch := make(chan Event, subscriberBuffer)
+	r.subscribers[id] = ch
+
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if ch, ok := r.subscribers[id]; ok {
+			delete(r.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+func (r *InstanceRegistry) publish(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range events {
+		for _, ch := range r.subscribers {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+// Run refreshes the registry every interval, starting one interval from
+// now, until stop is closed
+func (r *InstanceRegistry) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if err := r.Refresh(); err != nil {
+			logger.LogError("Failed to list instances: %v", err)
+		}
+	}
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *InstanceRegistry
+)
+
+// Default returns the process-wide registry
+func Default() *InstanceRegistry {
+	defaultOnce.Do(func() {
+		defaultRegistry = NewRegistry()
+	})
+	return defaultRegistry
+}
+
+// Start fills the shared registry, then keeps it current in the background
+// for the life of the app
+func Start() {
+	if err := Default().Refresh(); err != nil {
+		logger.LogError("Failed to list instances: %v", err)
+	}
+	go Default().Run(DefaultScanInterval, nil)
+}
@@ -0,0 +1,224 @@
+package instance_manager
+
+import (
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/config_store"
+	"insadem/multi_roblox_macos/internal/instance_account_tracker"
+	"insadem/multi_roblox_macos/internal/label_manager"
+	"insadem/multi_roblox_macos/internal/ps_darwin"
+	"testing"
+	"time"
+)
+
+type fakeProcess struct {
+	pid   int
+	name  string
+	start time.Time
+	path  string
+	args  []string
+}
+
+func (p fakeProcess) Pid() int                { return p.pid }
+func (p fakeProcess) PPid() int               { return 1 }
+func (p fakeProcess) Executable() string      { return p.name }
+func (p fakeProcess) StartTime() time.Time    { return p.start }
+func (p fakeProcess) UID() int                { return 501 }
+func (p fakeProcess) Path() (string, error)   { return p.path, nil }
+func (p fakeProcess) Args() ([]string, error) { return p.args, nil }
+
+// newTestRegistry returns a registry listing *running as its processes
+func newTestRegistry(t *testing.T, running *[]ps_darwin.Process) *InstanceRegistry {
+	t.Helper()
+	config_store.SetAppDir(t.TempDir())
+	t.Cleanup(func() { config_store.SetAppDir("") })
+
+	r := NewRegistry()
+	r.list = func() ([]ps_darwin.Process, error) { return *running, nil }
+	r.confirm = func(string, int64) bool { return false }
+	r.confirmInterval = time.Millisecond
+	return r
+}
+
+func drain(events <-chan Event) []Event {
+	var got []Event
+	for {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
+
+func TestRegistryDiffsScans(t *testing.T) {
+	running := []ps_darwin.Process{
+		fakeProcess{pid: 10, name: "RobloxPlayer"},
+		fakeProcess{pid: 11, name: "RobloxCrashHand"},
+	}
+	r := newTestRegistry(t, &running)
+	if err := label_manager.SetLabel(10, "Main", "#FF6B6B"); err != nil {
+		t.Fatal(err)
+	}
+	events, unsubscribe := r.Subscribe()
+	defer unsubscribe()
+
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	got := drain(events)
+	if len(got) != 1 || got[0].Kind != EventAdded || got[0].Instance.PID != 10 || got[0].Instance.Label != "Main" {
+		t.Fatalf("first scan events = %+v", got)
+	}
+
+	// An unchanged scan publishes nothing
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(events); len(got) != 0 {
+		t.Fatalf("unchanged scan events = %+v", got)
+	}
+
+	running = append(running, fakeProcess{pid: 20, name: "RobloxPlayer"})
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(events); len(got) != 1 || got[0].Kind != EventAdded || got[0].Instance.PID != 20 {
+		t.Fatalf("events after a launch = %+v", got)
+	}
+	if n := r.Count(); n != 2 {
+		t.Fatalf("Count() = %d, want 2", n)
+	}
+
+	if err := r.SetLabel(20, "Alt", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(events); len(got) != 1 || got[0].Kind != EventUpdated || got[0].Instance.Label != "Alt" {
+		t.Fatalf("events after labelling = %+v", got)
+	}
+
+	running = running[2:]
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(events); len(got) != 1 || got[0].Kind != EventRemoved || got[0].Instance.PID != 10 {
+		t.Fatalf("events after an exit = %+v", got)
+	}
+	if _, ok := label_manager.GetLabel(10); ok {
+		t.Fatal("label of exited instance kept")
+	}
+	if instances := r.Instances(); len(instances) != 1 || instances[0].PID != 20 || instances[0].Label != "Alt" {
+		t.Fatalf("Instances() = %+v", instances)
+	}
+}
+
+func TestRegistryFirstScanForgetsStaleRecords(t *testing.T) {
+	running := []ps_darwin.Process{fakeProcess{pid: 10, name: "RobloxPlayer"}}
+	r := newTestRegistry(t, &running)
+
+	if err := instance_account_tracker.TrackInstance(10, "running-account"); err != nil {
+		t.Fatal(err)
+	}
+	if err := instance_account_tracker.TrackInstance(99, "exited-account"); err != nil {
+		t.Fatal(err)
+	}
+	if err := label_manager.SetLabel(99, "Gone", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := instance_account_tracker.GetInstance(99); ok {
+		t.Fatal("mapping for a PID from an earlier session kept")
+	}
+	if _, ok := label_manager.GetLabel(99); ok {
+		t.Fatal("label for a PID from an earlier session kept")
+	}
+	if _, ok := instance_account_tracker.GetInstance(10); !ok {
+		t.Fatal("mapping for a running instance dropped")
+	}
+}
+
+func TestRegistryNoticesReusedPIDs(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	running := []ps_darwin.Process{fakeProcess{pid: 10, name: "RobloxPlayer", start: start}}
+	r := newTestRegistry(t, &running)
+	events, unsubscribe := r.Subscribe()
+	defer unsubscribe()
+
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetLabel(10, "Main", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := instance_account_tracker.TrackInstance(10, "old-account"); err != nil {
+		t.Fatal(err)
+	}
+	drain(events)
+
+	// The player exits and another takes its PID between scans
+	running = []ps_darwin.Process{fakeProcess{pid: 10, name: "RobloxPlayer", start: time.Now().Add(time.Minute)}}
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	got := drain(events)
+	if len(got) != 2 || got[0].Kind != EventRemoved || got[1].Kind != EventAdded {
+		t.Fatalf("events after PID reuse = %+v", got)
+	}
+	if got[1].Instance.Label != "" {
+		t.Fatalf("new process inherited label %q", got[1].Instance.Label)
+	}
+	if _, ok := instance_account_tracker.GetInstance(10); ok {
+		t.Fatal("new process inherited the old account mapping")
+	}
+}
+
+func TestRegistryCarriesTracking(t *testing.T) {
+	running := []ps_darwin.Process{fakeProcess{pid: 10, name: "RobloxPlayer"}}
+	r := newTestRegistry(t, &running)
+	if err := account_manager.SaveAccounts([]account_manager.Account{{ID: "main", Username: "MainPlayer", Label: "Main"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if tracking := r.Instances()[0].Tracking; tracking != nil {
+		t.Fatalf("untracked instance has tracking %+v", tracking)
+	}
+
+	reads := 0
+	r.readTracking = func() (map[int]*Tracking, error) {
+		reads++
+		return readTracking()
+	}
+
+	events, unsubscribe := r.Subscribe()
+	defer unsubscribe()
+	if err := instance_account_tracker.TrackLaunch(10, "main", 100, "job-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	got := drain(events)
+	if len(got) != 1 || got[0].Kind != EventUpdated {
+		t.Fatalf("events after tracking = %+v", got)
+	}
+	tracking := r.Instances()[0].Tracking
+	if tracking == nil || tracking.AccountName != "Main" || tracking.JobID != "job-1" {
+		t.Fatalf("tracking = %+v", tracking)
+	}
+
+	// Unchanged tracking publishes nothing, and isn't read again
+	if err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(events); len(got) != 0 {
+		t.Fatalf("events after an unchanged scan = %+v", got)
+	}
+	if reads != 1 {
+		t.Fatalf("tracking read %d times, want once after TrackLaunch", reads)
+	}
+}
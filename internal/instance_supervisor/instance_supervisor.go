@@ -0,0 +1,242 @@
+package instance_supervisor
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/instance_account_tracker"
+	"insadem/multi_roblox_macos/internal/launch_verifier"
+	"insadem/multi_roblox_macos/internal/launcher"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/preset_manager"
+	"insadem/multi_roblox_macos/internal/ps_darwin"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultInterval is how often kept-alive instances are checked
+	DefaultInterval = 5 * time.Second
+
+	// maxBackoff caps the wait before a relaunch
+	maxBackoff = 10 * time.Minute
+	// stableAfter is how long an instance must stay up for its retry count
+	// to reset
+	stableAfter = 10 * time.Minute
+)
+
+// Supervisor relaunches instances with a keep-alive policy when their
+// process exits or their client log shows a disconnect
+type Supervisor struct {
+	mu          sync.Mutex
+	interval    time.Duration
+	watchers    map[int]*launch_verifier.DisconnectWatcher // By PID
+	relaunching map[int]bool
+
+	// Replaced in tests
+	isAlive  func(pid int) bool
+	kill     func(pid int) error
+	relaunch func(m instance_account_tracker.InstanceAccountMap) (int, error)
+	sleep    func(time.Duration)
+	now      func() time.Time
+}
+
+// New returns a supervisor checking every interval
+func New(interval time.Duration) *Supervisor {
+	return &Supervisor{
+		interval:    interval,
+		watchers:    make(map[int]*launch_verifier.DisconnectWatcher),
+		relaunching: make(map[int]bool),
+		isAlive:     ps_darwin.ProcessAlive,
+		kill:        ps_darwin.ForceKillProcess,
+		relaunch:    relaunch,
+		sleep:       time.Sleep,
+		now:         time.Now,
+	}
+}
+
+// Run checks kept-alive instances until stop is closed
+func (s *Supervisor) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Check()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check looks at every kept-alive instance once and starts a relaunch for
+// those that exited or were disconnected
+func (s *Supervisor) Check() {
+	instances, err := instance_account_tracker.KeepAliveInstances()
+	if err != nil {
+		logger.LogError("Keep-alive: failed to load tracked instances: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watched := make(map[int]bool)
+	for _, m := range instances {
+		watched[m.PID] = true
+		if s.relaunching[m.PID] {
+			continue
+		}
+
+		reason := ""
+		if !s.isAlive(m.PID) {
+			reason = "process exited"
+		} else if line, ok := s.disconnected(m); ok {
+			reason = "disconnected: " + line
+		} else {
+			s.resetIfStable(m)
+			continue
+		}
+
+		policy := *m.KeepAlive
+		if policy.Retries >= policy.MaxRetries {
+			logger.LogError("Keep-alive: PID %d (%s) %s; giving up after %d relaunches", m.PID, m.AccountID, reason, policy.Retries)
+			instance_account_tracker.UntrackInstance(m.PID)
+			delete(s.watchers, m.PID)
+			continue
+		}
+
+		s.relaunching[m.PID] = true
+		go s.cycle(m, reason)
+	}
+
+	// Stop watching instances that are gone or no longer kept alive
+	for pid := range s.watchers {
+		if !watched[pid] {
+			delete(s.watchers, pid)
+		}
+	}
+}
+
+// disconnected checks the instance's client log for a disconnect
+func (s *Supervisor) disconnected(m instance_account_tracker.InstanceAccountMap) (string, bool) {
+	if m.LogPath == "" {
+		return "", false
+	}
+	watcher, ok := s.watchers[m.PID]
+	if !ok {
+		watcher = launch_verifier.WatchDisconnects(m.LogPath)
+		s.watchers[m.PID] = watcher
+	}
+	return watcher.Disconnected()
+}
+
+// resetIfStable clears the retry count of an instance that has stayed up
+func (s *Supervisor) resetIfStable(m instance_account_tracker.InstanceAccountMap) {
+	if m.KeepAlive.Retries == 0 || s.now().Sub(m.LaunchedAt) < stableAfter {
+		return
+	}
+	policy := *m.KeepAlive
+	policy.Retries = 0
+	if err := instance_account_tracker.SetKeepAlive(m.PID, &policy); err != nil {
+		logger.LogError("Keep-alive: failed to reset retries for PID %d: %v", m.PID, err)
+	}
+}
+
+// cycle waits out the backoff and relaunches the instance's account,
+// moving the policy to the new PID
+func (s *Supervisor) cycle(m instance_account_tracker.InstanceAccountMap, reason string) {
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.relaunching, m.PID)
+		delete(s.watchers, m.PID)
+	}()
+
+	policy := *m.KeepAlive
+	policy.Retries++
+	delay := Backoff(policy.BackoffSeconds, policy.Retries)
+	logger.LogInfo("Keep-alive: PID %d (%s) %s; relaunch %d/%d in %v",
+		m.PID, m.AccountID, reason, policy.Retries, policy.MaxRetries, delay)
+
+	if s.isAlive(m.PID) {
+		if err := s.kill(m.PID); err != nil {
+			logger.LogError("Keep-alive: failed to close disconnected PID %d: %v", m.PID, err)
+		}
+	}
+	s.sleep(delay)
+
+	pid, err := s.relaunch(m)
+	if err != nil {
+		// Count the attempt against the old mapping so the next check
+		// retries with a longer backoff
+		logger.LogError("Keep-alive: relaunch %d/%d for %s failed: %v", policy.Retries, policy.MaxRetries, m.AccountID, err)
+		if err := instance_account_tracker.SetKeepAlive(m.PID, &policy); err != nil {
+			logger.LogError("Keep-alive: failed to save retry count for PID %d: %v", m.PID, err)
+		}
+		return
+	}
+
+	instance_account_tracker.UntrackInstance(m.PID)
+	if err := instance_account_tracker.SetKeepAlive(pid, &policy); err != nil {
+		logger.LogError("Keep-alive: failed to move policy to PID %d: %v", pid, err)
+		return
+	}
+	logger.LogInfo("Keep-alive: relaunched %s as PID %d (was %d)", m.AccountID, pid, m.PID)
+}
+
+// Backoff returns the wait before the given relaunch, doubling from base
+// seconds and capped at ten minutes
+func Backoff(baseSeconds float64, retry int) time.Duration {
+	delay := time.Duration(baseSeconds * math.Pow(2, float64(max(retry-1, 0))) * float64(time.Second))
+	if delay > maxBackoff || delay < 0 {
+		return maxBackoff
+	}
+	return delay
+}
+
+// relaunch queues a launch of the instance's account into its policy's
+// preset and waits for it to start
+func relaunch(m instance_account_tracker.InstanceAccountMap) (int, error) {
+	account, err := account_manager.GetAccount(m.AccountID)
+	if err != nil {
+		return 0, fmt.Errorf("account %s: %w", m.AccountID, err)
+	}
+
+	state := launcher.Submit(launcher.Request{Account: *account, Preset: findPreset(m.KeepAlive)}).Wait()
+	if state.Err != nil {
+		return 0, state.Err
+	}
+	return state.PID, nil
+}
+
+// findPreset returns the saved preset a policy targets, or a bare one for
+// its place if the preset was renamed or deleted
+func findPreset(policy *instance_account_tracker.KeepAlivePolicy) preset_manager.Preset {
+	presets, _ := preset_manager.LoadPresets()
+	for _, p := range presets {
+		if p.Name == policy.PresetName && p.PlaceID == policy.PlaceID {
+			return p
+		}
+	}
+	return preset_manager.Preset{Name: policy.PresetName, PlaceID: policy.PlaceID}
+}
+
+var (
+	defaultOnce       sync.Once
+	defaultSupervisor *Supervisor
+)
+
+// Default returns the shared supervisor
+func Default() *Supervisor {
+	defaultOnce.Do(func() {
+		defaultSupervisor = New(DefaultInterval)
+	})
+	return defaultSupervisor
+}
+
+// Start runs the shared supervisor in the background for the life of the app
+func Start() {
+	go Default().Run(nil)
+}
@@ -0,0 +1,181 @@
+package instance_supervisor
+
+import (
+	"errors"
+	"insadem/multi_roblox_macos/internal/instance_account_tracker"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSystem struct {
+	mu       sync.Mutex
+	alive    map[int]bool
+	killed   []int
+	delays   []time.Duration
+	nextPID  int
+	failNext bool
+}
+
+func newTestSupervisor(t *testing.T, sys *fakeSystem) *Supervisor {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	s := New(time.Hour)
+	s.isAlive = func(pid int) bool {
+		sys.mu.Lock()
+		defer sys.mu.Unlock()
+		return sys.alive[pid]
+	}
+	s.kill = func(pid int) error {
+		sys.mu.Lock()
+		defer sys.mu.Unlock()
+		sys.killed = append(sys.killed, pid)
+		sys.alive[pid] = false
+		return nil
+	}
+	s.sleep = func(d time.Duration) {
+		sys.mu.Lock()
+		defer sys.mu.Unlock()
+		sys.delays = append(sys.delays, d)
+	}
+	s.relaunch = func(m instance_account_tracker.InstanceAccountMap) (int, error) {
+		sys.mu.Lock()
+		defer sys.mu.Unlock()
+		if sys.failNext {
+			sys.failNext = false
+			return 0, errors.New("no cookie")
+		}
+		sys.nextPID++
+		sys.alive[sys.nextPID] = true
+		// The launcher tracks the instances it starts
+		instance_account_tracker.TrackLaunch(sys.nextPID, m.AccountID, m.KeepAlive.PlaceID, "")
+		return sys.nextPID, nil
+	}
+	return s
+}
+
+// waitIdle waits for relaunches started by Check to finish
+func waitIdle(t *testing.T, s *Supervisor) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		busy := len(s.relaunching)
+		s.mu.Unlock()
+		if busy == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("relaunch didn't finish")
+}
+
+func keepAlive(t *testing.T, pid int, maxRetries int) {
+	t.Helper()
+	if err := instance_account_tracker.TrackLaunch(pid, "acc-1", 1818, ""); err != nil {
+		t.Fatal(err)
+	}
+	err := instance_account_tracker.SetKeepAlive(pid, &instance_account_tracker.KeepAlivePolicy{
+		PresetName:     "Farm",
+		PlaceID:        1818,
+		MaxRetries:     maxRetries,
+		BackoffSeconds: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSupervisorRelaunchesUntilRetriesRunOut(t *testing.T) {
+	sys := &fakeSystem{alive: map[int]bool{100: true}, nextPID: 200}
+	s := newTestSupervisor(t, sys)
+	keepAlive(t, 100, 2)
+
+	s.Check()
+	waitIdle(t, s)
+	if _, found := instance_account_tracker.GetInstance(100); !found {
+		t.Fatal("running instance was untracked")
+	}
+
+	// Crash: relaunched as 201 with the policy moved over
+	sys.alive[100] = false
+	s.Check()
+	waitIdle(t, s)
+	if _, found := instance_account_tracker.GetInstance(100); found {
+		t.Fatal("crashed instance is still tracked")
+	}
+	m, found := instance_account_tracker.GetInstance(201)
+	if !found || m.KeepAlive == nil || m.KeepAlive.Retries != 1 {
+		t.Fatalf("relaunched instance = %+v", m)
+	}
+
+	// A failed relaunch still counts against the policy
+	sys.alive[201] = false
+	sys.failNext = true
+	s.Check()
+	waitIdle(t, s)
+	if m, _ := instance_account_tracker.GetInstance(201); m.KeepAlive == nil || m.KeepAlive.Retries != 2 {
+		t.Fatalf("after failed relaunch = %+v", m)
+	}
+
+	// Out of retries: give up and forget the instance
+	s.Check()
+	waitIdle(t, s)
+	if _, found := instance_account_tracker.GetInstance(201); found {
+		t.Fatal("instance still tracked after retries ran out")
+	}
+
+	if len(sys.delays) != 2 || sys.delays[0] != time.Second || sys.delays[1] != 2*time.Second {
+		t.Fatalf("backoff delays = %v", sys.delays)
+	}
+}
+
+func TestSupervisorRelaunchesOnDisconnect(t *testing.T) {
+	sys := &fakeSystem{alive: map[int]bool{100: true}, nextPID: 200}
+	s := newTestSupervisor(t, sys)
+	keepAlive(t, 100, 3)
+
+	// Lines already in the log don't count
+	logPath := filepath.Join(t.TempDir(), "Player_last.log")
+	if err := os.WriteFile(logPath, []byte("[FLog::Network] Sending disconnect with reason: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	instance_account_tracker.SetLogPath(100, logPath)
+
+	s.Check()
+	waitIdle(t, s)
+	if len(sys.killed) != 0 {
+		t.Fatalf("killed %v before any new disconnect", sys.killed)
+	}
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("[FLog::Network] Sending disconnect with reason: 277\n")
+	f.Close()
+
+	s.Check()
+	waitIdle(t, s)
+	if len(sys.killed) != 1 || sys.killed[0] != 100 {
+		t.Fatalf("killed = %v, want [100]", sys.killed)
+	}
+	if m, found := instance_account_tracker.GetInstance(201); !found || m.KeepAlive == nil {
+		t.Fatalf("disconnected instance wasn't relaunched: %+v", m)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if got := Backoff(30, 1); got != 30*time.Second {
+		t.Fatalf("Backoff(30, 1) = %v", got)
+	}
+	if got := Backoff(30, 3); got != 2*time.Minute {
+		t.Fatalf("Backoff(30, 3) = %v", got)
+	}
+	if got := Backoff(30, 20); got != maxBackoff {
+		t.Fatalf("Backoff(30, 20) = %v", got)
+	}
+}
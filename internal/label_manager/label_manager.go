@@ -1,9 +1,9 @@
 package label_manager
 
 import (
+	"database/sql"
 	"encoding/json"
-	"os"
-	"path/filepath"
+	"insadem/multi_roblox_macos/internal/state_db"
 )
 
 // InstanceLabel represents a label for a Roblox instance
@@ -13,147 +13,153 @@ type InstanceLabel struct {
 	Color string `json:"color"`
 }
 
-// Config stores all instance labels
+// Config is the format of the labels JSON file
 type Config struct {
 	Labels []InstanceLabel `json:"labels"`
 }
 
-// GetConfigPath returns the path to the labels config file
-func GetConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	configDir := filepath.Join(homeDir, "Library", "Application Support", "multi_roblox_macos")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return "", err
-	}
-	return filepath.Join(configDir, "labels.json"), nil
+// labelsFile is the JSON file labels were kept in before the state database
+const labelsFile = "labels.json"
+
+func init() {
+	state_db.RegisterJSONSource(state_db.JSONSource{
+		File: labelsFile,
+		Import: func(tx *sql.Tx, data []byte) error {
+			var config Config
+			if err := json.Unmarshal(data, &config); err != nil {
+				return err
+			}
+			return replaceLabels(tx, config.Labels)
+		},
+		Export: func(db *sql.DB) ([]byte, error) {
+			labels, err := queryLabels(db)
+			if err != nil {
+				return nil, err
+			}
+			return json.MarshalIndent(Config{Labels: labels}, "", "  ")
+		},
+	})
 }
 
-// LoadLabels loads instance labels from config file
+// LoadLabels loads all instance labels
 func LoadLabels() ([]InstanceLabel, error) {
-	configPath, err := GetConfigPath()
+	db, err := state_db.Default()
 	if err != nil {
 		return nil, err
 	}
+	return queryLabels(db)
+}
 
-	// If file doesn't exist, return empty list
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return []InstanceLabel{}, nil
-	}
-
-	data, err := os.ReadFile(configPath)
+func queryLabels(db *sql.DB) ([]InstanceLabel, error) {
+	rows, err := db.Query("SELECT pid, label, color FROM labels ORDER BY pid")
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+	labels := []InstanceLabel{}
+	for rows.Next() {
+		var label InstanceLabel
+		if err := rows.Scan(&label.PID, &label.Label, &label.Color); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
 	}
-
-	return config.Labels, nil
+	return labels, rows.Err()
 }
 
-// SaveLabels saves instance labels to config file
+// SaveLabels replaces all instance labels
 func SaveLabels(labels []InstanceLabel) error {
-	configPath, err := GetConfigPath()
-	if err != nil {
-		return err
-	}
+	return state_db.Update(func(tx *sql.Tx) error {
+		return replaceLabels(tx, labels)
+	})
+}
 
-	config := Config{Labels: labels}
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
+func replaceLabels(tx *sql.Tx, labels []InstanceLabel) error {
+	if _, err := tx.Exec("DELETE FROM labels"); err != nil {
 		return err
 	}
-
-	return os.WriteFile(configPath, data, 0600) // Secure permissions - owner only
+	for _, label := range labels {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO labels (pid, label, color) VALUES (?, ?, ?)",
+			label.PID, label.Label, label.Color); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetLabel returns the label for a specific PID
 func GetLabel(pid int) (InstanceLabel, bool) {
-	labels, err := LoadLabels()
+	db, err := state_db.Default()
 	if err != nil {
 		return InstanceLabel{}, false
 	}
 
-	for _, label := range labels {
-		if label.PID == pid {
-			return label, true
-		}
+	label := InstanceLabel{PID: pid}
+	err = db.QueryRow("SELECT label, color FROM labels WHERE pid = ?", pid).Scan(&label.Label, &label.Color)
+	if err != nil {
+		return InstanceLabel{}, false
 	}
-
-	return InstanceLabel{}, false
+	return label, true
 }
 
 // SetLabel sets or updates a label for a PID
 func SetLabel(pid int, labelText, color string) error {
-	labels, err := LoadLabels()
+	db, err := state_db.Default()
 	if err != nil {
 		return err
 	}
-
-	// Update existing or add new
-	found := false
-	for i := range labels {
-		if labels[i].PID == pid {
-			labels[i].Label = labelText
-			labels[i].Color = color
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		labels = append(labels, InstanceLabel{
-			PID:   pid,
-			Label: labelText,
-			Color: color,
-		})
-	}
-
-	return SaveLabels(labels)
+	_, err = db.Exec(`INSERT INTO labels (pid, label, color) VALUES (?, ?, ?)
+		ON CONFLICT (pid) DO UPDATE SET label = excluded.label, color = excluded.color`,
+		pid, labelText, color)
+	return err
 }
 
 // DeleteLabel removes a label for a PID
 func DeleteLabel(pid int) error {
-	labels, err := LoadLabels()
+	db, err := state_db.Default()
 	if err != nil {
 		return err
 	}
-
-	newLabels := []InstanceLabel{}
-	for _, label := range labels {
-		if label.PID != pid {
-			newLabels = append(newLabels, label)
-		}
-	}
-
-	return SaveLabels(newLabels)
+	_, err = db.Exec("DELETE FROM labels WHERE pid = ?", pid)
+	return err
 }
 
 // CleanupStaleLabels removes labels for PIDs that no longer exist
 func CleanupStaleLabels(activePIDs []int) error {
-	labels, err := LoadLabels()
-	if err != nil {
-		return err
-	}
-
 	pidMap := make(map[int]bool)
 	for _, pid := range activePIDs {
 		pidMap[pid] = true
 	}
 
-	newLabels := []InstanceLabel{}
-	for _, label := range labels {
-		if pidMap[label.PID] {
-			newLabels = append(newLabels, label)
+	return state_db.Update(func(tx *sql.Tx) error {
+		rows, err := tx.Query("SELECT pid FROM labels")
+		if err != nil {
+			return err
+		}
+		var stale []int
+		for rows.Next() {
+			var pid int
+			if err := rows.Scan(&pid); err != nil {
+				rows.Close()
+				return err
+			}
+			if !pidMap[pid] {
+				stale = append(stale, pid)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
 		}
-	}
 
-	return SaveLabels(newLabels)
+		for _, pid := range stale {
+			if _, err := tx.Exec("DELETE FROM labels WHERE pid = ?", pid); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // DefaultColors returns a list of default label colors
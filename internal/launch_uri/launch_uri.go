@@ -0,0 +1,307 @@
+package launch_uri
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PlaceLauncherURL is the endpoint the Roblox client asks for a server
+const PlaceLauncherURL = "https://assetgame.roblox.com/game/PlaceLauncher.ashx"
+
+// DefaultLocale is used when a request doesn't set one
+const DefaultLocale = "en_us"
+
+// RequestType is the PlaceLauncher request kind
+type RequestType string
+
+const (
+	RequestGame        RequestType = "RequestGame"        // Any public server of a place
+	RequestGameJob     RequestType = "RequestGameJob"     // A specific server (job ID)
+	RequestPrivateGame RequestType = "RequestPrivateGame" // A private server
+	RequestFollowUser  RequestType = "RequestFollowUser"  // Whatever server a user is in
+)
+
+// LaunchRequest describes what the Roblox client should join
+type LaunchRequest struct {
+	Request      RequestType // Derived from the other fields if empty
+	PlaceID      int64
+	JobID        string // Server instance ID, for RequestGameJob
+	FollowUserID int64  // For RequestFollowUser
+	LinkCode     string // Private server link code
+	AccessCode   string // Private server access code
+
+	AuthTicket       string
+	LaunchTime       time.Time // Defaults to now
+	BrowserTrackerID int64     // Defaults to a time-based value
+	RobloxLocale     string    // Defaults to DefaultLocale
+	GameLocale       string    // Defaults to DefaultLocale
+	Channel          string
+}
+
+// RequestType returns the request kind, deriving it if not set explicitly
+func (r LaunchRequest) RequestType() RequestType {
+	switch {
+	case r.Request != "":
+		return r.Request
+	case r.FollowUserID > 0:
+		return RequestFollowUser
+	case r.LinkCode != "" || r.AccessCode != "":
+		return RequestPrivateGame
+	case r.JobID != "":
+		return RequestGameJob
+	default:
+		return RequestGame
+	}
+}
+
+// Validate checks that the request has what its type needs
+func (r LaunchRequest) Validate() error {
+	switch r.RequestType() {
+	case RequestFollowUser:
+		if r.FollowUserID <= 0 {
+			return fmt.Errorf("follow request needs a user ID")
+		}
+		return nil
+	case RequestGameJob:
+		if r.JobID == "" {
+			return fmt.Errorf("server request needs a job ID")
+		}
+	case RequestPrivateGame:
+		if r.LinkCode == "" && r.AccessCode == "" {
+			return fmt.Errorf("private server request needs a link or access code")
+		}
+	case RequestGame:
+	default:
+		return fmt.Errorf("unknown request type %q", r.Request)
+	}
+	if r.PlaceID <= 0 {
+		return fmt.Errorf("place ID is required")
+	}
+	return nil
+}
+
+// PlaceLauncherURL returns the PlaceLauncher URL the client fetches to join
+func (r LaunchRequest) PlaceLauncherURL() string {
+	var b strings.Builder
+	b.WriteString(PlaceLauncherURL)
+	b.WriteString("?request=")
+	b.WriteString(string(r.RequestType()))
+
+	add := func(key, value string) {
+		b.WriteString("&")
+		b.WriteString(key)
+		b.WriteString("=")
+		b.WriteString(url.QueryEscape(value))
+	}
+
+	add("browserTrackerId", strconv.FormatInt(r.browserTrackerID(), 10))
+	if r.PlaceID > 0 {
+		add("placeId", strconv.FormatInt(r.PlaceID, 10))
+	}
+	switch r.RequestType() {
+	case RequestGame:
+		add("isPlayTogetherGame", "false")
+	case RequestGameJob:
+		add("gameId", r.JobID)
+	case RequestPrivateGame:
+		if r.LinkCode != "" {
+			add("linkCode", r.LinkCode)
+		}
+		if r.AccessCode != "" {
+			add("accessCode", r.AccessCode)
+		}
+	case RequestFollowUser:
+		add("userId", strconv.FormatInt(r.FollowUserID, 10))
+	}
+	return b.String()
+}
+
+// PlayerURI returns the roblox-player: protocol string the website hands to
+// the client. It carries the auth ticket, so it should not be logged whole.
+func (r LaunchRequest) PlayerURI() string {
+	launchTime := r.LaunchTime
+	if launchTime.IsZero() {
+		launchTime = time.Now()
+	}
+
+	fields := []string{
+		"1",
+		"launchmode:play",
+		"gameinfo:" + escape(r.AuthTicket),
+		"launchtime:" + strconv.FormatInt(launchTime.UnixMilli(), 10),
+		"placelauncherurl:" + escape(r.PlaceLauncherURL()),
+		"browsertrackerid:" + strconv.FormatInt(r.browserTrackerID(), 10),
+		"robloxLocale:" + escape(orDefault(r.RobloxLocale, DefaultLocale)),
+		"gameLocale:" + escape(orDefault(r.GameLocale, DefaultLocale)),
+		"channel:" + escape(r.Channel),
+	}
+	return "roblox-player:" + strings.Join(fields, "+")
+}
+
+// RobloxURI returns a roblox:// deep link, which launches with whatever
+// account the client is signed into
+func (r LaunchRequest) RobloxURI() string {
+	if r.RequestType() == RequestGame {
+		return fmt.Sprintf("roblox://placeId=%d", r.PlaceID)
+	}
+
+	query := url.Values{}
+	if r.PlaceID > 0 {
+		query.Set("placeId", strconv.FormatInt(r.PlaceID, 10))
+	}
+	switch r.RequestType() {
+	case RequestGameJob:
+		query.Set("gameInstanceId", r.JobID)
+	case RequestPrivateGame:
+		if r.LinkCode != "" {
+			query.Set("linkCode", r.LinkCode)
+		}
+		if r.AccessCode != "" {
+			query.Set("accessCode", r.AccessCode)
+		}
+	case RequestFollowUser:
+		query.Set("userId", strconv.FormatInt(r.FollowUserID, 10))
+	}
+	return "roblox://experiences/start?" + query.Encode()
+}
+
+// URI returns PlayerURI when there is an auth ticket and RobloxURI otherwise
+func (r LaunchRequest) URI() string {
+	if r.AuthTicket != "" {
+		return r.PlayerURI()
+	}
+	return r.RobloxURI()
+}
+
+// Redacted returns the URI with the auth ticket removed, for logging
+func (r LaunchRequest) Redacted() string {
+	if r.AuthTicket == "" {
+		return r.URI()
+	}
+	r.AuthTicket = "REDACTED"
+	return r.PlayerURI()
+}
+
+func (r LaunchRequest) browserTrackerID() int64 {
+	if r.BrowserTrackerID != 0 {
+		return r.BrowserTrackerID
+	}
+	if !r.LaunchTime.IsZero() {
+		return r.LaunchTime.UnixMilli() % 1000000000
+	}
+	return time.Now().UnixMilli() % 1000000000
+}
+
+// Parse reads a roblox-player: or roblox:// URI
+func Parse(uri string) (*LaunchRequest, error) {
+	switch {
+	case strings.HasPrefix(uri, "roblox-player:"):
+		return parsePlayerURI(strings.TrimPrefix(uri, "roblox-player:"))
+	case strings.HasPrefix(uri, "roblox://"):
+		return parseRobloxURI(strings.TrimPrefix(uri, "roblox://"))
+	default:
+		return nil, fmt.Errorf("not a Roblox launch URI")
+	}
+}
+
+func parsePlayerURI(body string) (*LaunchRequest, error) {
+	r := &LaunchRequest{}
+	var launcherURL string
+
+	for _, field := range strings.Split(body, "+") {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok {
+			continue // The leading version number
+		}
+		decoded, err := url.PathUnescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value: %w", key, err)
+		}
+
+		switch key {
+		case "gameinfo":
+			r.AuthTicket = decoded
+		case "launchtime":
+			if ms, err := strconv.ParseInt(decoded, 10, 64); err == nil {
+				r.LaunchTime = time.UnixMilli(ms)
+			}
+		case "placelauncherurl":
+			launcherURL = decoded
+		case "browsertrackerid":
+			r.BrowserTrackerID, _ = strconv.ParseInt(decoded, 10, 64)
+		case "robloxLocale":
+			r.RobloxLocale = decoded
+		case "gameLocale":
+			r.GameLocale = decoded
+		case "channel":
+			r.Channel = decoded
+		}
+	}
+
+	if launcherURL == "" {
+		return r, nil
+	}
+
+	u, err := url.Parse(launcherURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid placelauncherurl: %w", err)
+	}
+	query := u.Query()
+	r.Request = RequestType(query.Get("request"))
+	r.PlaceID, _ = strconv.ParseInt(query.Get("placeId"), 10, 64)
+	r.JobID = query.Get("gameId")
+	r.FollowUserID, _ = strconv.ParseInt(query.Get("userId"), 10, 64)
+	r.LinkCode = query.Get("linkCode")
+	r.AccessCode = query.Get("accessCode")
+	if id, err := strconv.ParseInt(query.Get("browserTrackerId"), 10, 64); err == nil && r.BrowserTrackerID == 0 {
+		r.BrowserTrackerID = id
+	}
+	return r, nil
+}
+
+func parseRobloxURI(body string) (*LaunchRequest, error) {
+	r := &LaunchRequest{}
+
+	// Legacy form: roblox://placeId=123
+	if strings.HasPrefix(body, "placeId=") {
+		query, err := url.ParseQuery(body)
+		if err != nil {
+			return nil, err
+		}
+		if r.PlaceID, err = strconv.ParseInt(query.Get("placeId"), 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid place ID: %w", err)
+		}
+		return r, nil
+	}
+
+	path, rawQuery, _ := strings.Cut(body, "?")
+	if path != "experiences/start" {
+		return nil, fmt.Errorf("unsupported roblox:// link %q", path)
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+	r.PlaceID, _ = strconv.ParseInt(query.Get("placeId"), 10, 64)
+	r.JobID = query.Get("gameInstanceId")
+	r.FollowUserID, _ = strconv.ParseInt(query.Get("userId"), 10, 64)
+	r.LinkCode = query.Get("linkCode")
+	r.AccessCode = query.Get("accessCode")
+	return r, nil
+}
+
+// escape percent-encodes a field value. Spaces become %20 rather than '+',
+// which separates fields.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
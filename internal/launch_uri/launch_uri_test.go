@@ -0,0 +1,99 @@
+package launch_uri
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlayerURIRoundTrip(t *testing.T) {
+	launchTime := time.UnixMilli(1700000000123)
+
+	requests := []LaunchRequest{
+		{PlaceID: 606849621},
+		{PlaceID: 606849621, JobID: "0f2d7a3c-1b2e-4c5d-8e9f-a0b1c2d3e4f5"},
+		{PlaceID: 606849621, LinkCode: "abc+def/&=?"},
+		{PlaceID: 606849621, AccessCode: "e1d2c3"},
+		{FollowUserID: 156},
+	}
+
+	for _, want := range requests {
+		want.AuthTicket = "ticket with spaces+plus&amp"
+		want.LaunchTime = launchTime
+		want.BrowserTrackerID = 42
+		want.RobloxLocale = "de_de"
+		want.GameLocale = "en_us"
+		want.Channel = "zbeta"
+
+		uri := want.PlayerURI()
+		if strings.Count(uri, "+") != 8 {
+			t.Fatalf("field values not escaped: %s", uri)
+		}
+
+		got, err := Parse(uri)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", uri, err)
+		}
+		want.Request = want.RequestType()
+		if !got.LaunchTime.Equal(want.LaunchTime) {
+			t.Fatalf("launch time = %v, want %v", got.LaunchTime, want.LaunchTime)
+		}
+		got.LaunchTime = want.LaunchTime
+		if *got != want {
+			t.Fatalf("round trip of %s\n got %+v\nwant %+v", uri, *got, want)
+		}
+	}
+}
+
+func TestRobloxURIRoundTrip(t *testing.T) {
+	requests := []LaunchRequest{
+		{PlaceID: 1818},
+		{PlaceID: 1818, JobID: "job-1"},
+		{PlaceID: 1818, LinkCode: "code&x"},
+		{FollowUserID: 156},
+	}
+
+	for _, want := range requests {
+		got, err := Parse(want.RobloxURI())
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", want.RobloxURI(), err)
+		}
+		if *got != want {
+			t.Fatalf("round trip of %s: got %+v", want.RobloxURI(), *got)
+		}
+	}
+
+	if uri := (LaunchRequest{PlaceID: 1818}).RobloxURI(); uri != "roblox://placeId=1818" {
+		t.Fatalf("plain place URI = %s", uri)
+	}
+}
+
+func TestParseWebsiteURI(t *testing.T) {
+	// As handed out by the Roblox website
+	uri := "roblox-player:1+launchmode:play+gameinfo:TICKET+launchtime:1700000000000" +
+		"+placelauncherurl:https%3A%2F%2Fassetgame.roblox.com%2Fgame%2FPlaceLauncher.ashx%3Frequest%3DRequestGameJob%26browserTrackerId%3D99%26placeId%3D1818%26gameId%3Djob-1" +
+		"+browsertrackerid:99+robloxLocale:en_us+gameLocale:en_us+channel:+LaunchExp:InApp"
+
+	r, err := Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.RequestType() != RequestGameJob || r.PlaceID != 1818 || r.JobID != "job-1" || r.AuthTicket != "TICKET" {
+		t.Fatalf("Parse = %+v", r)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (LaunchRequest{PlaceID: 1}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (LaunchRequest{}).Validate(); err == nil {
+		t.Fatal("missing place ID accepted")
+	}
+	if err := (LaunchRequest{Request: RequestGameJob, PlaceID: 1}).Validate(); err == nil {
+		t.Fatal("job request without job ID accepted")
+	}
+	if err := (LaunchRequest{FollowUserID: 5}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,213 @@
+package launch_verifier
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logEventKind is what a client log line says about the join
+type logEventKind int
+
+const (
+	logNone       logEventKind = iota
+	logJoining                 // Client picked a server and is connecting
+	logJoined                  // Connected to the game server
+	logAuthFailed              // The auth ticket or session was rejected
+	logDisconnect              // Lost or refused the game connection
+)
+
+type logEvent struct {
+	Kind    logEventKind
+	PlaceID int64
+	JobID   string
+	Line    string
+}
+
+var (
+	// ! Joining game '0f2d7a3c-...' place 1818 at 128.116.1.2
+	joiningPattern = regexp.MustCompile(`Joining game '([0-9a-fA-F-]+)' place (\d+)`)
+	joinedPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`Report game_join_loadtime`),
+		regexp.MustCompile(`Connection accepted from`),
+		regexp.MustCompile(`Replicator created`),
+	}
+	authFailedPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)authentication (ticket )?(failed|invalid|expired)`),
+		regexp.MustCompile(`(?i)invalid (auth(entication)? )?ticket`),
+		regexp.MustCompile(`(?i)\b401\b.*unauthori[sz]ed`),
+		regexp.MustCompile(`(?i)not authori[sz]ed to join`),
+	}
+	disconnectPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)disconnect(ed)? reason`),
+		regexp.MustCompile(`(?i)sending disconnect with reason`),
+		regexp.MustCompile(`(?i)connection (lost|failed)`),
+	}
+)
+
+// parseLogLine classifies one line of the Roblox client log
+func parseLogLine(line string) logEvent {
+	if m := joiningPattern.FindStringSubmatch(line); m != nil {
+		placeID, _ := strconv.ParseInt(m[2], 10, 64)
+		return logEvent{Kind: logJoining, JobID: m[1], PlaceID: placeID, Line: line}
+	}
+	for _, p := range authFailedPatterns {
+		if p.MatchString(line) {
+			return logEvent{Kind: logAuthFailed, Line: line}
+		}
+	}
+	for _, p := range joinedPatterns {
+		if p.MatchString(line) {
+			return logEvent{Kind: logJoined, Line: line}
+		}
+	}
+	for _, p := range disconnectPatterns {
+		if p.MatchString(line) {
+			return logEvent{Kind: logDisconnect, Line: line}
+		}
+	}
+	return logEvent{Kind: logNone}
+}
+
+// DefaultLogDir returns where the Roblox client writes its logs
+func DefaultLogDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "Logs", "Roblox")
+}
+
+// logNameTime matches the start time in a client log name, such as
+// 0.650.0.6500734_20241016T120102Z_Player_3F2A1_last.log
+var logNameTime = regexp.MustCompile(`_(\d{8}T\d{6}Z)_`)
+
+// logStartTime returns when a client log was started, from its name if it
+// has a timestamp and its modification time otherwise
+func logStartTime(path string) (time.Time, error) {
+	if m := logNameTime.FindStringSubmatch(filepath.Base(path)); m != nil {
+		if t, err := time.Parse("20060102T150405Z", m[1]); err == nil {
+			return t, nil
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// logFollower reads new lines from the player log of one instance
+type logFollower struct {
+	dir    string
+	since  time.Time
+	path   string
+	offset int64
+	buf    string // Partial last line
+}
+
+// findLog claims the oldest unclaimed player log started after the launch.
+// The client log doesn't name the PID, so with simultaneous launches the
+// pairing is by start order.
+func (f *logFollower) findLog() bool {
+	if f.path != "" {
+		return true
+	}
+
+	files, err := filepath.Glob(filepath.Join(f.dir, "*.log"))
+	if err != nil {
+		return false
+	}
+
+	claimsMu.Lock()
+	defer claimsMu.Unlock()
+
+	// Log names only have second precision
+	since := f.since.Truncate(time.Second)
+	var best string
+	var bestTime time.Time
+	for _, file := range files {
+		if !strings.Contains(filepath.Base(file), "Player") || claimedLogs[file] {
+			continue
+		}
+		started, err := logStartTime(file)
+		if err != nil || started.Before(since) {
+			continue
+		}
+		if best == "" || started.Before(bestTime) {
+			best, bestTime = file, started
+		}
+	}
+	if best == "" {
+		return false
+	}
+
+	claimedLogs[best] = true
+	f.path = best
+	return true
+}
+
+// poll returns the events in lines written since the last poll
+func (f *logFollower) poll() []logEvent {
+	if !f.findLog() {
+		return nil
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil
+	}
+	f.offset += int64(len(data))
+
+	// Only complete lines are parsed; a partial last line waits for the next poll
+	text := f.buf + string(data)
+	end := strings.LastIndexByte(text, '\n') + 1
+	f.buf = text[end:]
+
+	var events []logEvent
+	scanner := bufio.NewScanner(strings.NewReader(text[:end]))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if event := parseLogLine(scanner.Text()); event.Kind != logNone {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// DisconnectWatcher follows a running instance's client log for disconnects
+type DisconnectWatcher struct {
+	follower *logFollower
+}
+
+// WatchDisconnects starts watching the client log at path. Only lines
+// written from now on count.
+func WatchDisconnects(path string) *DisconnectWatcher {
+	f := &logFollower{path: path}
+	if info, err := os.Stat(path); err == nil {
+		f.offset = info.Size()
+	}
+	return &DisconnectWatcher{follower: f}
+}
+
+// Disconnected returns the disconnect line if one was written since the
+// last call
+func (w *DisconnectWatcher) Disconnected() (string, bool) {
+	for _, event := range w.follower.poll() {
+		if event.Kind == logDisconnect {
+			return event.Line, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,291 @@
+package launch_verifier
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/ps_darwin"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"sync"
+	"time"
+)
+
+// Result is how a launch turned out
+type Result string
+
+const (
+	ResultJoined     Result = "joined"        // In the intended game
+	ResultStuckHome  Result = "stuck on home" // Running but never reached the game
+	ResultAuthFailed Result = "auth failed"   // The ticket or session was rejected
+	ResultCrashed    Result = "crashed"       // Exited, or never started
+)
+
+// Outcome is the verified result of a launch
+type Outcome struct {
+	Result  Result
+	PID     int    // 0 if no new instance was found
+	PlaceID int64  // Where the account ended up, if known
+	JobID   string // Server the account ended up in, if known
+	Detail  string // The evidence: a client log line or what presence showed
+	LogPath string // The instance's client log, if it was found
+	Elapsed time.Duration
+}
+
+// OK reports whether the instance joined its game
+func (o Outcome) OK() bool {
+	return o.Result == ResultJoined
+}
+
+// Target describes the launch to verify
+type Target struct {
+	// PID of the started player; 0 to watch for a new RobloxPlayer process,
+	// as with launches through `open`
+	PID int
+	// KnownPIDs are RobloxPlayer PIDs that were running before the launch
+	KnownPIDs []int
+	// UserID and Cookie of the launched account let presence confirm the
+	// join; without them only the client log is used
+	UserID int64
+	Cookie string
+	// PlaceID is the place the launch was for; 0 accepts any game
+	PlaceID    int64
+	LaunchedAt time.Time // Defaults to now
+	// OnPID is called as soon as the instance's PID is known
+	OnPID func(pid int)
+}
+
+// Options tunes verification. Zero values use the defaults.
+type Options struct {
+	Timeout      time.Duration // Default 90s
+	PollInterval time.Duration // Default 2s
+	LogDir       string        // Default DefaultLogDir()
+
+	// Replaced in tests
+	ListPIDs func() ([]int, error)
+	IsAlive  func(pid int) bool
+	Presence func(userID int64, cookie string) (*roblox_api.UserPresence, error)
+	Sleep    func(time.Duration)
+}
+
+func (o *Options) setDefaults() {
+	if o.Timeout == 0 {
+		o.Timeout = 90 * time.Second
+	}
+	if o.PollInterval == 0 {
+		o.PollInterval = 2 * time.Second
+	}
+	if o.LogDir == "" {
+		o.LogDir = DefaultLogDir()
+	}
+	if o.ListPIDs == nil {
+		o.ListPIDs = RunningPIDs
+	}
+	if o.IsAlive == nil {
+		o.IsAlive = ps_darwin.ProcessAlive
+	}
+	if o.Presence == nil {
+		o.Presence = userPresence
+	}
+	if o.Sleep == nil {
+		o.Sleep = time.Sleep
+	}
+}
+
+// Verify watches a launch until the instance joins its game, fails, or the
+// timeout passes. Evidence comes from the process table, the client log and,
+// if the account's cookie is given, the presence API.
+func Verify(target Target, opts Options) *Outcome {
+	opts.setDefaults()
+	holdClaims()
+	defer releaseClaims()
+	if target.LaunchedAt.IsZero() {
+		target.LaunchedAt = time.Now()
+	}
+
+	logs := &logFollower{dir: opts.LogDir, since: target.LaunchedAt}
+	pid := target.PID
+	if pid > 0 {
+		claimPID(pid)
+	}
+	known := make(map[int]bool)
+	for _, p := range target.KnownPIDs {
+		known[p] = true
+	}
+	usePresence := target.UserID > 0 && target.Cookie != ""
+
+	var joining *logEvent
+	var joinedLine, lastDisconnect, lastPresence string
+	finish := func(result Result, detail string, waited time.Duration) *Outcome {
+		outcome := &Outcome{Result: result, PID: pid, Detail: detail, LogPath: logs.path, Elapsed: waited}
+		if joining != nil {
+			outcome.PlaceID, outcome.JobID = joining.PlaceID, joining.JobID
+		}
+		logger.LogInfo("Launch verification (PID %d): %s - %s", pid, result, detail)
+		return outcome
+	}
+
+	for waited := time.Duration(0); ; waited += opts.PollInterval {
+		if pid == 0 {
+			if pid = findNewPID(opts.ListPIDs, known); pid > 0 && target.OnPID != nil {
+				target.OnPID(pid)
+			}
+		}
+
+		for _, event := range logs.poll() {
+			switch event.Kind {
+			case logAuthFailed:
+				return finish(ResultAuthFailed, event.Line, waited)
+			case logJoining:
+				event := event
+				joining = &event
+			case logJoined:
+				joinedLine = event.Line
+			case logDisconnect:
+				lastDisconnect = event.Line
+			}
+		}
+
+		if pid > 0 && !opts.IsAlive(pid) {
+			detail := "the player process exited"
+			if lastDisconnect != "" {
+				detail += ": " + lastDisconnect
+			}
+			return finish(ResultCrashed, detail, waited)
+		}
+
+		if usePresence {
+			presence, err := opts.Presence(target.UserID, target.Cookie)
+			if err != nil {
+				lastPresence = fmt.Sprintf("presence unavailable: %v", err)
+			} else {
+				lastPresence = describePresence(presence)
+				if pid > 0 && presence.UserPresenceType == 2 && placeMatches(presence, target.PlaceID) {
+					outcome := finish(ResultJoined, lastPresence, waited)
+					outcome.PlaceID, outcome.JobID = presence.PlaceID, presence.GameID
+					return outcome
+				}
+			}
+		} else if pid > 0 && joinedLine != "" && (joining == nil || target.PlaceID == 0 || joining.PlaceID == target.PlaceID) {
+			return finish(ResultJoined, joinedLine, waited)
+		}
+
+		if waited >= opts.Timeout {
+			break
+		}
+		opts.Sleep(opts.PollInterval)
+	}
+
+	// Timed out without a definite answer
+	switch {
+	case pid == 0:
+		return finish(ResultCrashed, "no new RobloxPlayer process appeared", opts.Timeout)
+	case joinedLine != "":
+		// Presence can lag behind the client
+		return finish(ResultJoined, "client log shows the game joined; presence didn't confirm it: "+lastPresence, opts.Timeout)
+	case lastDisconnect != "":
+		return finish(ResultStuckHome, lastDisconnect, opts.Timeout)
+	case lastPresence != "":
+		return finish(ResultStuckHome, lastPresence, opts.Timeout)
+	}
+	return finish(ResultStuckHome, "the client never joined a game", opts.Timeout)
+}
+
+func placeMatches(presence *roblox_api.UserPresence, placeID int64) bool {
+	return placeID == 0 || presence.PlaceID == placeID || presence.RootPlaceID == placeID
+}
+
+func describePresence(p *roblox_api.UserPresence) string {
+	switch p.UserPresenceType {
+	case 0:
+		return "presence shows the account offline"
+	case 1:
+		return "presence shows the account online but not in a game"
+	case 2:
+		return fmt.Sprintf("presence shows the account in %q (place %d)", p.LastLocation, p.PlaceID)
+	case 3:
+		return "presence shows the account in Studio"
+	}
+	return fmt.Sprintf("presence type %d", p.UserPresenceType)
+}
+
+// claimedPIDs and claimedLogs are new instances and client logs already
+// paired with a launch, so concurrent verifications don't pick the same
+// ones. Claims only keep overlapping verifications apart, so they are
+// dropped once the last one returns; a later launch lists the earlier
+// instances as known, and its log starts after them.
+var (
+	claimsMu    sync.Mutex
+	verifying   int
+	claimedPIDs = map[int]bool{}
+	claimedLogs = map[string]bool{}
+)
+
+func holdClaims() {
+	claimsMu.Lock()
+	defer claimsMu.Unlock()
+	verifying++
+}
+
+func releaseClaims() {
+	claimsMu.Lock()
+	defer claimsMu.Unlock()
+	verifying--
+	if verifying == 0 {
+		claimedPIDs = map[int]bool{}
+		claimedLogs = map[string]bool{}
+	}
+}
+
+func claimPID(pid int) {
+	claimsMu.Lock()
+	defer claimsMu.Unlock()
+	claimedPIDs[pid] = true
+}
+
+// findNewPID claims the newest RobloxPlayer PID that wasn't running before
+// the launch and isn't paired with another launch
+func findNewPID(list func() ([]int, error), known map[int]bool) int {
+	pids, err := list()
+	if err != nil {
+		return 0
+	}
+
+	claimsMu.Lock()
+	defer claimsMu.Unlock()
+
+	newest := 0
+	for _, pid := range pids {
+		if !known[pid] && !claimedPIDs[pid] && pid > newest {
+			newest = pid
+		}
+	}
+	if newest > 0 {
+		claimedPIDs[newest] = true
+	}
+	return newest
+}
+
+// RunningPIDs returns the PIDs of running RobloxPlayer processes
+func RunningPIDs() ([]int, error) {
+	processes, err := ps_darwin.Processes()
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, proc := range processes {
+		if proc.Executable() == "RobloxPlayer" {
+			pids = append(pids, proc.Pid())
+		}
+	}
+	return pids, nil
+}
+
+func userPresence(userID int64, cookie string) (*roblox_api.UserPresence, error) {
+	presences, err := roblox_api.GetUserPresence([]int64{userID}, cookie)
+	if err != nil {
+		return nil, err
+	}
+	if len(presences) == 0 {
+		return nil, fmt.Errorf("no presence returned for user %d", userID)
+	}
+	return &presences[0], nil
+}
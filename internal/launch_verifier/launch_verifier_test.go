@@ -0,0 +1,141 @@
+package launch_verifier
+
+import (
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLogLine(t *testing.T) {
+	cases := []struct {
+		line string
+		kind logEventKind
+	}{
+		{"2024-10-16T12:01:05.123Z,5.1,2fa4,6 [FLog::Output] ! Joining game '0f2d7a3c-1b2e-4c5d-8e9f-a0b1c2d3e4f5' place 1818 at 128.116.1.2", logJoining},
+		{"2024-10-16T12:01:09.001Z,9.0,2fa4,6 [FLog::GameJoinLoadTime] Report game_join_loadtime: placeid:1818", logJoined},
+		{"2024-10-16T12:01:03.000Z,3.0,2fa4,6 [FLog::Error] Authentication failed for ticket", logAuthFailed},
+		{"2024-10-16T12:01:03.000Z,3.0,2fa4,6 [FLog::Network] Sending disconnect with reason: 277", logDisconnect},
+		{"2024-10-16T12:01:00.000Z,0.0,2fa4,6 [FLog::Output] Settings loaded", logNone},
+	}
+	for _, c := range cases {
+		if got := parseLogLine(c.line); got.Kind != c.kind {
+			t.Errorf("parseLogLine(%q) = %v, want %v", c.line, got.Kind, c.kind)
+		}
+	}
+
+	event := parseLogLine(cases[0].line)
+	if event.PlaceID != 1818 || event.JobID != "0f2d7a3c-1b2e-4c5d-8e9f-a0b1c2d3e4f5" {
+		t.Fatalf("joining event = %+v", event)
+	}
+}
+
+// writeClientLog writes a player log named as the client names them
+func writeClientLog(t *testing.T, dir string, started time.Time, lines string) string {
+	t.Helper()
+	name := "0.650.0.6500734_" + started.UTC().Format("20060102T150405Z") + "_Player_" + started.Format("150405") + "_last.log"
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testOptions(dir string, pids []int) Options {
+	return Options{
+		Timeout:      10 * time.Second,
+		PollInterval: time.Second,
+		LogDir:       dir,
+		ListPIDs:     func() ([]int, error) { return pids, nil },
+		IsAlive:      func(int) bool { return true },
+		Presence: func(int64, string) (*roblox_api.UserPresence, error) {
+			return &roblox_api.UserPresence{UserPresenceType: 1}, nil
+		},
+		Sleep: func(time.Duration) {},
+	}
+}
+
+func TestVerifyJoinedByPresence(t *testing.T) {
+	opts := testOptions(t.TempDir(), []int{500, 501})
+	polls := 0
+	opts.Presence = func(userID int64, cookie string) (*roblox_api.UserPresence, error) {
+		polls++
+		if polls < 3 {
+			return &roblox_api.UserPresence{UserPresenceType: 1}, nil
+		}
+		return &roblox_api.UserPresence{UserPresenceType: 2, PlaceID: 1818, RootPlaceID: 1818, GameID: "job-1"}, nil
+	}
+
+	var reported int
+	outcome := Verify(Target{
+		KnownPIDs: []int{500},
+		UserID:    42,
+		Cookie:    "cookie",
+		PlaceID:   1818,
+		OnPID:     func(pid int) { reported = pid },
+	}, opts)
+
+	if outcome.Result != ResultJoined || outcome.PID != 501 || outcome.JobID != "job-1" {
+		t.Fatalf("outcome = %+v", outcome)
+	}
+	if reported != 501 {
+		t.Fatalf("OnPID got %d, want 501", reported)
+	}
+}
+
+func TestVerifyAuthFailedFromLog(t *testing.T) {
+	dir := t.TempDir()
+	launched := time.Now()
+	// A log from before the launch belongs to another instance
+	writeClientLog(t, dir, launched.Add(-time.Hour), "[FLog::Output] ! Joining game 'abc-1' place 1818 at 1.2.3.4\n")
+	writeClientLog(t, dir, launched.Add(time.Second), "[FLog::Output] Settings loaded\n[FLog::Error] Authentication failed for ticket\n")
+
+	outcome := Verify(Target{PID: 700, PlaceID: 1818, LaunchedAt: launched}, testOptions(dir, nil))
+	if outcome.Result != ResultAuthFailed {
+		t.Fatalf("outcome = %+v", outcome)
+	}
+}
+
+func TestVerifyCrashedAndStuck(t *testing.T) {
+	opts := testOptions(t.TempDir(), nil)
+	opts.IsAlive = func(int) bool { return false }
+	if outcome := Verify(Target{PID: 800}, opts); outcome.Result != ResultCrashed {
+		t.Fatalf("dead process: outcome = %+v", outcome)
+	}
+
+	// No new process ever shows up
+	opts = testOptions(t.TempDir(), []int{900})
+	if outcome := Verify(Target{KnownPIDs: []int{900}}, opts); outcome.Result != ResultCrashed || outcome.PID != 0 {
+		t.Fatalf("no process: outcome = %+v", outcome)
+	}
+
+	// Running, but presence never leaves the home screen
+	opts = testOptions(t.TempDir(), nil)
+	outcome := Verify(Target{PID: 801, UserID: 42, Cookie: "cookie", PlaceID: 1818}, opts)
+	if outcome.Result != ResultStuckHome {
+		t.Fatalf("home screen: outcome = %+v", outcome)
+	}
+}
+
+func TestVerifyReleasesClaims(t *testing.T) {
+	opts := testOptions(t.TempDir(), []int{600})
+	opts.IsAlive = func(int) bool { return false }
+	if outcome := Verify(Target{}, opts); outcome.PID != 600 {
+		t.Fatalf("first launch: outcome = %+v", outcome)
+	}
+
+	// Once that verification is over, a new player reusing the PID is found
+	if outcome := Verify(Target{}, opts); outcome.PID != 600 {
+		t.Fatalf("reused PID: outcome = %+v", outcome)
+	}
+
+	// While another verification runs, its instance stays claimed
+	holdClaims()
+	claimPID(600)
+	outcome := Verify(Target{}, opts)
+	releaseClaims()
+	if outcome.PID != 0 {
+		t.Fatalf("claimed PID picked again: outcome = %+v", outcome)
+	}
+}
@@ -0,0 +1,84 @@
+package launcher
+
+import (
+	"database/sql"
+	"insadem/multi_roblox_macos/internal/launch_verifier"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/state_db"
+	"time"
+)
+
+// HistoryEntry is a finished launch as recorded in the state database
+type HistoryEntry struct {
+	ID          int64
+	AccountID   string
+	Description string
+	PlaceID     int64
+	JobID       string
+	PID         int
+	Status      Status
+	Err         string
+	Result      launch_verifier.Result // Empty if the launch wasn't verified
+	Detail      string
+	QueuedAt    time.Time
+	FinishedAt  time.Time
+}
+
+// recordHistory stores a finished job in the launch history
+func recordHistory(state JobState) {
+	entry := HistoryEntry{
+		AccountID:   state.Request.Account.ID,
+		Description: state.Request.Label(),
+		PlaceID:     state.PlaceID,
+		JobID:       state.JobID,
+		PID:         state.PID,
+		Status:      state.Status,
+		QueuedAt:    state.QueuedAt,
+		FinishedAt:  time.Now(),
+	}
+	if state.Err != nil {
+		entry.Err = state.Err.Error()
+	}
+	if state.Outcome != nil {
+		entry.Result = state.Outcome.Result
+		entry.Detail = state.Outcome.Detail
+	}
+
+	err := state_db.Update(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO launch_history
+			(account_id, description, place_id, job_id, pid, status, error, result, detail, queued_at, finished_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			entry.AccountID, entry.Description, entry.PlaceID, entry.JobID, entry.PID, string(entry.Status),
+			entry.Err, string(entry.Result), entry.Detail, entry.QueuedAt, entry.FinishedAt)
+		return err
+	})
+	if err != nil {
+		logger.LogError("Failed to record launch #%d in history: %v", state.ID, err)
+	}
+}
+
+// History returns up to limit recorded launches, newest first
+func History(limit int) ([]HistoryEntry, error) {
+	db, err := state_db.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT id, account_id, description, place_id, job_id, pid, status, error, result, detail, queued_at, finished_at
+		FROM launch_history ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		if err := rows.Scan(&entry.ID, &entry.AccountID, &entry.Description, &entry.PlaceID, &entry.JobID, &entry.PID,
+			&entry.Status, &entry.Err, &entry.Result, &entry.Detail, &entry.QueuedAt, &entry.FinishedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
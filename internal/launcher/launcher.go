@@ -0,0 +1,498 @@
+package launcher
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/config_store"
+	"insadem/multi_roblox_macos/internal/cookie_manager"
+	"insadem/multi_roblox_macos/internal/instance_account_tracker"
+	"insadem/multi_roblox_macos/internal/launch_verifier"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/preset_manager"
+	"sync"
+	"time"
+)
+
+// Status is where a launch job is
+type Status string
+
+const (
+	StatusQueued         Status = "queued"
+	StatusCloning        Status = "cloning" // Only when a new clone is copied
+	StatusFetchingTicket Status = "fetching ticket"
+	StatusStarting       Status = "starting"
+	StatusRunning        Status = "running"
+	StatusFailed         Status = "failed"
+)
+
+// Done reports whether the job has finished, successfully or not
+func (s Status) Done() bool {
+	return s == StatusRunning || s == StatusFailed
+}
+
+// Request is one account launch
+type Request struct {
+	Account account_manager.Account
+	// Preset is launched with an auth ticket. A private server link code is
+	// used as-is, so clear it for public launches.
+	Preset preset_manager.Preset
+	// FollowUserID, if set, follows that user instead; Preset.PlaceID is the
+	// fallback if the follow is rejected
+	FollowUserID int64
+}
+
+// Label describes the request for display
+func (r Request) Label() string {
+	if r.FollowUserID > 0 {
+		return fmt.Sprintf("%s → following user %d", r.Account.Username, r.FollowUserID)
+	}
+	return fmt.Sprintf("%s → %s", r.Account.Username, r.Preset.Name)
+}
+
+// JobState is a snapshot of a launch job
+type JobState struct {
+	ID      int
+	Request Request
+	Status  Status
+	PID     int   // Once started
+	PlaceID int64 // Where the instance was sent
+	JobID   string
+	Follow  *preset_manager.FollowLaunchResult // For follow requests
+	Err     error                              // Set for StatusFailed
+	// Outcome is set once a running instance has been verified to have
+	// joined, or not
+	Outcome   *launch_verifier.Outcome
+	QueuedAt  time.Time
+	StartedAt time.Time // When the player process was started
+	UpdatedAt time.Time
+}
+
+// Job is a handle to a submitted launch
+type Job struct {
+	entry    *entry
+	launcher *Launcher
+}
+
+// ID returns the job's ID, which matches JobState.ID in updates
+func (j *Job) ID() int {
+	return j.entry.state.ID
+}
+
+// Wait blocks until the job has finished and returns its final state
+func (j *Job) Wait() JobState {
+	<-j.entry.done
+	return j.launcher.snapshot(j.entry)
+}
+
+type entry struct {
+	state JobState
+	done  chan struct{}
+}
+
+// launchPlan is what fetching a ticket produces: the protocol string to
+// start the player with and where it leads
+type launchPlan struct {
+	ProtocolString string
+	PlaceID        int64
+	JobID          string
+	Follow         *preset_manager.FollowLaunchResult
+}
+
+// starter starts a prepared player binary
+type starter interface {
+	Start(args ...string) (int, error)
+	Release() // Gives up the binary without starting it
+}
+
+const (
+	// DefaultMaxConcurrent is how many launches may run at once by default
+	DefaultMaxConcurrent = 2
+	// DefaultMinGap is the default minimum time between starting launches
+	DefaultMinGap = 3 * time.Second
+
+	// keepFinished is how many finished jobs are kept for display
+	keepFinished = 20
+	// subscriberBuffer is how many updates a slow subscriber may fall behind
+	subscriberBuffer = 64
+)
+
+// Launcher runs account launches from a FIFO queue, limiting how many run
+// at once and spacing out their starts so ticket requests, clones and
+// process starts don't pile up.
+type Launcher struct {
+	mu            sync.Mutex
+	maxConcurrent int
+	minGap        time.Duration
+	queue         []*entry
+	jobs          []*entry // Queued, active and recently finished, oldest first
+	running       int
+	lastStart     time.Time
+	timerPending  bool
+	nextID        int
+	subscribers   map[int]chan JobState
+	nextSub       int
+
+	// Launch steps; replaced in tests
+	plan    func(req Request) (*launchPlan, error)
+	prepare func(onCopy func()) (starter, error)
+	track   func(state JobState)
+	verify  func(state JobState) *launch_verifier.Outcome // nil skips verification
+	record  func(state JobState)                          // nil skips history
+}
+
+// New returns a launcher running at most maxConcurrent launches at once,
+// starting each at least minGap after the previous one
+func New(maxConcurrent int, minGap time.Duration) *Launcher {
+	l := &Launcher{
+		subscribers: make(map[int]chan JobState),
+		plan:        planLaunch,
+		prepare:     preparePlayer,
+		track:       trackInstance,
+		verify:      verifyLaunch,
+		record:      recordHistory,
+	}
+	l.SetLimits(maxConcurrent, minGap)
+	return l
+}
+
+// SetLimits changes the concurrency and spacing limits. Values below 1 and
+// 0 respectively are raised to them.
+func (l *Launcher) SetLimits(maxConcurrent int, minGap time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxConcurrent = max(maxConcurrent, 1)
+	l.minGap = max(minGap, 0)
+	l.dispatchLocked()
+}
+
+// Limits returns the current concurrency and spacing limits
+func (l *Launcher) Limits() (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.maxConcurrent, l.minGap
+}
+
+// Submit queues a launch and returns its handle
+func (l *Launcher) Submit(req Request) *Job {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextID++
+	now := time.Now()
+	e := &entry{
+		state: JobState{ID: l.nextID, Request: req, Status: StatusQueued, QueuedAt: now, UpdatedAt: now},
+		done:  make(chan struct{}),
+	}
+	l.queue = append(l.queue, e)
+	l.jobs = append(l.jobs, e)
+	logger.LogInfo("Queued launch #%d: %s (%d waiting)", e.state.ID, req.Label(), len(l.queue))
+
+	l.publishLocked(e)
+	l.dispatchLocked()
+	return &Job{entry: e, launcher: l}
+}
+
+// Jobs returns queued, active and recently finished jobs, oldest first
+func (l *Launcher) Jobs() []JobState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	states := make([]JobState, len(l.jobs))
+	for i, e := range l.jobs {
+		states[i] = e.state
+	}
+	return states
+}
+
+// Subscribe returns a channel receiving every job update and a function
+// that unsubscribes. Updates are dropped for a subscriber that falls too
+// far behind, so use Jobs for the full picture.
+func (l *Launcher) Subscribe() (<-chan JobState, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextSub++
+	id := l.nextSub
+	ch := make(chan JobState, subscriberBuffer)
+	l.subscribers[id] = ch
+
+	return ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if ch, ok := l.subscribers[id]; ok {
+			delete(l.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// dispatchLocked starts queued jobs while limits allow, scheduling another
+// try when the gap since the last start hasn't passed yet
+func (l *Launcher) dispatchLocked() {
+	for len(l.queue) > 0 && l.running < l.maxConcurrent {
+		if wait := l.minGap - time.Since(l.lastStart); !l.lastStart.IsZero() && wait > 0 {
+			if !l.timerPending {
+				l.timerPending = true
+				time.AfterFunc(wait, func() {
+					l.mu.Lock()
+					defer l.mu.Unlock()
+					l.timerPending = false
+					l.dispatchLocked()
+				})
+			}
+			return
+		}
+
+		e := l.queue[0]
+		l.queue = l.queue[1:]
+		l.running++
+		l.lastStart = time.Now()
+		go l.run(e)
+	}
+}
+
+// run takes a job through its launch steps
+func (l *Launcher) run(e *entry) {
+	defer func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.running--
+		close(e.done)
+		l.trimLocked()
+		l.dispatchLocked()
+	}()
+
+	req := l.snapshot(e).Request
+	fail := func(err error) {
+		logger.LogError("Launch #%d (%s) failed: %v", e.state.ID, req.Label(), err)
+		l.update(e, func(s *JobState) {
+			s.Status = StatusFailed
+			s.Err = err
+		})
+		l.recordFinished(e)
+	}
+
+	// The clone is copied before the ticket is fetched, since a ticket
+	// expires soon after it's issued
+	player, err := l.prepare(func() {
+		l.update(e, func(s *JobState) { s.Status = StatusCloning })
+	})
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	l.update(e, func(s *JobState) { s.Status = StatusFetchingTicket })
+	plan, err := l.plan(req)
+	if err != nil {
+		player.Release()
+		fail(err)
+		return
+	}
+
+	l.update(e, func(s *JobState) {
+		s.Status = StatusStarting
+		s.StartedAt = time.Now()
+		s.PlaceID = plan.PlaceID
+		s.JobID = plan.JobID
+		s.Follow = plan.Follow
+	})
+	pid, err := player.Start("-protocolString", plan.ProtocolString)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	l.update(e, func(s *JobState) {
+		s.Status = StatusRunning
+		s.PID = pid
+	})
+	l.track(l.snapshot(e))
+	logger.LogInfo("Launch #%d (%s) started, PID: %d", e.state.ID, req.Label(), pid)
+
+	// Verification can take a minute, so it runs without holding a slot.
+	// History waits for it so the entry includes the outcome.
+	if l.verify == nil {
+		l.recordFinished(e)
+		return
+	}
+	go func() {
+		outcome := l.verify(l.snapshot(e))
+		l.update(e, func(s *JobState) { s.Outcome = outcome })
+		l.recordFinished(e)
+	}()
+}
+
+func (l *Launcher) recordFinished(e *entry) {
+	if l.record != nil {
+		l.record(l.snapshot(e))
+	}
+}
+
+func (l *Launcher) update(e *entry, fn func(s *JobState)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn(&e.state)
+	e.state.UpdatedAt = time.Now()
+	l.publishLocked(e)
+}
+
+func (l *Launcher) snapshot(e *entry) JobState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return e.state
+}
+
+func (l *Launcher) publishLocked(e *entry) {
+	for _, ch := range l.subscribers {
+		select {
+		case ch <- e.state:
+		default:
+		}
+	}
+}
+
+// trimLocked drops the oldest finished jobs beyond keepFinished
+func (l *Launcher) trimLocked() {
+	finished := 0
+	for _, e := range l.jobs {
+		if e.state.Status.Done() {
+			finished++
+		}
+	}
+
+	kept := l.jobs[:0]
+	for _, e := range l.jobs {
+		if finished > keepFinished && e.state.Status.Done() {
+			finished--
+			continue
+		}
+		kept = append(kept, e)
+	}
+	l.jobs = kept
+}
+
+// planLaunch checks the account's cookie and gets the ticketed protocol
+// string for a request
+func planLaunch(req Request) (*launchPlan, error) {
+	cookieValue, err := cookie_manager.PreLaunchCookieCheck(req.Account)
+	if err != nil {
+		return nil, fmt.Errorf("cookie issue for %s: %w", req.Account.Username, err)
+	}
+
+	if req.FollowUserID > 0 {
+		result, protocolString, err := preset_manager.PlanFollowLaunch(req.FollowUserID, req.Preset.PlaceID, cookieValue)
+		if err != nil {
+			return nil, err
+		}
+		return &launchPlan{ProtocolString: protocolString, PlaceID: result.PlaceID, JobID: result.JobID, Follow: result}, nil
+	}
+
+	authTicket, err := cookie_manager.GetAuthTicket(cookieValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth ticket for %s: %w", req.Account.Username, err)
+	}
+	return &launchPlan{
+		ProtocolString: preset_manager.PresetLaunchURI(req.Preset, authTicket),
+		PlaceID:        req.Preset.PlaceID,
+		JobID:          req.Preset.JobID,
+	}, nil
+}
+
+func preparePlayer(onCopy func()) (starter, error) {
+	player, err := preset_manager.PreparePlayer(onCopy)
+	if err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+func trackInstance(state JobState) {
+	if state.PID > 0 {
+		instance_account_tracker.TrackLaunch(state.PID, state.Request.Account.ID, state.PlaceID, state.JobID)
+	}
+}
+
+// verifyLaunch confirms a started instance joined its game, using the
+// account's presence when its cookie is available
+func verifyLaunch(state JobState) *launch_verifier.Outcome {
+	target := launch_verifier.Target{
+		PID:        state.PID,
+		UserID:     state.Request.Account.UserID,
+		PlaceID:    state.PlaceID,
+		LaunchedAt: state.StartedAt,
+	}
+	if cookie, err := cookie_manager.GetCookieForAccount(state.Request.Account.ID); err == nil {
+		target.Cookie = cookie.Value
+	}
+	outcome := launch_verifier.Verify(target, launch_verifier.Options{})
+	if outcome.LogPath != "" {
+		instance_account_tracker.SetLogPath(state.PID, outcome.LogPath)
+	}
+	return outcome
+}
+
+// Config is the saved launcher limits
+type Config struct {
+	MaxConcurrent int     `json:"max_concurrent"`
+	MinGapSeconds float64 `json:"min_gap_seconds"`
+}
+
+// configDoc holds the saved launcher limits; nil until they're first saved
+var configDoc = config_store.NewDocument[*Config]("launcher.json")
+
+// LoadConfig returns the saved limits, or the defaults
+func LoadConfig() Config {
+	if config, err := configDoc.Load(); err == nil && config != nil {
+		return *config
+	}
+	return Config{MaxConcurrent: DefaultMaxConcurrent, MinGapSeconds: DefaultMinGap.Seconds()}
+}
+
+// SaveConfig saves limits and applies them to the default launcher
+func SaveConfig(config Config) error {
+	if err := configDoc.Save(&config); err != nil {
+		return err
+	}
+
+	Default().SetLimits(config.MaxConcurrent, config.minGap())
+	return nil
+}
+
+func (c Config) minGap() time.Duration {
+	return time.Duration(c.MinGapSeconds * float64(time.Second))
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultLauncher *Launcher
+)
+
+// Default returns the process-wide launcher, using the saved limits
+func Default() *Launcher {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultLauncher == nil {
+		config := LoadConfig()
+		defaultLauncher = New(config.MaxConcurrent, config.minGap())
+	}
+	return defaultLauncher
+}
+
+// Submit queues a launch on the default launcher
+func Submit(req Request) *Job {
+	return Default().Submit(req)
+}
+
+// Jobs returns the default launcher's jobs
+func Jobs() []JobState {
+	return Default().Jobs()
+}
+
+// Subscribe subscribes to the default launcher's job updates
+func Subscribe() (<-chan JobState, func()) {
+	return Default().Subscribe()
+}
@@ -0,0 +1,207 @@
+package launcher
+
+import (
+	"errors"
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/preset_manager"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeStarter struct {
+	start   func() (int, error)
+	release func()
+}
+
+func (f fakeStarter) Start(args ...string) (int, error) {
+	return f.start()
+}
+
+func (f fakeStarter) Release() {
+	if f.release != nil {
+		f.release()
+	}
+}
+
+// newTestLauncher returns a launcher whose starts block until release is
+// closed and are recorded in order
+func newTestLauncher(maxConcurrent int, minGap time.Duration, release chan struct{}) (*Launcher, *[]string, *[]time.Time, *int) {
+	l := New(maxConcurrent, minGap)
+	l.verify = nil
+	l.record = nil
+
+	var mu sync.Mutex
+	var order []string
+	var starts []time.Time
+	active, peak := 0, 0
+
+	l.plan = func(req Request) (*launchPlan, error) {
+		if req.Account.Username == "broken" {
+			return nil, errors.New("no cookie")
+		}
+		return &launchPlan{ProtocolString: "roblox-player:1", PlaceID: req.Preset.PlaceID}, nil
+	}
+	l.prepare = func(onCopy func()) (starter, error) {
+		onCopy()
+		return fakeStarter{start: func() (int, error) {
+			mu.Lock()
+			active++
+			peak = max(peak, active)
+			starts = append(starts, time.Now())
+			pid := 1000 + len(starts)
+			mu.Unlock()
+
+			<-release
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+			return pid, nil
+		}}, nil
+	}
+	l.track = func(state JobState) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, state.Request.Account.Username)
+	}
+	return l, &order, &starts, &peak
+}
+
+func request(username string) Request {
+	return Request{
+		Account: account_manager.Account{Username: username},
+		Preset:  preset_manager.Preset{Name: "Test", PlaceID: 1818},
+	}
+}
+
+func TestLauncherLimitsConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	l, _, _, peak := newTestLauncher(2, 0, release)
+
+	var jobs []*Job
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		jobs = append(jobs, l.Submit(request(name)))
+	}
+
+	// Let the first launches reach their start and check the rest wait
+	time.Sleep(50 * time.Millisecond)
+	queued := 0
+	for _, s := range l.Jobs() {
+		if s.Status == StatusQueued {
+			queued++
+		}
+	}
+	if queued != 3 {
+		t.Fatalf("%d jobs queued, want 3", queued)
+	}
+
+	close(release)
+	for _, job := range jobs {
+		if state := job.Wait(); state.Status != StatusRunning || state.PID == 0 {
+			t.Fatalf("job %d ended as %+v", job.ID(), state)
+		}
+	}
+	if *peak != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", *peak)
+	}
+}
+
+func TestLauncherSpacesStartsInOrder(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	gap := 40 * time.Millisecond
+	l, order, starts, _ := newTestLauncher(4, gap, release)
+
+	updates, unsubscribe := l.Subscribe()
+	defer unsubscribe()
+
+	var jobs []*Job
+	for _, name := range []string{"a", "b", "broken", "c"} {
+		jobs = append(jobs, l.Submit(request(name)))
+	}
+	for _, job := range jobs {
+		job.Wait()
+	}
+
+	if got := *order; len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Fatalf("launch order = %v", got)
+	}
+	for i := 1; i < len(*starts); i++ {
+		// "broken" fails before starting but still takes a slot in the spacing
+		if d := (*starts)[i].Sub((*starts)[i-1]); d < gap {
+			t.Fatalf("starts %d and %d only %v apart", i-1, i, d)
+		}
+	}
+
+	failed := jobs[2].Wait()
+	if failed.Status != StatusFailed || failed.Err == nil {
+		t.Fatalf("broken job = %+v", failed)
+	}
+
+	// Every job reports its steps in order
+	seen := map[int][]Status{}
+	for len(updates) > 0 {
+		u := <-updates
+		seen[u.ID] = append(seen[u.ID], u.Status)
+	}
+	want := []Status{StatusQueued, StatusCloning, StatusFetchingTicket, StatusStarting, StatusRunning}
+	got := seen[jobs[0].ID()]
+	if len(got) != len(want) {
+		t.Fatalf("updates for job 1 = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("updates for job 1 = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLauncherFetchesTicketAfterPreparing(t *testing.T) {
+	l := New(1, 0)
+	l.verify = nil
+	l.record = nil
+	l.track = func(JobState) {}
+
+	var steps []string
+	l.prepare = func(onCopy func()) (starter, error) {
+		steps = append(steps, "prepare")
+		return fakeStarter{
+			start: func() (int, error) {
+				steps = append(steps, "start")
+				return 1001, nil
+			},
+			release: func() { steps = append(steps, "release") },
+		}, nil
+	}
+	l.plan = func(req Request) (*launchPlan, error) {
+		steps = append(steps, "ticket")
+		if req.Account.Username == "broken" {
+			return nil, errors.New("no cookie")
+		}
+		return &launchPlan{ProtocolString: "roblox-player:1"}, nil
+	}
+
+	updates, unsubscribe := l.Subscribe()
+	defer unsubscribe()
+
+	l.Submit(request("a")).Wait()
+	l.Submit(request("broken")).Wait()
+
+	want := []string{"prepare", "ticket", "start", "prepare", "ticket", "release"}
+	if len(steps) != len(want) {
+		t.Fatalf("steps = %v, want %v", steps, want)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Fatalf("steps = %v, want %v", steps, want)
+		}
+	}
+
+	// Nothing was copied, so no job reports cloning
+	for len(updates) > 0 {
+		if u := <-updates; u.Status == StatusCloning {
+			t.Fatalf("job %d reported cloning without a copy", u.ID)
+		}
+	}
+}
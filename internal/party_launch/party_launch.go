@@ -0,0 +1,257 @@
+package party_launch
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/cookie_manager"
+	"insadem/multi_roblox_macos/internal/instance_account_tracker"
+	"insadem/multi_roblox_macos/internal/launcher"
+	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/preset_manager"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"time"
+)
+
+// Stage is where an account is in the party launch
+type Stage string
+
+const (
+	StageLaunching  Stage = "launching"
+	StageWaitingJob Stage = "finding server"
+	StageWaiting    Stage = "waiting"
+	StageLaunched   Stage = "launched"
+	StageFailed     Stage = "failed"
+)
+
+// Progress is reported for every stage change of every account
+type Progress struct {
+	Index   int // Position of the account in the party, from 0
+	Total   int
+	Account account_manager.Account
+	Stage   Stage
+	JobID   string // The party's server, once known
+	Err     error  // Set for StageFailed
+}
+
+// Outcome is the end result for one account
+type Outcome struct {
+	Account account_manager.Account
+	PID     int
+	Err     error
+}
+
+// Result summarizes a party launch
+type Result struct {
+	JobID    string // Server the party was sent to; empty if it was never found
+	Host     *account_manager.Account
+	Outcomes []Outcome
+}
+
+// Failed returns the outcomes that failed
+func (r *Result) Failed() []Outcome {
+	var failed []Outcome
+	for _, o := range r.Outcomes {
+		if o.Err != nil {
+			failed = append(failed, o)
+		}
+	}
+	return failed
+}
+
+// Options tunes a party launch. Zero values use the defaults.
+type Options struct {
+	Stagger      time.Duration // Delay between launches (default 8s)
+	JobTimeout   time.Duration // How long to wait for the host's server (default 90s)
+	PollInterval time.Duration // Presence polling interval (default 3s)
+
+	// Launch starts a preset as an account and returns its PID. Defaults to
+	// an auth ticket launch from the account's saved cookie.
+	Launch func(preset preset_manager.Preset, account account_manager.Account) (int, error)
+	// Follow launches an account into whatever server userID is in. Used when
+	// the host's server couldn't be found.
+	Follow func(account account_manager.Account, userID, placeID int64) (int, error)
+	// Sleep waits between steps; replaced in tests
+	Sleep func(time.Duration)
+}
+
+func (o *Options) setDefaults() {
+	if o.Stagger == 0 {
+		o.Stagger = 8 * time.Second
+	}
+	if o.JobTimeout == 0 {
+		o.JobTimeout = 90 * time.Second
+	}
+	if o.PollInterval == 0 {
+		o.PollInterval = 3 * time.Second
+	}
+	if o.Launch == nil {
+		o.Launch = launchWithCookie
+	}
+	if o.Follow == nil {
+		o.Follow = followWithCookie
+	}
+	if o.Sleep == nil {
+		o.Sleep = time.Sleep
+	}
+}
+
+// Launch puts accounts into the same server of a preset. The first account
+// that launches is the host; once presence shows its server, the rest are
+// launched into that job ID one by one, following the host if the server
+// can't be found or joined. Private servers can't be joined by job ID, so
+// for those every account launches with the link code instead. An account
+// that fails is reported and skipped. progress may be nil.
+func Launch(preset preset_manager.Preset, accounts []account_manager.Account, opts Options, progress func(Progress)) (*Result, error) {
+	if preset.PlaceID <= 0 {
+		return nil, fmt.Errorf("preset %s has no place ID", preset.Name)
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no accounts selected")
+	}
+	opts.setDefaults()
+	if progress == nil {
+		progress = func(Progress) {}
+	}
+
+	logger.LogInfo("Party launch of %s with %d accounts", preset.Name, len(accounts))
+
+	result := &Result{Outcomes: make([]Outcome, len(accounts))}
+	report := func(i int, stage Stage, err error) {
+		progress(Progress{Index: i, Total: len(accounts), Account: accounts[i], Stage: stage, JobID: result.JobID, Err: err})
+	}
+	fail := func(i int, err error) {
+		logger.LogError("Party launch: %s failed: %v", accounts[i].Username, err)
+		result.Outcomes[i].Err = err
+		report(i, StageFailed, err)
+	}
+	for i, acc := range accounts {
+		result.Outcomes[i].Account = acc
+		report(i, StageWaiting, nil)
+	}
+
+	// The host launches the preset as is, so a pinned server or private
+	// server is respected
+	next := 0
+	for ; next < len(accounts) && result.Host == nil; next++ {
+		report(next, StageLaunching, nil)
+		pid, err := opts.Launch(preset, accounts[next])
+		if err != nil {
+			fail(next, err)
+			continue
+		}
+		result.Outcomes[next].PID = pid
+		result.Host = &accounts[next]
+		report(next, StageLaunched, nil)
+	}
+	if result.Host == nil {
+		return result, fmt.Errorf("no account could be launched")
+	}
+	if next == len(accounts) {
+		return result, nil
+	}
+
+	private := preset.PrivateServerLinkCode != ""
+	if private {
+		logger.LogInfo("Party launch: %s is a private server, launching everyone with its link code", preset.Name)
+	} else {
+		// Find the host's server
+		report(next-1, StageWaitingJob, nil)
+		jobID, err := waitForJobID(*result.Host, preset.PlaceID, opts)
+		if err != nil {
+			logger.LogError("Party launch: couldn't find %s's server, following instead: %v", result.Host.Username, err)
+		} else {
+			result.JobID = jobID
+			logger.LogInfo("Party launch: host %s is in server %s", result.Host.Username, jobID)
+			if pid := result.Outcomes[next-1].PID; pid > 0 {
+				instance_account_tracker.TrackLaunch(pid, result.Host.ID, preset.PlaceID, jobID)
+			}
+		}
+		report(next-1, StageLaunched, nil)
+	}
+
+	// Everyone else joins the host's server
+	memberPreset := preset
+	if !private {
+		memberPreset.JobID = result.JobID
+	}
+	for i := next; i < len(accounts); i++ {
+		opts.Sleep(opts.Stagger)
+		report(i, StageLaunching, nil)
+
+		var pid int
+		var err error
+		switch {
+		case private:
+			pid, err = opts.Launch(memberPreset, accounts[i])
+		case result.JobID != "":
+			pid, err = opts.Launch(memberPreset, accounts[i])
+			if err != nil {
+				// The server may be full; following the host can still get in
+				logger.LogError("Party launch: %s couldn't join server %s, following %s instead: %v", accounts[i].Username, result.JobID, result.Host.Username, err)
+				joinErr := err
+				if pid, err = opts.Follow(accounts[i], result.Host.UserID, preset.PlaceID); err != nil {
+					err = fmt.Errorf("joining server: %v; following host: %w", joinErr, err)
+				}
+			}
+		default:
+			pid, err = opts.Follow(accounts[i], result.Host.UserID, preset.PlaceID)
+		}
+		if err != nil {
+			fail(i, err)
+			continue
+		}
+		result.Outcomes[i].PID = pid
+		report(i, StageLaunched, nil)
+	}
+
+	logger.LogInfo("Party launch of %s done: %d of %d failed", preset.Name, len(result.Failed()), len(accounts))
+	return result, nil
+}
+
+// waitForJobID polls the host's presence until it shows a server in the place
+func waitForJobID(host account_manager.Account, placeID int64, opts Options) (string, error) {
+	if host.UserID == 0 {
+		return "", fmt.Errorf("%s has no user ID", host.Username)
+	}
+	cookie, err := cookie_manager.GetCookieForAccount(host.ID)
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	for waited := time.Duration(0); waited < opts.JobTimeout; waited += opts.PollInterval {
+		presences, err := roblox_api.GetUserPresence([]int64{host.UserID}, cookie.Value)
+		if err != nil {
+			lastErr = err
+		} else if len(presences) > 0 {
+			p := presences[0]
+			if p.UserPresenceType == 2 && p.GameID != "" && (p.PlaceID == placeID || p.RootPlaceID == placeID) {
+				return p.GameID, nil
+			}
+		}
+		opts.Sleep(opts.PollInterval)
+	}
+
+	if lastErr != nil {
+		return "", fmt.Errorf("timed out waiting for server: %w", lastErr)
+	}
+	return "", fmt.Errorf("timed out waiting for %s to join a server", host.Username)
+}
+
+// launchWithCookie launches a preset from an account's saved cookie through
+// the launch queue
+func launchWithCookie(preset preset_manager.Preset, account account_manager.Account) (int, error) {
+	state := launcher.Submit(launcher.Request{Account: account, Preset: preset}).Wait()
+	return state.PID, state.Err
+}
+
+// followWithCookie follows a user from an account's saved cookie through
+// the launch queue
+func followWithCookie(account account_manager.Account, userID, placeID int64) (int, error) {
+	state := launcher.Submit(launcher.Request{
+		Account:      account,
+		Preset:       preset_manager.Preset{PlaceID: placeID},
+		FollowUserID: userID,
+	}).Wait()
+	return state.PID, state.Err
+}
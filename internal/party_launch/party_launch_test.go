@@ -0,0 +1,195 @@
+package party_launch
+
+import (
+	"errors"
+	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/cookie_manager"
+	"insadem/multi_roblox_macos/internal/fake_roblox"
+	"insadem/multi_roblox_macos/internal/preset_manager"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"insadem/multi_roblox_macos/internal/secret_store"
+	"testing"
+	"time"
+)
+
+func TestPartyLaunch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 1, Name: "Host", Cookie: "host-cookie"})
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+
+	if err := cookie_manager.SaveCookieForAccount("host", &cookie_manager.RobloxCookie{Value: "host-cookie"}); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := []account_manager.Account{
+		{ID: "broken", Username: "Broken"},
+		{ID: "host", Username: "Host", UserID: 1},
+		{ID: "alt1", Username: "Alt1", UserID: 2},
+		{ID: "alt2", Username: "Alt2", UserID: 3},
+	}
+	preset := preset_manager.Preset{Name: "Farm", PlaceID: 1818}
+
+	var launched []preset_manager.Preset
+	var followed []string
+	polls := 0
+	opts := Options{
+		Launch: func(p preset_manager.Preset, acc account_manager.Account) (int, error) {
+			if acc.ID == "broken" || acc.ID == "alt1" {
+				return 0, errors.New("no cookie")
+			}
+			launched = append(launched, p)
+			return 100 + len(launched), nil
+		},
+		// Only tried for a member that couldn't join the server
+		Follow: func(acc account_manager.Account, userID, _ int64) (int, error) {
+			followed = append(followed, acc.ID)
+			return 0, errors.New("no cookie")
+		},
+		// The host shows up in a server on the third poll
+		Sleep: func(time.Duration) {
+			if polls++; polls == 2 {
+				server.SetPresence(1, fake_roblox.Presence{UserPresenceType: 2, PlaceID: 1818, GameID: "job-party"})
+			}
+		},
+	}
+
+	var stages []Stage
+	result, err := Launch(preset, accounts, opts, func(p Progress) { stages = append(stages, p.Stage) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Host == nil || result.Host.ID != "host" || result.JobID != "job-party" {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(launched) != 2 || launched[0].JobID != "" || launched[1].JobID != "job-party" {
+		t.Fatalf("launches = %+v", launched)
+	}
+	if len(followed) != 1 || followed[0] != "alt1" {
+		t.Fatalf("followed = %v, want only alt1", followed)
+	}
+	if failed := result.Failed(); len(failed) != 2 || failed[0].Account.ID != "broken" || failed[1].Account.ID != "alt1" {
+		t.Fatalf("failed = %+v", failed)
+	}
+	if result.Outcomes[3].PID != 102 {
+		t.Fatalf("outcomes = %+v", result.Outcomes)
+	}
+	if len(stages) == 0 || stages[len(stages)-1] != StageLaunched {
+		t.Fatalf("stages = %v", stages)
+	}
+}
+
+func TestPartyLaunchFollowsWhenServerUnknown(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 1, Name: "Host", Cookie: "host-cookie"})
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+	cookie_manager.SaveCookieForAccount("host", &cookie_manager.RobloxCookie{Value: "host-cookie"})
+
+	accounts := []account_manager.Account{
+		{ID: "host", Username: "Host", UserID: 1},
+		{ID: "alt", Username: "Alt", UserID: 2},
+	}
+
+	var followed int64
+	opts := Options{
+		JobTimeout:   time.Second,
+		PollInterval: 100 * time.Millisecond,
+		Launch: func(preset_manager.Preset, account_manager.Account) (int, error) {
+			return 0, nil
+		},
+		Follow: func(_ account_manager.Account, userID, _ int64) (int, error) {
+			followed = userID
+			return 0, nil
+		},
+		Sleep: func(time.Duration) {},
+	}
+
+	result, err := Launch(preset_manager.Preset{Name: "Farm", PlaceID: 1818}, accounts, opts, nil)
+	if err != nil || result.JobID != "" || followed != 1 || len(result.Failed()) != 0 {
+		t.Fatalf("Launch = %+v, %v (followed %d)", result, err, followed)
+	}
+}
+
+func TestPartyLaunchFollowsWhenServerFull(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	secret_store.SetDefault(secret_store.NewMemoryStore())
+
+	server := fake_roblox.NewServer()
+	defer server.Close()
+	server.AddUser(fake_roblox.User{ID: 1, Name: "Host", Cookie: "host-cookie"})
+	server.SetPresence(1, fake_roblox.Presence{UserPresenceType: 2, PlaceID: 1818, GameID: "job-full"})
+	previous := roblox_api.SetDefaultClient(roblox_api.NewClientWithBaseURL(server.URL))
+	defer roblox_api.SetDefaultClient(previous)
+	cookie_manager.SaveCookieForAccount("host", &cookie_manager.RobloxCookie{Value: "host-cookie"})
+
+	accounts := []account_manager.Account{
+		{ID: "host", Username: "Host", UserID: 1},
+		{ID: "alt", Username: "Alt", UserID: 2},
+	}
+
+	opts := Options{
+		Launch: func(p preset_manager.Preset, _ account_manager.Account) (int, error) {
+			if p.JobID != "" {
+				return 0, errors.New("server full")
+			}
+			return 101, nil
+		},
+		Follow: func(_ account_manager.Account, userID, _ int64) (int, error) {
+			return 200 + int(userID), nil
+		},
+		Sleep: func(time.Duration) {},
+	}
+
+	result, err := Launch(preset_manager.Preset{Name: "Farm", PlaceID: 1818}, accounts, opts, nil)
+	if err != nil || result.JobID != "job-full" || len(result.Failed()) != 0 {
+		t.Fatalf("Launch = %+v, %v", result, err)
+	}
+	if result.Outcomes[1].PID != 201 {
+		t.Fatalf("alt outcome = %+v, want a follow of the host", result.Outcomes[1])
+	}
+}
+
+func TestPartyLaunchPrivateServer(t *testing.T) {
+	accounts := []account_manager.Account{
+		{ID: "host", Username: "Host", UserID: 1},
+		{ID: "alt", Username: "Alt", UserID: 2},
+	}
+
+	var launched []preset_manager.Preset
+	opts := Options{
+		Launch: func(p preset_manager.Preset, _ account_manager.Account) (int, error) {
+			launched = append(launched, p)
+			return 100 + len(launched), nil
+		},
+		Follow: func(account_manager.Account, int64, int64) (int, error) {
+			t.Fatal("Follow used for a private server")
+			return 0, nil
+		},
+		Sleep: func(time.Duration) {},
+	}
+
+	// No presence lookups are needed, so there is no server or cookie
+	preset := preset_manager.Preset{Name: "VIP", PlaceID: 1818, PrivateServerLinkCode: "link-code"}
+	result, err := Launch(preset, accounts, opts, nil)
+	if err != nil || len(result.Failed()) != 0 || result.JobID != "" {
+		t.Fatalf("Launch = %+v, %v", result, err)
+	}
+	if len(launched) != 2 {
+		t.Fatalf("launches = %+v", launched)
+	}
+	for _, p := range launched {
+		if p.PrivateServerLinkCode != "link-code" || p.JobID != "" {
+			t.Fatalf("launches = %+v, want every account on the link code", launched)
+		}
+	}
+}
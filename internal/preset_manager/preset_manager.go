@@ -1,22 +1,22 @@
 package preset_manager
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"insadem/multi_roblox_macos/internal/clone_pool"
+	"insadem/multi_roblox_macos/internal/launch_uri"
 	"insadem/multi_roblox_macos/internal/logger"
 	"insadem/multi_roblox_macos/internal/roblox_api"
-	"os"
+	"insadem/multi_roblox_macos/internal/roblox_install"
+	"insadem/multi_roblox_macos/internal/state_db"
+	neturl "net/url"
 	"os/exec"
-	"path/filepath"
+	"regexp"
 	"strings"
-	"time"
 )
 
-// timeNowMillis returns current time in milliseconds
-func timeNowMillis() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
-}
-
 // Preset represents a saved Roblox game shortcut
 type Preset struct {
 	Name                  string `json:"name"`
@@ -25,74 +25,115 @@ type Preset struct {
 	ThumbnailURL          string `json:"thumbnail_url,omitempty"`
 	LastAccountUsed       string `json:"last_account_used,omitempty"`
 	PrivateServerLinkCode string `json:"private_server_link_code,omitempty"`
+	JobID                 string `json:"job_id,omitempty"` // Pinned public server (game instance ID)
 }
 
-// Config stores all presets
+// Config is the format of the presets JSON file
 type Config struct {
 	Presets []Preset `json:"presets"`
 }
 
-// GetConfigPath returns the path to the presets config file
-func GetConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	configDir := filepath.Join(homeDir, "Library", "Application Support", "multi_roblox_macos")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return "", err
-	}
-	return filepath.Join(configDir, "presets.json"), nil
+// presetsFile is the JSON file presets were kept in before the state database
+const presetsFile = "presets.json"
+
+func init() {
+	state_db.RegisterJSONSource(state_db.JSONSource{
+		File: presetsFile,
+		Import: func(tx *sql.Tx, data []byte) error {
+			var config Config
+			if err := json.Unmarshal(data, &config); err != nil {
+				return err
+			}
+			return replacePresets(tx, config.Presets)
+		},
+		Export: func(db *sql.DB) ([]byte, error) {
+			presets, err := queryPresets(db)
+			if err != nil {
+				return nil, err
+			}
+			return json.MarshalIndent(Config{Presets: presets}, "", "  ")
+		},
+	})
 }
 
-// LoadPresets loads presets from config file
+const presetColumns = "name, url, place_id, thumbnail_url, last_account_used, private_server_link_code, job_id"
+
+// LoadPresets loads all presets in the order they were added
 func LoadPresets() ([]Preset, error) {
-	configPath, err := GetConfigPath()
+	db, err := state_db.Default()
 	if err != nil {
 		return nil, err
 	}
+	return queryPresets(db)
+}
 
-	// If file doesn't exist, return empty list
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return []Preset{}, nil
-	}
-
-	data, err := os.ReadFile(configPath)
+func queryPresets(db *sql.DB) ([]Preset, error) {
+	rows, err := db.Query("SELECT " + presetColumns + " FROM presets ORDER BY seq")
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+	presets := []Preset{}
+	for rows.Next() {
+		var p Preset
+		if err := rows.Scan(&p.Name, &p.URL, &p.PlaceID, &p.ThumbnailURL, &p.LastAccountUsed, &p.PrivateServerLinkCode, &p.JobID); err != nil {
+			return nil, err
+		}
+		presets = append(presets, p)
 	}
-
-	return config.Presets, nil
+	return presets, rows.Err()
 }
 
-// SavePresets saves presets to config file
+// SavePresets replaces all presets
 func SavePresets(presets []Preset) error {
-	configPath, err := GetConfigPath()
-	if err != nil {
-		return err
-	}
+	return state_db.Update(func(tx *sql.Tx) error {
+		return replacePresets(tx, presets)
+	})
+}
 
-	config := Config{Presets: presets}
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
+func replacePresets(tx *sql.Tx, presets []Preset) error {
+	if _, err := tx.Exec("DELETE FROM presets"); err != nil {
 		return err
 	}
+	for _, p := range presets {
+		if err := insertPreset(tx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return os.WriteFile(configPath, data, 0600) // Secure permissions - owner only
+func insertPreset(tx *sql.Tx, p Preset) error {
+	_, err := tx.Exec("INSERT INTO presets ("+presetColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		p.Name, p.URL, p.PlaceID, p.ThumbnailURL, p.LastAccountUsed, p.PrivateServerLinkCode, p.JobID)
+	return err
 }
 
-// AddPreset adds a new preset with auto-fetched game info
-func AddPreset(name, url string) error {
-	presets, err := LoadPresets()
-	if err != nil {
+// updatePreset sets one column of the preset at index
+func updatePreset(index int, column string, value any) error {
+	return state_db.Update(func(tx *sql.Tx) error {
+		seq, err := presetSeq(tx, index)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE presets SET "+column+" = ? WHERE seq = ?", value, seq)
 		return err
+	})
+}
+
+// presetSeq returns the row of the preset at index
+func presetSeq(tx *sql.Tx, index int) (int64, error) {
+	var seq int64
+	err := tx.QueryRow("SELECT seq FROM presets ORDER BY seq LIMIT 1 OFFSET ?", index).Scan(&seq)
+	if index < 0 || errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("invalid preset index")
 	}
+	return seq, err
+}
 
+// AddPreset adds a new preset with auto-fetched game info
+func AddPreset(name, url string) error {
 	preset := Preset{Name: name, URL: url}
 
 	// Try to extract private server link code if present
@@ -101,6 +142,12 @@ func AddPreset(name, url string) error {
 		logger.LogInfo("Detected private server link code in URL")
 	}
 
+	// Pin the server if the link points at one
+	if jobID := ExtractJobID(url); jobID != "" {
+		preset.JobID = jobID
+		logger.LogInfo("Detected server (job ID) in URL: %s", jobID)
+	}
+
 	// Try to auto-fetch game info
 	if placeID, err := roblox_api.ExtractPlaceID(url); err == nil {
 		preset.PlaceID = placeID
@@ -115,8 +162,9 @@ func AddPreset(name, url string) error {
 		}
 	}
 
-	presets = append(presets, preset)
-	return SavePresets(presets)
+	return state_db.Update(func(tx *sql.Tx) error {
+		return insertPreset(tx, preset)
+	})
 }
 
 // ExtractPrivateServerLinkCode extracts the link code from a private server URL
@@ -183,50 +231,84 @@ func ExtractPrivateServerLinkCode(input string) string {
 	return ""
 }
 
+// jobIDPattern matches a server instance ID (a GUID)
+var jobIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ExtractJobID extracts a server instance ID from a server link
+// Supports:
+// - https://www.roblox.com/games/start?placeId=123&gameInstanceId=XXXXX
+// - roblox://experiences/start?placeId=123&gameInstanceId=XXXXX
+// - Direct job ID paste: XXXXX
+func ExtractJobID(input string) string {
+	input = strings.TrimSpace(input)
+	if jobIDPattern.MatchString(input) {
+		return input
+	}
+
+	if strings.HasPrefix(input, "roblox://") || strings.HasPrefix(input, "roblox-player:") {
+		if request, err := launch_uri.Parse(input); err == nil && jobIDPattern.MatchString(request.JobID) {
+			return request.JobID
+		}
+		return ""
+	}
+
+	u, err := neturl.Parse(input)
+	if err != nil {
+		return ""
+	}
+	// Anything but a GUID would be passed on to the launch as is
+	query := u.Query()
+	for _, key := range []string{"gameInstanceId", "gameId"} {
+		if jobID := query.Get(key); jobIDPattern.MatchString(jobID) {
+			return jobID
+		}
+	}
+	return ""
+}
+
 // UpdatePresetPrivateServer updates the private server link code for a preset
 func UpdatePresetPrivateServer(index int, linkCode string) error {
-	presets, err := LoadPresets()
-	if err != nil {
+	if err := updatePreset(index, "private_server_link_code", linkCode); err != nil {
 		return err
 	}
+	logger.LogInfo("Updated preset %d private server link code", index)
+	return nil
+}
 
-	if index < 0 || index >= len(presets) {
-		return fmt.Errorf("invalid preset index")
+// UpdatePresetJobID pins a preset to a server, or unpins it if jobID is empty
+func UpdatePresetJobID(index int, jobID string) error {
+	if err := updatePreset(index, "job_id", jobID); err != nil {
+		return err
 	}
-
-	presets[index].PrivateServerLinkCode = linkCode
-	logger.LogInfo("Updated preset %d private server link code", index)
-	return SavePresets(presets)
+	logger.LogInfo("Updated preset %d job ID: %q", index, jobID)
+	return nil
 }
 
 // UpdatePresetLastAccount updates the last used account for a preset
 func UpdatePresetLastAccount(index int, accountID string) error {
-	presets, err := LoadPresets()
+	return updatePreset(index, "last_account_used", accountID)
+}
+
+// RekeyAccount updates presets that last used an old account ID
+func RekeyAccount(oldID, newID string) error {
+	db, err := state_db.Default()
 	if err != nil {
 		return err
 	}
-
-	if index < 0 || index >= len(presets) {
-		return fmt.Errorf("invalid preset index")
-	}
-
-	presets[index].LastAccountUsed = accountID
-	return SavePresets(presets)
+	_, err = db.Exec("UPDATE presets SET last_account_used = ? WHERE last_account_used = ?", newID, oldID)
+	return err
 }
 
 // DeletePreset removes a preset by index
 func DeletePreset(index int) error {
-	presets, err := LoadPresets()
-	if err != nil {
+	return state_db.Update(func(tx *sql.Tx) error {
+		seq, err := presetSeq(tx, index)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("DELETE FROM presets WHERE seq = ?", seq)
 		return err
-	}
-
-	if index < 0 || index >= len(presets) {
-		return fmt.Errorf("invalid preset index")
-	}
-
-	presets = append(presets[:index], presets[index+1:]...)
-	return SavePresets(presets)
+	})
 }
 
 // LaunchPreset launches Roblox with the URL from a preset
@@ -242,38 +324,6 @@ func isRobloxRunning() bool {
 	return err == nil
 }
 
-// getNextRobloxCopyPath returns the next available path for a Roblox copy
-func getNextRobloxCopyPath() string {
-	for i := 2; i <= 10; i++ {
-		path := fmt.Sprintf("/tmp/Roblox%d.app", i)
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			return path
-		}
-	}
-	// Cleanup old ones and use Roblox2.app
-	for i := 2; i <= 10; i++ {
-		path := fmt.Sprintf("/tmp/Roblox%d.app", i)
-		os.RemoveAll(path)
-	}
-	return "/tmp/Roblox2.app"
-}
-
-// copyRobloxApp copies Roblox.app to a temporary location for multi-instance
-func copyRobloxApp(destPath string) error {
-	logger.LogInfo("Copying Roblox.app to %s for multi-instance...", destPath)
-
-	// Use cp -R to copy the entire bundle
-	cmd := exec.Command("cp", "-R", "/Applications/Roblox.app", destPath)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.LogError("Failed to copy Roblox.app: %v, output: %s", err, string(output))
-		return fmt.Errorf("failed to copy Roblox.app: %w", err)
-	}
-
-	logger.LogInfo("Successfully copied Roblox.app to %s", destPath)
-	return nil
-}
-
 // LaunchPresetWithTicket launches a preset with an optional authentication ticket
 // Returns the PID of the launched process (or 0 if unknown)
 func LaunchPresetWithTicket(preset Preset, authTicket string) (int, error) {
@@ -284,6 +334,13 @@ func LaunchPresetWithTicket(preset Preset, authTicket string) (int, error) {
 		logger.LogDebug("Using auth ticket (length: %d)", len(authTicket))
 	}
 
+	return launchProtocolString(PresetLaunchURI(preset, authTicket), authTicket != "")
+}
+
+// PresetLaunchURI returns the protocol string that launches a preset. With
+// an auth ticket it's a roblox-player: URI for the player binary; without
+// one it's a link for the installed URL handler.
+func PresetLaunchURI(preset Preset, authTicket string) string {
 	// Determine the place ID
 	var placeID int64
 	if preset.PlaceID > 0 {
@@ -296,29 +353,18 @@ func LaunchPresetWithTicket(preset Preset, authTicket string) (int, error) {
 
 	// Build the launch URL/protocol string
 	var protocolString string
-	if placeID > 0 && authTicket != "" {
-		// Use the proper roblox-player: format with auth ticket (how browsers do it!)
-		launchTime := fmt.Sprintf("%d", timeNowMillis())
-		browserTrackerId := fmt.Sprintf("%d", timeNowMillis()%1000000000)
-
-		// Check if launching to private server
-		if preset.PrivateServerLinkCode != "" {
-			linkCode := preset.PrivateServerLinkCode
+	if placeID > 0 {
+		request := launch_uri.LaunchRequest{
+			PlaceID:    placeID,
+			JobID:      preset.JobID,
+			LinkCode:   preset.PrivateServerLinkCode,
+			AuthTicket: authTicket,
+		}
+		if linkCode := request.LinkCode; linkCode != "" {
 			logger.LogInfo("Attempting private server launch with code: %s...", linkCode[:min(10, len(linkCode))])
-
-			// Use the share link resolution approach - PlaceLauncher with share code
-			// Try request=RequestPrivateGame with the share code as linkCode
-			protocolString = fmt.Sprintf("roblox-player:1+launchmode:play+gameinfo:%s+launchtime:%s+placelauncherurl:https://assetgame.roblox.com/game/PlaceLauncher.ashx?request=RequestPrivateGame&placeId=%d&linkCode=%s&browserTrackerId=%s+browsertrackerid:%s+robloxLocale:en_us+gameLocale:en_us+channel:",
-				authTicket, launchTime, placeID, linkCode, browserTrackerId, browserTrackerId)
-			logger.LogInfo("Using RequestPrivateGame with linkCode")
-		} else {
-			// Regular game launch
-			protocolString = fmt.Sprintf("roblox-player:1+launchmode:play+gameinfo:%s+launchtime:%s+placelauncherurl:https://assetgame.roblox.com/game/PlaceLauncher.ashx?request=RequestGame&browserTrackerId=%s&placeId=%d&isPlayTogetherGame=false+browsertrackerid:%s+robloxLocale:en_us+gameLocale:en_us+channel:",
-				authTicket, launchTime, browserTrackerId, placeID, browserTrackerId)
-			logger.LogDebug("Using roblox-player: with auth ticket")
 		}
-	} else if placeID > 0 {
-		protocolString = fmt.Sprintf("roblox://placeId=%d", placeID)
+		protocolString = request.URI()
+		logger.LogDebug("Using %s launch (auth ticket: %v)", request.RequestType(), authTicket != "")
 	} else if strings.HasPrefix(preset.URL, "roblox://") {
 		protocolString = preset.URL
 	} else {
@@ -331,31 +377,87 @@ func LaunchPresetWithTicket(preset Preset, authTicket string) (int, error) {
 	} else {
 		logger.LogDebug("Final protocol string: %s", protocolString)
 	}
+	return protocolString
+}
+
+// Player is a Roblox player binary picked for a new instance
+type Player struct {
+	Path  string
+	clone *clone_pool.Clone // Set when running from a clone
+}
+
+// PreparePlayer picks the binary for a new instance. If Roblox is already
+// running, a clone from the pool is used so the new instance doesn't just
+// hand off to the running one. onCopy, if not nil, is called before a new
+// clone is copied.
+func PreparePlayer(onCopy func()) (*Player, error) {
+	install, err := roblox_install.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	player := &Player{Path: install.PlayerPath()}
 
-	// Check if Roblox is already running - need to use copied app for multi-instance
-	robloxApp := "/Applications/Roblox.app/Contents/MacOS/RobloxPlayer"
-	if isRobloxRunning() && authTicket != "" {
-		// For multi-instance with auth ticket, we need to copy the app
-		copyPath := getNextRobloxCopyPath()
-		if err := copyRobloxApp(copyPath); err != nil {
-			// Fall back to regular launch
-			logger.LogError("Failed to copy Roblox for multi-instance, falling back to regular launch: %v", err)
+	if isRobloxRunning() {
+		clone, err := clone_pool.Acquire(onCopy)
+		if err != nil {
+			// Fall back to the main app
+			logger.LogError("Failed to get a Roblox clone for multi-instance, using the main app: %v", err)
 		} else {
-			robloxApp = filepath.Join(copyPath, "Contents", "MacOS", "RobloxPlayer")
-			logger.LogInfo("Using copied app for multi-instance: %s", robloxApp)
+			player.Path = clone.Executable()
+			player.clone = clone
+			logger.LogInfo("Using Roblox clone for multi-instance: %s", clone.Name)
+		}
+	}
+	return player, nil
+}
+
+// Start runs the player with args and returns its PID. The clone, if any,
+// is leased to the new process, or returned to the pool if it fails to start.
+func (p *Player) Start(args ...string) (int, error) {
+	logger.LogDebug("Starting %s", p.Path)
+	cmd := exec.Command(p.Path, args...)
+	if err := cmd.Start(); err != nil {
+		p.Release()
+		return 0, err
+	}
+
+	pid := cmd.Process.Pid
+	if p.clone != nil {
+		if err := clone_pool.Lease(p.clone, pid); err != nil {
+			logger.LogError("Failed to lease Roblox clone %s: %v", p.clone.Name, err)
 		}
 	}
+	return pid, nil
+}
+
+// Release returns the player's clone, if any, to the pool when it won't be
+// started
+func (p *Player) Release() {
+	if p.clone != nil {
+		clone_pool.Release(p.clone)
+	}
+}
 
+// startRobloxPlayer starts a new player instance with args and returns its PID
+func startRobloxPlayer(args ...string) (int, error) {
+	player, err := PreparePlayer(nil)
+	if err != nil {
+		return 0, err
+	}
+	return player.Start(args...)
+}
+
+// launchProtocolString starts Roblox with a launch URI. Ticketed launches
+// run the player binary directly (from a copy if Roblox is already running)
+// so the PID is known; others go through `open` and return PID 0.
+func launchProtocolString(protocolString string, hasTicket bool) (int, error) {
 	// Launch directly with -protocolString for auth ticket launches
-	if authTicket != "" {
-		logger.LogDebug("Launching with direct -protocolString: %s", robloxApp)
-		cmd := exec.Command(robloxApp, "-protocolString", protocolString)
-		err := cmd.Start() // Use Start() to not wait
+	if hasTicket {
+		pid, err := startRobloxPlayer("-protocolString", protocolString)
 		if err != nil {
 			logger.LogError("LaunchPreset failed: %v", err)
 			return 0, err
 		}
-		pid := cmd.Process.Pid
 		logger.LogInfo("LaunchPreset successful (direct launch), PID: %d", pid)
 		return pid, nil
 	}
@@ -376,38 +478,87 @@ func LaunchPresetWithTicket(preset Preset, authTicket string) (int, error) {
 	return 0, nil
 }
 
+// FollowLaunchResult reports how a follow-user launch went
+type FollowLaunchResult struct {
+	PID      int
+	Followed bool   // False if the follow was rejected and the place was launched instead
+	Reason   string // Why the follow was rejected
+	PlaceID  int64
+	JobID    string // The followed user's server, if gamejoin reported it
+}
+
+// LaunchFollowUser launches an account into whatever server followUserID is
+// in. If gamejoin won't allow the follow, it falls back to launching
+// fallbackPlaceID (if set) and says so in the result.
+func LaunchFollowUser(followUserID, fallbackPlaceID int64, cookie string) (*FollowLaunchResult, error) {
+	logger.LogInfo("LaunchFollowUser called for user ID %d", followUserID)
+
+	result, protocolString, err := PlanFollowLaunch(followUserID, fallbackPlaceID, cookie)
+	if err != nil {
+		return result, err
+	}
+	result.PID, err = launchProtocolString(protocolString, true)
+	return result, err
+}
+
+// PlanFollowLaunch checks whether followUserID can be followed and returns
+// the ticketed protocol string to launch, following LaunchFollowUser's
+// fallback rules. The result's PID is left unset.
+func PlanFollowLaunch(followUserID, fallbackPlaceID int64, cookie string) (*FollowLaunchResult, string, error) {
+	result := &FollowLaunchResult{PlaceID: fallbackPlaceID}
+	status, err := roblox_api.CheckFollowUser(followUserID, cookie)
+	switch {
+	case err != nil:
+		result.Reason = err.Error()
+	case !status.CanJoin():
+		result.Reason = status.Message
+		if result.Reason == "" {
+			result.Reason = fmt.Sprintf("join status %d", status.Status)
+		}
+	default:
+		result.Followed = true
+		if status.PlaceID > 0 {
+			result.PlaceID = status.PlaceID
+		}
+		result.JobID = status.JobID
+	}
+
+	if !result.Followed {
+		logger.LogInfo("Follow of user %d rejected: %s", followUserID, result.Reason)
+		if fallbackPlaceID <= 0 {
+			return result, "", fmt.Errorf("can't follow user: %s", result.Reason)
+		}
+	}
+
+	// Tickets are single-use, so fetch one only once we know what to launch
+	authTicket, err := roblox_api.GetAuthTicket(cookie)
+	if err != nil {
+		return result, "", fmt.Errorf("failed to get auth ticket: %w", err)
+	}
+
+	request := launch_uri.LaunchRequest{PlaceID: result.PlaceID, AuthTicket: authTicket}
+	if result.Followed {
+		request.FollowUserID = followUserID
+	}
+	logger.LogDebug("Follow launch: %s", request.Redacted())
+	return result, request.PlayerURI(), nil
+}
+
 // LaunchRobloxHomeWithAccount launches Roblox home screen with a specific account
 // using multi-instance support (copies app if Roblox is already running)
 // Returns the PID of the launched process
 func LaunchRobloxHomeWithAccount(cookie string) (int, error) {
 	logger.LogInfo("LaunchRobloxHomeWithAccount called")
 
-	// Determine which Roblox app to use
-	robloxApp := "/Applications/Roblox.app/Contents/MacOS/RobloxPlayer"
-
-	// Check if Roblox is already running - need to use copied app for multi-instance
-	if isRobloxRunning() {
-		copyPath := getNextRobloxCopyPath()
-		if err := copyRobloxApp(copyPath); err != nil {
-			logger.LogError("Failed to copy Roblox for multi-instance: %v", err)
-			// Fall through to use main app anyway
-		} else {
-			robloxApp = filepath.Join(copyPath, "Contents", "MacOS", "RobloxPlayer")
-			logger.LogInfo("Using copied app for multi-instance: %s", robloxApp)
-		}
-	}
-
 	// Launch Roblox directly without a game URL - it will open to home screen
 	// The app will use whatever credentials are stored
-	logger.LogInfo("Launching Roblox home: %s", robloxApp)
-	cmd := exec.Command(robloxApp)
-	err := cmd.Start()
+	logger.LogInfo("Launching Roblox home")
+	pid, err := startRobloxPlayer()
 	if err != nil {
 		logger.LogError("Failed to launch Roblox home: %v", err)
 		return 0, err
 	}
 
-	pid := cmd.Process.Pid
 	logger.LogInfo("Roblox home launched successfully, PID: %d", pid)
 	return pid, nil
 }
@@ -0,0 +1,27 @@
+package preset_manager
+
+import "testing"
+
+func TestExtractJobID(t *testing.T) {
+	const jobID = "0f2d7a3c-1b2e-4c5d-8e9f-a0b1c2d3e4f5"
+
+	tests := map[string]string{
+		jobID:               jobID,
+		"  " + jobID + "\n": jobID,
+		"https://www.roblox.com/games/start?placeId=1818&gameInstanceId=" + jobID: jobID,
+		"roblox://experiences/start?placeId=1818&gameInstanceId=" + jobID:         jobID,
+		"https://www.roblox.com/games/1818/Classic-Crossroads":                    "",
+		"roblox://placeId=1818": "",
+		"not a job id":          "",
+		"https://www.roblox.com/games/start?placeId=1818&gameId=" + jobID:                  jobID,
+		"https://www.roblox.com/games/start?placeId=1818&gameInstanceId=1+launchmode:edit": "",
+		"https://www.roblox.com/games/start?placeId=1818&gameId=../../etc":                 "",
+		"roblox://experiences/start?placeId=1818&gameInstanceId=not-a-guid":                "",
+	}
+
+	for input, want := range tests {
+		if got := ExtractJobID(input); got != want {
+			t.Errorf("ExtractJobID(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package ps_darwin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Parsing of the kernel's process structures, kept free of syscalls so it
+// can be tested on any platform.
+
+const (
+	_KINFO_STRUCT_SIZE = 648
+)
+
+// kinfoProc is struct kinfo_proc from <sys/sysctl.h> on 64-bit macOS,
+// keeping only the fields used here
+type kinfoProc struct {
+	StartSec  int64 // kp_proc.p_starttime.tv_sec
+	StartUsec int32 // kp_proc.p_starttime.tv_usec
+	_         [28]byte
+	Pid       int32 // kp_proc.p_pid
+	_         [199]byte
+	Comm      [16]byte // kp_proc.p_comm, NUL-terminated
+	_         [161]byte
+	UID       uint32 // kp_eproc.e_ucred.cr_uid
+	_         [136]byte
+	PPid      int32 // kp_eproc.e_ppid
+	_         [84]byte
+}
+
+// parseKinfoProcs splits a KERN_PROC_ALL sysctl result into its records
+func parseKinfoProcs(buf []byte) ([]kinfoProc, error) {
+	if len(buf)%_KINFO_STRUCT_SIZE != 0 {
+		return nil, fmt.Errorf("process table is %d bytes, not a multiple of %d", len(buf), _KINFO_STRUCT_SIZE)
+	}
+
+	procs := make([]kinfoProc, len(buf)/_KINFO_STRUCT_SIZE)
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, procs); err != nil {
+		return nil, err
+	}
+	return procs, nil
+}
+
+// startTime returns when the process started
+func (k kinfoProc) startTime() time.Time {
+	return time.Unix(k.StartSec, int64(k.StartUsec)*int64(time.Microsecond))
+}
+
+func darwinCstring(s [16]byte) string {
+	i := 0
+	for _, b := range s {
+		if b != 0 {
+			i++
+		} else {
+			break
+		}
+	}
+
+	return string(s[:i])
+}
+
+// parseProcArgs reads a KERN_PROCARGS2 sysctl result: argc, the exec path,
+// NUL padding, then argc NUL-terminated arguments followed by the
+// environment, which is ignored
+func parseProcArgs(buf []byte) (execPath string, args []string, err error) {
+	if len(buf) < 4 {
+		return "", nil, errors.New("process arguments too short")
+	}
+	argc := int(binary.LittleEndian.Uint32(buf))
+	rest := buf[4:]
+
+	end := bytes.IndexByte(rest, 0)
+	if end < 0 {
+		return "", nil, errors.New("process arguments have no exec path")
+	}
+	execPath = string(rest[:end])
+	rest = bytes.TrimLeft(rest[end:], "\x00")
+
+	args = make([]string, 0, argc)
+	for len(args) < argc {
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			return "", nil, fmt.Errorf("process arguments end after %d of %d", len(args), argc)
+		}
+		args = append(args, string(rest[:end]))
+		rest = rest[end+1:]
+	}
+	return execPath, args, nil
+}
@@ -0,0 +1,83 @@
+package ps_darwin
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// kinfoRecord builds a kinfo_proc record with fields at their offsets in
+// <sys/sysctl.h> on 64-bit macOS, independent of the kinfoProc layout
+func kinfoRecord(pid, ppid int32, uid uint32, comm string, start time.Time) []byte {
+	b := make([]byte, _KINFO_STRUCT_SIZE)
+	binary.LittleEndian.PutUint64(b[0:], uint64(start.Unix()))            // p_starttime.tv_sec
+	binary.LittleEndian.PutUint32(b[8:], uint32(start.Nanosecond()/1000)) // p_starttime.tv_usec
+	binary.LittleEndian.PutUint32(b[40:], uint32(pid))                    // p_pid
+	copy(b[243:243+17], comm)                                             // p_comm[MAXCOMLEN+1]
+	binary.LittleEndian.PutUint32(b[420:], uid)                           // e_ucred.cr_uid
+	binary.LittleEndian.PutUint32(b[560:], uint32(ppid))                  // e_ppid
+	return b
+}
+
+func TestParseKinfoProcs(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 30, 15, 250000000, time.UTC)
+
+	var buf []byte
+	buf = append(buf, kinfoRecord(1, 0, 0, "launchd", start.Add(-time.Hour))...)
+	buf = append(buf, kinfoRecord(4242, 1, 501, "RobloxPlayerBeta", start)...)
+
+	procs, err := parseKinfoProcs(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 2 {
+		t.Fatalf("parsed %d records, want 2", len(procs))
+	}
+
+	p := procs[1]
+	if p.Pid != 4242 || p.PPid != 1 || p.UID != 501 {
+		t.Fatalf("pid %d, ppid %d, uid %d", p.Pid, p.PPid, p.UID)
+	}
+	// p_comm holds 16 characters before its terminator
+	if got := darwinCstring(p.Comm); got != "RobloxPlayerBeta" {
+		t.Fatalf("comm = %q", got)
+	}
+	if got := p.startTime(); !got.Equal(start) {
+		t.Fatalf("start time = %v, want %v", got, start)
+	}
+	if got := darwinCstring(procs[0].Comm); got != "launchd" {
+		t.Fatalf("first record comm = %q", got)
+	}
+
+	if _, err := parseKinfoProcs(buf[:len(buf)-1]); err == nil {
+		t.Fatal("parsed a truncated process table")
+	}
+}
+
+func TestParseProcArgs(t *testing.T) {
+	// argc, exec path, padding, argv, then the environment
+	var buf []byte
+	buf = binary.LittleEndian.AppendUint32(buf, 3)
+	buf = append(buf, "/Applications/Roblox.app/Contents/MacOS/RobloxPlayer\x00\x00\x00\x00"...)
+	buf = append(buf, "RobloxPlayer\x00-protocolString\x00roblox-player:1+launchmode:play\x00"...)
+	buf = append(buf, "HOME=/Users/me\x00PATH=/usr/bin\x00"...)
+
+	execPath, args, err := parseProcArgs(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if execPath != "/Applications/Roblox.app/Contents/MacOS/RobloxPlayer" {
+		t.Fatalf("exec path = %q", execPath)
+	}
+	want := []string{"RobloxPlayer", "-protocolString", "roblox-player:1+launchmode:play"}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %q, want %q", args, want)
+	}
+
+	// argc larger than what's there is reported, not read past
+	buf[0] = 9
+	if _, _, err := parseProcArgs(buf[:len(buf)-30]); err == nil {
+		t.Fatal("parsed arguments cut short")
+	}
+}
@@ -7,6 +7,13 @@
 // are interested.
 package ps_darwin
 
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
 // Process is the generic interface that is implemented on every platform
 // and provides common operations for processes.
 type Process interface {
@@ -17,8 +24,22 @@ type Process interface {
 	PPid() int
 
 	// Executable name running this process. This is not a path to the
-	// executable.
+	// executable, and the kernel truncates it to 16 characters.
 	Executable() string
+
+	// StartTime is when the process started.
+	StartTime() time.Time
+
+	// UID is the effective user ID the process runs as.
+	UID() int
+
+	// Path is the full path of the executable. It is looked up when
+	// called, so it fails once the process has exited.
+	Path() (string, error)
+
+	// Args is the process's argv, including the program name. It is looked
+	// up when called and is only readable for the current user's processes.
+	Args() ([]string, error)
 }
 
 // Processes returns all processes.
@@ -48,3 +69,23 @@ func KillProcess(pid int) error {
 func ForceKillProcess(pid int) error {
 	return forceKillProcess(pid)
 }
+
+// ProcessAlive reports whether a process with pid exists. EPERM means it
+// exists but belongs to someone else.
+func ProcessAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// ProcessStartTime returns when the process with pid started, which tells it
+// apart from a later process reusing the PID.
+func ProcessStartTime(pid int) (time.Time, error) {
+	p, err := findProcess(pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if p == nil {
+		return time.Time{}, fmt.Errorf("no process with pid %d", pid)
+	}
+	return p.StartTime(), nil
+}
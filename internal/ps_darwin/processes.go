@@ -5,16 +5,17 @@ package ps_darwin
 
 import (
 	"bytes"
-	"encoding/binary"
 	"syscall"
 	"time"
 	"unsafe"
 )
 
 type DarwinProcess struct {
-	pid    int
-	ppid   int
-	binary string
+	pid       int
+	ppid      int
+	uid       int
+	binary    string
+	startTime time.Time
 }
 
 func (p *DarwinProcess) Pid() int {
@@ -29,6 +30,27 @@ func (p *DarwinProcess) Executable() string {
 	return p.binary
 }
 
+func (p *DarwinProcess) StartTime() time.Time {
+	return p.startTime
+}
+
+func (p *DarwinProcess) UID() int {
+	return p.uid
+}
+
+func (p *DarwinProcess) Path() (string, error) {
+	return pidPath(p.pid)
+}
+
+func (p *DarwinProcess) Args() ([]string, error) {
+	buf, err := sysctl([]int32{_CTRL_KERN, _KERN_PROCARGS2, int32(p.pid)})
+	if err != nil {
+		return nil, err
+	}
+	_, args, err := parseProcArgs(buf)
+	return args, err
+}
+
 func findProcess(pid int) (Process, error) {
 	ps, err := processes()
 	if err != nil {
@@ -64,57 +86,38 @@ func forceKillProcess(pid int) error {
 }
 
 func processes() ([]Process, error) {
-	buf, err := darwinSyscall()
+	buf, err := sysctl([]int32{_CTRL_KERN, _KERN_PROC, _KERN_PROC_ALL, 0})
 	if err != nil {
 		return nil, err
 	}
 
-	procs := make([]*kinfoProc, 0, 50)
-	k := 0
-	for i := _KINFO_STRUCT_SIZE; i < buf.Len(); i += _KINFO_STRUCT_SIZE {
-		proc := &kinfoProc{}
-		err = binary.Read(bytes.NewBuffer(buf.Bytes()[k:i]), binary.LittleEndian, proc)
-		if err != nil {
-			return nil, err
-		}
-
-		k = i
-		procs = append(procs, proc)
+	procs, err := parseKinfoProcs(buf)
+	if err != nil {
+		return nil, err
 	}
 
 	darwinProcs := make([]Process, len(procs))
 	for i, p := range procs {
 		darwinProcs[i] = &DarwinProcess{
-			pid:    int(p.Pid),
-			ppid:   int(p.PPid),
-			binary: darwinCstring(p.Comm),
+			pid:       int(p.Pid),
+			ppid:      int(p.PPid),
+			uid:       int(p.UID),
+			binary:    darwinCstring(p.Comm),
+			startTime: p.startTime(),
 		}
 	}
 
 	return darwinProcs, nil
 }
 
-func darwinCstring(s [16]byte) string {
-	i := 0
-	for _, b := range s {
-		if b != 0 {
-			i++
-		} else {
-			break
-		}
-	}
-
-	return string(s[:i])
-}
-
-func darwinSyscall() (*bytes.Buffer, error) {
-	mib := [4]int32{_CTRL_KERN, _KERN_PROC, _KERN_PROC_ALL, 0}
+// sysctl reads a variable-sized sysctl value
+func sysctl(mib []int32) ([]byte, error) {
 	size := uintptr(0)
 
 	_, _, errno := syscall.Syscall6(
 		syscall.SYS___SYSCTL,
 		uintptr(unsafe.Pointer(&mib[0])),
-		4,
+		uintptr(len(mib)),
 		0,
 		uintptr(unsafe.Pointer(&size)),
 		0,
@@ -128,7 +131,7 @@ func darwinSyscall() (*bytes.Buffer, error) {
 	_, _, errno = syscall.Syscall6(
 		syscall.SYS___SYSCTL,
 		uintptr(unsafe.Pointer(&mib[0])),
-		4,
+		uintptr(len(mib)),
 		uintptr(unsafe.Pointer(&bs[0])),
 		uintptr(unsafe.Pointer(&size)),
 		0,
@@ -138,22 +141,35 @@ func darwinSyscall() (*bytes.Buffer, error) {
 		return nil, errno
 	}
 
-	return bytes.NewBuffer(bs[0:size]), nil
+	return bs[0:size], nil
 }
 
-const (
-	_CTRL_KERN         = 1
-	_KERN_PROC         = 14
-	_KERN_PROC_ALL     = 0
-	_KINFO_STRUCT_SIZE = 648
-)
+// pidPath is proc_pidpath from libproc, which wraps this proc_info call
+func pidPath(pid int) (string, error) {
+	buf := make([]byte, _PROC_PIDPATHINFO_MAXSIZE)
+	n, _, errno := syscall.Syscall6(
+		syscall.SYS_PROC_INFO,
+		_PROC_INFO_CALL_PIDINFO,
+		uintptr(pid),
+		_PROC_PIDPATHINFO,
+		0,
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(len(buf)))
+
+	if errno != 0 {
+		return "", errno
+	}
 
-type kinfoProc struct {
-	_    [40]byte
-	Pid  int32
-	_    [199]byte
-	Comm [16]byte
-	_    [301]byte
-	PPid int32
-	_    [84]byte
+	return string(bytes.TrimRight(buf[:n], "\x00")), nil
 }
+
+const (
+	_CTRL_KERN      = 1
+	_KERN_PROC      = 14
+	_KERN_PROC_ALL  = 0
+	_KERN_PROCARGS2 = 49
+
+	_PROC_INFO_CALL_PIDINFO   = 2
+	_PROC_PIDPATHINFO         = 11
+	_PROC_PIDPATHINFO_MAXSIZE = 4 * 1024
+)
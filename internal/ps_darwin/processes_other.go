@@ -0,0 +1,24 @@
+//go:build !darwin
+
+package ps_darwin
+
+import "errors"
+
+// The process table is only readable on macOS; these let dependent packages
+// build and test elsewhere
+
+func processes() ([]Process, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func findProcess(pid int) (Process, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func killProcess(pid int) error {
+	return errors.ErrUnsupported
+}
+
+func forceKillProcess(pid int) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,163 @@
+package roblox_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized means Roblox rejected the cookie (expired, revoked or logged out)
+	ErrUnauthorized = errors.New("roblox: cookie is invalid or expired")
+
+	// ErrRateLimited means Roblox answered 429 Too Many Requests
+	ErrRateLimited = errors.New("roblox: rate limited")
+
+	// ErrNetwork means the request never got an HTTP response
+	ErrNetwork = errors.New("roblox: network error")
+
+	// ErrUserNotFound means no Roblox user has the requested name or ID
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// StatusError is an unexpected HTTP status from a Roblox endpoint. It matches
+// ErrUnauthorized for 401 and ErrRateLimited for 429 under errors.Is.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("API returned status: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Is lets errors.Is classify a StatusError by its status code
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// doWithCSRF sends a cookie-authenticated request, answering Roblox's
+// 403 x-csrf-token challenge with one retry; a second challenge is an
+// error. newRequest must build a fresh request each call since the body can
+// only be read once.
+func (c *Client) doWithCSRF(cookie string, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	csrfToken := ""
+	for {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Cookie", ".ROBLOSECURITY="+cookie)
+		req.Header.Set("Referer", "https://www.roblox.com/")
+		if csrfToken != "" {
+			req.Header.Set("X-CSRF-TOKEN", csrfToken)
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
+		}
+
+		token := resp.Header.Get("x-csrf-token")
+		if resp.StatusCode != http.StatusForbidden || token == "" {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if csrfToken != "" {
+			return nil, fmt.Errorf("CSRF challenge repeated after retry")
+		}
+		csrfToken = token
+	}
+}
+
+// statusError reads the body of a failed response into a StatusError
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+}
+
+// GetAuthTicket exchanges a .ROBLOSECURITY cookie for a one-time
+// authentication ticket used to launch the Roblox player
+func (c *Client) GetAuthTicket(cookie string) (string, error) {
+	if cookie == "" {
+		return "", ErrUnauthorized
+	}
+
+	resp, err := c.doWithCSRF(cookie, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c.AuthURL+"/v1/authentication-ticket", strings.NewReader(""))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get auth ticket: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get auth ticket: %w", statusError(resp))
+	}
+
+	ticket := resp.Header.Get("rbx-authentication-ticket")
+	if ticket == "" {
+		return "", fmt.Errorf("no auth ticket in response")
+	}
+	return ticket, nil
+}
+
+// GetAuthenticatedUser returns the user a .ROBLOSECURITY cookie belongs to
+func (c *Client) GetAuthenticatedUser(cookie string) (*UserInfo, error) {
+	if cookie == "" {
+		return nil, ErrUnauthorized
+	}
+
+	resp, err := c.doWithCSRF(cookie, func() (*http.Request, error) {
+		return http.NewRequest("GET", c.UsersURL+"/v1/users/authenticated", nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify cookie: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to verify cookie: %w", statusError(resp))
+	}
+
+	var user struct {
+		ID          int64  `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &UserInfo{
+		UserID:      user.ID,
+		Username:    user.Name,
+		DisplayName: user.DisplayName,
+	}, nil
+}
+
+// VerifyCookieUsername returns the username a .ROBLOSECURITY cookie belongs to
+func (c *Client) VerifyCookieUsername(cookie string) (string, error) {
+	user, err := c.GetAuthenticatedUser(cookie)
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
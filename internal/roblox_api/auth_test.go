@@ -0,0 +1,78 @@
+package roblox_api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newAuthTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClientWithBaseURL(server.URL)
+}
+
+func TestGetAuthTicketCSRFRetry(t *testing.T) {
+	client := newAuthTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cookie") != ".ROBLOSECURITY=good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-CSRF-TOKEN") != "token123" {
+			w.Header().Set("x-csrf-token", "token123")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("rbx-authentication-ticket", "ticket")
+	})
+
+	ticket, err := client.GetAuthTicket("good")
+	if err != nil || ticket != "ticket" {
+		t.Fatalf("GetAuthTicket = %q, %v", ticket, err)
+	}
+
+	if _, err := client.GetAuthTicket("bad"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+
+	// A server that rejects every token is only retried once
+	requests := 0
+	client = newAuthTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("x-csrf-token", "rotated")
+		w.WriteHeader(http.StatusForbidden)
+	})
+	if _, err := client.GetAuthTicket("good"); err == nil || requests != 2 {
+		t.Fatalf("repeated challenge: %d requests, %v", requests, err)
+	}
+}
+
+func TestVerifyCookieUsernameErrors(t *testing.T) {
+	client := newAuthTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Cookie") {
+		case ".ROBLOSECURITY=good":
+			w.Write([]byte(`{"id":42,"name":"Builderman","displayName":"Builder"}`))
+		case ".ROBLOSECURITY=busy":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+
+	if name, err := client.VerifyCookieUsername("good"); err != nil || name != "Builderman" {
+		t.Fatalf("VerifyCookieUsername = %q, %v", name, err)
+	}
+	if _, err := client.VerifyCookieUsername("busy"); !errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if _, err := client.VerifyCookieUsername("expired"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+
+	client.UsersURL = "http://127.0.0.1:1"
+	if _, err := client.VerifyCookieUsername("good"); !errors.Is(err, ErrNetwork) {
+		t.Fatalf("expected ErrNetwork, got %v", err)
+	}
+}
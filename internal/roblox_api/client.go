@@ -0,0 +1,154 @@
+package roblox_api
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// requestTimeout bounds every request, including the response body
+const requestTimeout = 10 * time.Second
+
+// secureHTTPClient creates a secure HTTP client with timeout
+var secureHTTPClient = &http.Client{
+	Timeout: requestTimeout,
+	Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+	},
+}
+
+// Client talks to the Roblox web APIs. Each host has its own base URL so
+// tests can point any of them at a fake server.
+type Client struct {
+	HTTPClient *http.Client
+
+	UsersURL      string // users.roblox.com
+	GamesURL      string // games.roblox.com
+	PresenceURL   string // presence.roblox.com
+	ThumbnailsURL string // thumbnails.roblox.com
+	APIsURL       string // apis.roblox.com
+	AuthURL       string // auth.roblox.com
+	GameJoinURL   string // gamejoin.roblox.com
+}
+
+// NewClient returns a client for the real Roblox hosts
+func NewClient() *Client {
+	return &Client{
+		HTTPClient:    secureHTTPClient,
+		UsersURL:      "https://users.roblox.com",
+		GamesURL:      "https://games.roblox.com",
+		PresenceURL:   "https://presence.roblox.com",
+		ThumbnailsURL: "https://thumbnails.roblox.com",
+		APIsURL:       "https://apis.roblox.com",
+		AuthURL:       "https://auth.roblox.com",
+		GameJoinURL:   "https://gamejoin.roblox.com",
+	}
+}
+
+// NewClientWithBaseURL returns a client that sends every host's requests to
+// one server, such as the fake_roblox test server
+func NewClientWithBaseURL(baseURL string) *Client {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &Client{
+		HTTPClient:    &http.Client{Timeout: requestTimeout},
+		UsersURL:      baseURL,
+		GamesURL:      baseURL,
+		PresenceURL:   baseURL,
+		ThumbnailsURL: baseURL,
+		APIsURL:       baseURL,
+		AuthURL:       baseURL,
+		GameJoinURL:   baseURL,
+	}
+}
+
+// defaultClient backs the package-level functions
+var defaultClient atomic.Pointer[Client]
+
+func init() {
+	defaultClient.Store(NewClient())
+}
+
+// DefaultClient returns the client used by the package-level functions
+func DefaultClient() *Client {
+	return defaultClient.Load()
+}
+
+// SetDefaultClient replaces the client used by the package-level functions
+// and returns the previous one so tests can restore it
+func SetDefaultClient(c *Client) *Client {
+	return defaultClient.Swap(c)
+}
+
+// GetGameInfo fetches game information using the default client
+func GetGameInfo(placeID int64) (*GameInfo, error) {
+	return DefaultClient().GetGameInfo(placeID)
+}
+
+// DownloadThumbnail downloads thumbnail bytes using the default client
+func DownloadThumbnail(thumbnailURL string) ([]byte, error) {
+	return DefaultClient().DownloadThumbnail(thumbnailURL)
+}
+
+// LookupUserByUsername looks up a user by username using the default client
+func LookupUserByUsername(username string) (*UserInfo, error) {
+	return DefaultClient().LookupUserByUsername(username)
+}
+
+// LookupUserByID looks up a user by ID using the default client
+func LookupUserByID(userID int64) (*UserInfo, error) {
+	return DefaultClient().LookupUserByID(userID)
+}
+
+// GetUserPresence gets user presence using the default client
+func GetUserPresence(userIDs []int64, cookie string) ([]UserPresence, error) {
+	return DefaultClient().GetUserPresence(userIDs, cookie)
+}
+
+// GetUserAvatar gets an avatar headshot URL using the default client
+func GetUserAvatar(userID int64) (string, error) {
+	return DefaultClient().GetUserAvatar(userID)
+}
+
+// ResolveShareLink resolves a share link code using the default client
+func ResolveShareLink(shareCode string, cookie string) (*ShareLinkInfo, error) {
+	return DefaultClient().ResolveShareLink(shareCode, cookie)
+}
+
+// GetPrivateServerJoinScript gets a private server join script using the default client
+func GetPrivateServerJoinScript(placeID int64, accessCode string, cookie string) (string, error) {
+	return DefaultClient().GetPrivateServerJoinScript(placeID, accessCode, cookie)
+}
+
+// GetPublicServers fetches a page of public servers using the default client
+func GetPublicServers(placeID int64, cursor string) (*ServerPage, error) {
+	return DefaultClient().GetPublicServers(placeID, cursor)
+}
+
+// ListPublicServers pages through public servers using the default client
+func ListPublicServers(placeID int64, maxPages int) ([]GameServer, error) {
+	return DefaultClient().ListPublicServers(placeID, maxPages)
+}
+
+// CheckFollowUser checks whether a user can be followed using the default client
+func CheckFollowUser(userID int64, cookie string) (*FollowStatus, error) {
+	return DefaultClient().CheckFollowUser(userID, cookie)
+}
+
+// GetAuthTicket gets an authentication ticket using the default client
+func GetAuthTicket(cookie string) (string, error) {
+	return DefaultClient().GetAuthTicket(cookie)
+}
+
+// GetAuthenticatedUser returns the cookie's user using the default client
+func GetAuthenticatedUser(cookie string) (*UserInfo, error) {
+	return DefaultClient().GetAuthenticatedUser(cookie)
+}
+
+// VerifyCookieUsername returns the cookie's username using the default client
+func VerifyCookieUsername(cookie string) (string, error) {
+	return DefaultClient().VerifyCookieUsername(cookie)
+}
@@ -0,0 +1,147 @@
+package roblox_api_test
+
+import (
+	"fmt"
+	"insadem/multi_roblox_macos/internal/fake_roblox"
+	"insadem/multi_roblox_macos/internal/roblox_api"
+	"testing"
+)
+
+func newFakeClient(t *testing.T) (*fake_roblox.Server, *roblox_api.Client) {
+	t.Helper()
+
+	server := fake_roblox.NewServer()
+	t.Cleanup(server.Close)
+
+	server.AddUser(fake_roblox.User{ID: 1, Name: "Builderman", Cookie: "cookie-1"})
+	server.AddUser(fake_roblox.User{ID: 2, Name: "Friend"})
+	server.AddGame(fake_roblox.Game{PlaceID: 606849621, UniverseID: 245662005, Name: "Jailbreak"})
+	server.SetPresence(2, fake_roblox.Presence{UserPresenceType: 2, PlaceID: 606849621, GameID: "job-abc"})
+	server.AddShareLink("share123", fake_roblox.ShareLink{PlaceID: 606849621, PrivateServerLinkCode: "link456"})
+
+	return server, roblox_api.NewClientWithBaseURL(server.URL)
+}
+
+func TestClientAgainstFakeServer(t *testing.T) {
+	server, client := newFakeClient(t)
+
+	game, err := client.GetGameInfo(606849621)
+	if err != nil || game.Name != "Jailbreak" || game.UniverseID != 245662005 || game.ThumbnailURL == "" {
+		t.Fatalf("GetGameInfo = %+v, %v", game, err)
+	}
+	if data, err := client.DownloadThumbnail(game.ThumbnailURL); err != nil || len(data) == 0 {
+		t.Fatalf("DownloadThumbnail = %d bytes, %v", len(data), err)
+	}
+
+	user, err := client.LookupUserByUsername("builderman")
+	if err != nil || user.UserID != 1 || user.Username != "Builderman" {
+		t.Fatalf("LookupUserByUsername = %+v, %v", user, err)
+	}
+	if _, err := client.LookupUserByUsername("nobody"); err == nil {
+		t.Fatalf("LookupUserByUsername found a missing user")
+	}
+	if user, err := client.LookupUserByID(2); err != nil || user.Username != "Friend" {
+		t.Fatalf("LookupUserByID = %+v, %v", user, err)
+	}
+
+	presences, err := client.GetUserPresence([]int64{2}, "cookie-1")
+	if err != nil || len(presences) != 1 || presences[0].GameID != "job-abc" || presences[0].PlaceID != 606849621 {
+		t.Fatalf("GetUserPresence = %+v, %v", presences, err)
+	}
+
+	if avatar, err := client.GetUserAvatar(2); err != nil || avatar == "" {
+		t.Fatalf("GetUserAvatar = %q, %v", avatar, err)
+	}
+
+	link, err := client.ResolveShareLink("share123", "cookie-1")
+	if err != nil || link.PlaceID != 606849621 || link.PrivateServerLinkCode != "link456" {
+		t.Fatalf("ResolveShareLink = %+v, %v", link, err)
+	}
+
+	ticket, err := client.GetAuthTicket("cookie-1")
+	if err != nil || ticket == "" {
+		t.Fatalf("GetAuthTicket = %q, %v", ticket, err)
+	}
+	if server.RequestCount("/v1/authentication-ticket") != 2 {
+		t.Fatalf("expected a CSRF challenge and a retry, got %d requests", server.RequestCount("/v1/authentication-ticket"))
+	}
+
+	if name, err := client.VerifyCookieUsername("cookie-1"); err != nil || name != "Builderman" {
+		t.Fatalf("VerifyCookieUsername = %q, %v", name, err)
+	}
+}
+
+func TestPackageFunctionsUseDefaultClient(t *testing.T) {
+	_, client := newFakeClient(t)
+	previous := roblox_api.SetDefaultClient(client)
+	defer roblox_api.SetDefaultClient(previous)
+
+	if user, err := roblox_api.LookupUserByID(1); err != nil || user.Username != "Builderman" {
+		t.Fatalf("LookupUserByID = %+v, %v", user, err)
+	}
+}
+
+func TestCheckFollowUser(t *testing.T) {
+	server, client := newFakeClient(t)
+
+	status, err := client.CheckFollowUser(2, "cookie-1")
+	if err != nil || !status.CanJoin() || status.PlaceID != 606849621 || status.JobID != "job-abc" {
+		t.Fatalf("CheckFollowUser = %+v, %v", status, err)
+	}
+
+	if status, err := client.CheckFollowUser(1, "cookie-1"); err != nil || status.CanJoin() || status.Message == "" {
+		t.Fatalf("following an offline user: %+v, %v", status, err)
+	}
+
+	server.BlockFollow(2)
+	if status, err := client.CheckFollowUser(2, "cookie-1"); err != nil || status.CanJoin() {
+		t.Fatalf("following a blocked user: %+v, %v", status, err)
+	}
+
+	if _, err := client.CheckFollowUser(2, "bad-cookie"); err == nil {
+		t.Fatalf("CheckFollowUser accepted an invalid cookie")
+	}
+}
+
+func TestListPublicServers(t *testing.T) {
+	server, client := newFakeClient(t)
+
+	var want []fake_roblox.GameServer
+	for i := 0; i < 250; i++ {
+		want = append(want, fake_roblox.GameServer{JobID: fmt.Sprintf("job-%d", i), Playing: i % 20, MaxPlayers: 20, Ping: 50 + i, FPS: 60})
+	}
+	server.AddServers(606849621, want...)
+
+	servers, err := client.ListPublicServers(606849621, 0)
+	if err != nil || len(servers) != 250 || servers[249].JobID != "job-249" {
+		t.Fatalf("ListPublicServers = %d servers, %v", len(servers), err)
+	}
+	if servers, _ := client.ListPublicServers(606849621, 2); len(servers) != 200 {
+		t.Fatalf("ListPublicServers with 2 pages = %d servers", len(servers))
+	}
+
+	maxPlayers := 1
+	empty := roblox_api.FilterServers(servers, roblox_api.ServerFilter{MaxPlayers: &maxPlayers, MaxPing: 100})
+	roblox_api.SortServers(empty, roblox_api.SortMostEmpty)
+	if len(empty) == 0 || empty[0].Playing != 0 {
+		t.Fatalf("filtered servers = %+v", empty)
+	}
+	for _, s := range empty {
+		if s.Playing > 1 || s.Ping > 100 {
+			t.Fatalf("filter let through %+v", s)
+		}
+	}
+
+	// A limit of 0 players keeps only empty servers
+	maxPlayers = 0
+	for _, s := range roblox_api.FilterServers(servers, roblox_api.ServerFilter{MaxPlayers: &maxPlayers}) {
+		if s.Playing != 0 {
+			t.Fatalf("empty-only filter let through %+v", s)
+		}
+	}
+
+	roblox_api.SortServers(servers, roblox_api.SortLowestPing)
+	if servers[0].Ping != 50 {
+		t.Fatalf("lowest ping first = %+v", servers[0])
+	}
+}
@@ -0,0 +1,81 @@
+package roblox_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Join statuses returned by the gamejoin API
+const (
+	JoinStatusRetry   = 0 // Server is being found or started
+	JoinStatusWaiting = 1 // Waiting for a slot
+	JoinStatusReady   = 2 // Joinable
+)
+
+// FollowStatus is gamejoin's answer to "can this account follow that user"
+type FollowStatus struct {
+	Status  int
+	Message string
+	PlaceID int64
+	JobID   string
+}
+
+// CanJoin reports whether the client should be able to follow the user
+func (s *FollowStatus) CanJoin() bool {
+	switch s.Status {
+	case JoinStatusRetry, JoinStatusWaiting, JoinStatusReady:
+		return true
+	}
+	return false
+}
+
+// CheckFollowUser asks the gamejoin API whether the cookie's account may
+// follow userID into their server. It doesn't launch anything; a rejection
+// (privacy settings, not in a game, full server) is returned as a status.
+func (c *Client) CheckFollowUser(userID int64, cookie string) (*FollowStatus, error) {
+	if cookie == "" {
+		return nil, ErrUnauthorized
+	}
+
+	payload := fmt.Sprintf(`{"userIdToFollow":%d}`, userID)
+	resp, err := c.doWithCSRF(cookie, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c.GameJoinURL+"/v1/play-with-user", strings.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check follow: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to check follow: %w", statusError(resp))
+	}
+
+	var result struct {
+		JobID      string `json:"jobId"`
+		Status     int    `json:"status"`
+		Message    string `json:"message"`
+		JoinScript *struct {
+			PlaceID int64  `json:"PlaceId"`
+			GameID  string `json:"GameId"`
+		} `json:"joinScript"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse follow response: %w", err)
+	}
+
+	status := &FollowStatus{Status: result.Status, Message: result.Message, JobID: result.JobID}
+	if result.JoinScript != nil {
+		status.PlaceID = result.JoinScript.PlaceID
+		if status.JobID == "" {
+			status.JobID = result.JoinScript.GameID
+		}
+	}
+	return status, nil
+}
@@ -1,7 +1,6 @@
 package roblox_api
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,7 +9,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 )
 
 // GameInfo represents Roblox game information
@@ -30,16 +28,6 @@ type ShareLinkInfo struct {
 	ServerID              string `json:"serverId"`
 }
 
-// secureHTTPClient creates a secure HTTP client with timeout
-var secureHTTPClient = &http.Client{
-	Timeout: 10 * time.Second,
-	Transport: &http.Transport{
-		TLSClientConfig: &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		},
-	},
-}
-
 // ExtractPlaceID extracts Place ID from various Roblox URL formats
 func ExtractPlaceID(urlStr string) (int64, error) {
 	// Sanitize input
@@ -77,22 +65,22 @@ func ExtractPlaceID(urlStr string) (int64, error) {
 }
 
 // GetGameInfo fetches game information from Roblox API
-func GetGameInfo(placeID int64) (*GameInfo, error) {
+func (c *Client) GetGameInfo(placeID int64) (*GameInfo, error) {
 	// Validate placeID
 	if placeID <= 0 {
 		return nil, fmt.Errorf("invalid place ID: %d", placeID)
 	}
 
 	// Step 1: Get Universe ID from Place ID
-	universeID, err := getUniverseIDFromPlaceID(placeID)
+	universeID, err := c.getUniverseIDFromPlaceID(placeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get universe ID: %w", err)
 	}
 
 	// Step 2: Get game details
-	gameURL := fmt.Sprintf("https://games.roblox.com/v1/games?universeIds=%d", universeID)
+	gameURL := fmt.Sprintf("%s/v1/games?universeIds=%d", c.GamesURL, universeID)
 
-	resp, err := secureHTTPClient.Get(gameURL)
+	resp, err := c.HTTPClient.Get(gameURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch game info: %w", err)
 	}
@@ -127,7 +115,7 @@ func GetGameInfo(placeID int64) (*GameInfo, error) {
 	game := result.Data[0]
 
 	// Step 3: Get thumbnail
-	thumbnailURL, _ := getGameThumbnail(universeID)
+	thumbnailURL, _ := c.getGameThumbnail(universeID)
 
 	return &GameInfo{
 		PlaceID:      placeID,
@@ -139,10 +127,10 @@ func GetGameInfo(placeID int64) (*GameInfo, error) {
 }
 
 // getUniverseIDFromPlaceID converts Place ID to Universe ID
-func getUniverseIDFromPlaceID(placeID int64) (int64, error) {
-	apiURL := fmt.Sprintf("https://apis.roblox.com/universes/v1/places/%d/universe", placeID)
+func (c *Client) getUniverseIDFromPlaceID(placeID int64) (int64, error) {
+	apiURL := fmt.Sprintf("%s/universes/v1/places/%d/universe", c.APIsURL, placeID)
 
-	resp, err := secureHTTPClient.Get(apiURL)
+	resp, err := c.HTTPClient.Get(apiURL)
 	if err != nil {
 		return 0, err
 	}
@@ -169,10 +157,10 @@ func getUniverseIDFromPlaceID(placeID int64) (int64, error) {
 }
 
 // getGameThumbnail fetches game thumbnail URL
-func getGameThumbnail(universeID int64) (string, error) {
-	thumbnailURL := fmt.Sprintf("https://thumbnails.roblox.com/v1/games/icons?universeIds=%d&size=512x512&format=Png", universeID)
+func (c *Client) getGameThumbnail(universeID int64) (string, error) {
+	thumbnailURL := fmt.Sprintf("%s/v1/games/icons?universeIds=%d&size=512x512&format=Png", c.ThumbnailsURL, universeID)
 
-	resp, err := secureHTTPClient.Get(thumbnailURL)
+	resp, err := c.HTTPClient.Get(thumbnailURL)
 	if err != nil {
 		return "", err
 	}
@@ -205,12 +193,12 @@ func getGameThumbnail(universeID int64) (string, error) {
 }
 
 // DownloadThumbnail downloads and returns thumbnail image bytes
-func DownloadThumbnail(thumbnailURL string) ([]byte, error) {
+func (c *Client) DownloadThumbnail(thumbnailURL string) ([]byte, error) {
 	if thumbnailURL == "" {
 		return nil, fmt.Errorf("empty thumbnail URL")
 	}
 
-	resp, err := secureHTTPClient.Get(thumbnailURL)
+	resp, err := c.HTTPClient.Get(thumbnailURL)
 	if err != nil {
 		return nil, err
 	}
@@ -243,14 +231,14 @@ type UserPresence struct {
 }
 
 // LookupUserByUsername looks up a user by their username
-func LookupUserByUsername(username string) (*UserInfo, error) {
+func (c *Client) LookupUserByUsername(username string) (*UserInfo, error) {
 	username = strings.TrimSpace(username)
 	if username == "" {
 		return nil, fmt.Errorf("username cannot be empty")
 	}
 
 	// Use the users API to look up by username
-	apiURL := "https://users.roblox.com/v1/usernames/users"
+	apiURL := c.UsersURL + "/v1/usernames/users"
 
 	payload := fmt.Sprintf(`{"usernames":["%s"],"excludeBannedUsers":false}`, username)
 
@@ -260,7 +248,7 @@ func LookupUserByUsername(username string) (*UserInfo, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := secureHTTPClient.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to lookup user: %w", err)
 	}
@@ -289,7 +277,7 @@ func LookupUserByUsername(username string) (*UserInfo, error) {
 	}
 
 	if len(result.Data) == 0 {
-		return nil, fmt.Errorf("user not found: %s", username)
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
 	}
 
 	user := result.Data[0]
@@ -301,21 +289,21 @@ func LookupUserByUsername(username string) (*UserInfo, error) {
 }
 
 // LookupUserByID looks up a user by their ID
-func LookupUserByID(userID int64) (*UserInfo, error) {
+func (c *Client) LookupUserByID(userID int64) (*UserInfo, error) {
 	if userID <= 0 {
 		return nil, fmt.Errorf("invalid user ID")
 	}
 
-	apiURL := fmt.Sprintf("https://users.roblox.com/v1/users/%d", userID)
+	apiURL := fmt.Sprintf("%s/v1/users/%d", c.UsersURL, userID)
 
-	resp, err := secureHTTPClient.Get(apiURL)
+	resp, err := c.HTTPClient.Get(apiURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to lookup user: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("user not found: %d", userID)
+		return nil, fmt.Errorf("%w: %d", ErrUserNotFound, userID)
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API returned status: %d", resp.StatusCode)
@@ -345,12 +333,12 @@ func LookupUserByID(userID int64) (*UserInfo, error) {
 
 // GetUserPresence gets the online presence of one or more users
 // Note: This requires authentication (cookie) to get accurate results
-func GetUserPresence(userIDs []int64, cookie string) ([]UserPresence, error) {
+func (c *Client) GetUserPresence(userIDs []int64, cookie string) ([]UserPresence, error) {
 	if len(userIDs) == 0 {
 		return nil, fmt.Errorf("no user IDs provided")
 	}
 
-	apiURL := "https://presence.roblox.com/v1/presence/users"
+	apiURL := c.PresenceURL + "/v1/presence/users"
 
 	// Build the request body
 	idsJSON, err := json.Marshal(struct {
@@ -369,7 +357,7 @@ func GetUserPresence(userIDs []int64, cookie string) ([]UserPresence, error) {
 		req.Header.Set("Cookie", ".ROBLOSECURITY="+cookie)
 	}
 
-	resp, err := secureHTTPClient.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get presence: %w", err)
 	}
@@ -396,14 +384,14 @@ func GetUserPresence(userIDs []int64, cookie string) ([]UserPresence, error) {
 }
 
 // GetUserAvatar gets the avatar headshot URL for a user
-func GetUserAvatar(userID int64) (string, error) {
+func (c *Client) GetUserAvatar(userID int64) (string, error) {
 	if userID <= 0 {
 		return "", fmt.Errorf("invalid user ID")
 	}
 
-	apiURL := fmt.Sprintf("https://thumbnails.roblox.com/v1/users/avatar-headshot?userIds=%d&size=150x150&format=Png", userID)
+	apiURL := fmt.Sprintf("%s/v1/users/avatar-headshot?userIds=%d&size=150x150&format=Png", c.ThumbnailsURL, userID)
 
-	resp, err := secureHTTPClient.Get(apiURL)
+	resp, err := c.HTTPClient.Get(apiURL)
 	if err != nil {
 		return "", err
 	}
@@ -438,13 +426,13 @@ func GetUserAvatar(userID int64) (string, error) {
 // ResolveShareLink resolves a share link code to get the actual private server details
 // This is needed because share codes (from roblox.com/share?code=XXX) are different
 // from the direct privateServerLinkCode used in game URLs
-func ResolveShareLink(shareCode string, cookie string) (*ShareLinkInfo, error) {
+func (c *Client) ResolveShareLink(shareCode string, cookie string) (*ShareLinkInfo, error) {
 	if shareCode == "" {
 		return nil, fmt.Errorf("empty share code")
 	}
 
 	// Try the share-links API
-	apiURL := "https://apis.roblox.com/share-links/v1/resolve-link"
+	apiURL := c.APIsURL + "/share-links/v1/resolve-link"
 
 	payload := fmt.Sprintf(`{"linkId":"%s","linkType":"Server"}`, shareCode)
 
@@ -457,7 +445,7 @@ func ResolveShareLink(shareCode string, cookie string) (*ShareLinkInfo, error) {
 		req.Header.Set("Cookie", ".ROBLOSECURITY="+cookie)
 	}
 
-	resp, err := secureHTTPClient.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve share link: %w", err)
 	}
@@ -514,9 +502,9 @@ func ResolveShareLink(shareCode string, cookie string) (*ShareLinkInfo, error) {
 
 // GetPrivateServerJoinScript gets the join script for a private server
 // This is an alternative approach that mimics what the browser does
-func GetPrivateServerJoinScript(placeID int64, accessCode string, cookie string) (string, error) {
+func (c *Client) GetPrivateServerJoinScript(placeID int64, accessCode string, cookie string) (string, error) {
 	// Try using the games API to get join info
-	apiURL := fmt.Sprintf("https://gamejoin.roblox.com/v1/join-private-game")
+	apiURL := c.GameJoinURL + "/v1/join-private-game"
 
 	payload := fmt.Sprintf(`{"placeId":%d,"accessCode":"%s"}`, placeID, accessCode)
 
@@ -529,7 +517,7 @@ func GetPrivateServerJoinScript(placeID int64, accessCode string, cookie string)
 		req.Header.Set("Cookie", ".ROBLOSECURITY="+cookie)
 	}
 
-	resp, err := secureHTTPClient.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to get join script: %w", err)
 	}
@@ -0,0 +1,166 @@
+package roblox_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// GameServer is one running public server of a place
+type GameServer struct {
+	JobID      string  `json:"id"`
+	Playing    int     `json:"playing"`
+	MaxPlayers int     `json:"maxPlayers"`
+	Ping       int     `json:"ping"`
+	FPS        float64 `json:"fps"`
+}
+
+// FreeSlots returns how many more players fit in the server
+func (s GameServer) FreeSlots() int {
+	if free := s.MaxPlayers - s.Playing; free > 0 {
+		return free
+	}
+	return 0
+}
+
+// ServerPage is one page of a place's public servers
+type ServerPage struct {
+	Servers    []GameServer
+	NextCursor string // Empty on the last page
+}
+
+// GetPublicServers fetches one page (up to 100) of a place's public servers.
+// Pass the previous page's NextCursor to continue.
+func (c *Client) GetPublicServers(placeID int64, cursor string) (*ServerPage, error) {
+	if placeID <= 0 {
+		return nil, fmt.Errorf("invalid place ID: %d", placeID)
+	}
+
+	query := url.Values{}
+	query.Set("sortOrder", "Asc")
+	query.Set("limit", "100")
+	query.Set("excludeFullGames", "false")
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	apiURL := fmt.Sprintf("%s/v1/games/%d/servers/Public?%s", c.GamesURL, placeID, query.Encode())
+
+	resp, err := c.HTTPClient.Get(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch servers: %w", ErrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch servers: %w", statusError(resp))
+	}
+
+	var result struct {
+		NextPageCursor *string      `json:"nextPageCursor"`
+		Data           []GameServer `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse servers: %w", err)
+	}
+
+	page := &ServerPage{Servers: result.Data}
+	if result.NextPageCursor != nil {
+		page.NextCursor = *result.NextPageCursor
+	}
+	return page, nil
+}
+
+// ListPublicServers pages through a place's public servers, stopping after
+// maxPages pages (0 means no limit). If a later page fails, the servers
+// fetched so far are returned along with the error.
+func (c *Client) ListPublicServers(placeID int64, maxPages int) ([]GameServer, error) {
+	var servers []GameServer
+	cursor := ""
+	for pages := 0; maxPages == 0 || pages < maxPages; pages++ {
+		page, err := c.GetPublicServers(placeID, cursor)
+		if err != nil {
+			return servers, err
+		}
+		servers = append(servers, page.Servers...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	return servers, nil
+}
+
+// ServerSort is an ordering for a server list
+type ServerSort int
+
+const (
+	SortMostEmpty ServerSort = iota
+	SortFullest
+	SortLowestPing
+	SortHighestFPS
+)
+
+// String returns the label shown for the sort order
+func (s ServerSort) String() string {
+	switch s {
+	case SortMostEmpty:
+		return "Most empty"
+	case SortFullest:
+		return "Fullest"
+	case SortLowestPing:
+		return "Lowest ping"
+	case SortHighestFPS:
+		return "Highest FPS"
+	}
+	return "Unknown"
+}
+
+// SortServers sorts servers in place
+func SortServers(servers []GameServer, order ServerSort) {
+	sort.SliceStable(servers, func(i, j int) bool {
+		a, b := servers[i], servers[j]
+		switch order {
+		case SortFullest:
+			return a.Playing > b.Playing
+		case SortLowestPing:
+			// Servers that don't report ping go last
+			if (a.Ping == 0) != (b.Ping == 0) {
+				return b.Ping == 0
+			}
+			return a.Ping < b.Ping
+		case SortHighestFPS:
+			return a.FPS > b.FPS
+		default:
+			return a.Playing < b.Playing
+		}
+	})
+}
+
+// ServerFilter selects servers worth joining; zero fields don't filter
+type ServerFilter struct {
+	MinFreeSlots int
+	// MaxPlayers drops servers with more players than this, if set; 0 keeps
+	// only empty servers
+	MaxPlayers *int
+	MaxPing    int
+}
+
+// FilterServers returns the servers that pass the filter
+func FilterServers(servers []GameServer, filter ServerFilter) []GameServer {
+	var kept []GameServer
+	for _, s := range servers {
+		if s.FreeSlots() < filter.MinFreeSlots {
+			continue
+		}
+		if filter.MaxPlayers != nil && s.Playing > *filter.MaxPlayers {
+			continue
+		}
+		if filter.MaxPing > 0 && s.Ping > filter.MaxPing {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return kept
+}
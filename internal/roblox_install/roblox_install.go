@@ -0,0 +1,188 @@
+package roblox_install
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/config_store"
+	"insadem/multi_roblox_macos/internal/logger"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotInstalled is returned when no Roblox installation can be found
+var ErrNotInstalled = errors.New("Roblox is not installed")
+
+// Installation is a Roblox.app bundle found on disk
+type Installation struct {
+	Path          string // The .app bundle
+	Version       string // CFBundleShortVersionString, e.g. 0.650.0.6500734
+	BundleVersion string // CFBundleVersion
+	Custom        bool   // Chosen by the user rather than found in a default location
+}
+
+// PlayerPath returns the player binary inside the bundle
+func (i Installation) PlayerPath() string {
+	return filepath.Join(i.Path, "Contents", "MacOS", "RobloxPlayer")
+}
+
+// Fingerprint identifies the exact build and location; it changes whenever
+// Roblox updates or a different install is selected
+func (i Installation) Fingerprint() string {
+	return fmt.Sprintf("%s@%s+%s", i.Path, i.Version, i.BundleVersion)
+}
+
+// String describes the installation for display
+func (i Installation) String() string {
+	if i.Version == "" {
+		return i.Path
+	}
+	return fmt.Sprintf("%s (version %s)", i.Path, i.Version)
+}
+
+// settings is the saved installation preference
+type settings struct {
+	CustomPath string `json:"custom_path,omitempty"`
+}
+
+var settingsDoc = config_store.NewDocument[settings]("roblox_install.json")
+
+// DefaultLocations returns where Roblox is looked for, in order
+func DefaultLocations() []string {
+	locations := []string{"/Applications/Roblox.app"}
+	if home, err := os.UserHomeDir(); err == nil {
+		locations = append(locations, filepath.Join(home, "Applications", "Roblox.app"))
+	}
+	return locations
+}
+
+// CustomPath returns the user's chosen Roblox.app, or "" if none is set
+func CustomPath() string {
+	s, err := settingsDoc.Load()
+	if err != nil {
+		return ""
+	}
+	return s.CustomPath
+}
+
+// SetCustomPath saves a Roblox.app to use ahead of the default locations.
+// An empty path goes back to automatic detection.
+func SetCustomPath(path string) error {
+	if path != "" {
+		if _, err := Inspect(path); err != nil {
+			return err
+		}
+	}
+
+	logger.LogInfo("Roblox installation set to %q", path)
+	return settingsDoc.Save(settings{CustomPath: path})
+}
+
+// Resolve finds the Roblox installation to launch: the custom path if set
+// and still valid, otherwise the first default location that has Roblox
+func Resolve() (*Installation, error) {
+	if custom := CustomPath(); custom != "" {
+		install, err := Inspect(custom)
+		if err == nil {
+			install.Custom = true
+			return install, nil
+		}
+		logger.LogError("Custom Roblox installation unusable, falling back to defaults: %v", err)
+	}
+
+	for _, path := range DefaultLocations() {
+		if install, err := Inspect(path); err == nil {
+			return install, nil
+		}
+	}
+	return nil, ErrNotInstalled
+}
+
+// Inspect reads the Roblox bundle at path
+func Inspect(path string) (*Installation, error) {
+	if _, err := os.Stat(filepath.Join(path, "Contents", "MacOS", "RobloxPlayer")); err != nil {
+		return nil, fmt.Errorf("no Roblox player in %s: %w", path, err)
+	}
+
+	info, err := readInfoPlist(filepath.Join(path, "Contents", "Info.plist"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Roblox version: %w", err)
+	}
+
+	return &Installation{
+		Path:          path,
+		Version:       info["CFBundleShortVersionString"],
+		BundleVersion: info["CFBundleVersion"],
+	}, nil
+}
+
+// readInfoPlist returns the string values of a property list's top-level
+// dictionary. Binary plists are converted with plutil first.
+func readInfoPlist(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		data, err = exec.Command("plutil", "-convert", "xml1", "-o", "-", path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert binary plist: %w", err)
+		}
+	}
+	return parsePlistStrings(data)
+}
+
+// parsePlistStrings reads the <key>/<string> pairs of an XML plist's
+// top-level dictionary, skipping values of other types
+func parsePlistStrings(data []byte) (map[string]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	values := make(map[string]string)
+	depth := 0 // <plist> is 1, the top-level <dict> 2, its entries 3
+	key := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth != 3 {
+				continue
+			}
+			if t.Name.Local != "key" && t.Name.Local != "string" {
+				key = "" // A value of another type
+				continue
+			}
+
+			var text string
+			if err := decoder.DecodeElement(&text, &t); err != nil {
+				return nil, fmt.Errorf("invalid plist: %w", err)
+			}
+			depth-- // DecodeElement consumed the end tag
+			if t.Name.Local == "key" {
+				key = strings.TrimSpace(text)
+			} else if key != "" {
+				values[key] = strings.TrimSpace(text)
+				key = ""
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("plist has no string values")
+	}
+	return values, nil
+}
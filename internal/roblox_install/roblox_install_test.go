@@ -0,0 +1,99 @@
+package roblox_install
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleExecutable</key>
+	<string>RobloxPlayer</string>
+	<key>CFBundleURLTypes</key>
+	<array>
+		<dict>
+			<key>CFBundleVersion</key>
+			<string>nested</string>
+		</dict>
+	</array>
+	<key>LSRequiresNativeExecution</key>
+	<true/>
+	<key>CFBundleShortVersionString</key>
+	<string>0.650.0.6500734</string>
+	<key>CFBundleVersion</key>
+	<string>0.650.0.6500734</string>
+</dict>
+</plist>
+`
+
+func writeApp(t *testing.T, path, plist string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(path, "Contents", "MacOS"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "Contents", "MacOS", "RobloxPlayer"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "Contents", "Info.plist"), []byte(plist), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Roblox.app")
+	writeApp(t, app, testInfoPlist)
+
+	install, err := Inspect(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if install.Version != "0.650.0.6500734" || install.BundleVersion != "0.650.0.6500734" {
+		t.Fatalf("Inspect = %+v", install)
+	}
+	if install.PlayerPath() != filepath.Join(app, "Contents", "MacOS", "RobloxPlayer") {
+		t.Fatalf("PlayerPath = %s", install.PlayerPath())
+	}
+
+	if _, err := Inspect(t.TempDir()); err == nil {
+		t.Fatal("directory without Roblox accepted")
+	}
+}
+
+func TestResolvePrefersCustomPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	userApp := filepath.Join(home, "Applications", "Roblox.app")
+	writeApp(t, userApp, testInfoPlist)
+	custom := filepath.Join(home, "Games", "Roblox.app")
+	writeApp(t, custom, testInfoPlist)
+
+	// /Applications may hold a real install, so only check the custom path wins
+	if err := SetCustomPath(custom); err != nil {
+		t.Fatal(err)
+	}
+	install, err := Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if install.Path != custom || !install.Custom {
+		t.Fatalf("Resolve = %+v, want custom %s", install, custom)
+	}
+
+	// A custom path that disappears falls back to detection
+	os.RemoveAll(custom)
+	install, err = Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if install.Custom {
+		t.Fatalf("Resolve used the missing custom path: %+v", install)
+	}
+
+	if err := SetCustomPath(filepath.Join(home, "Nope.app")); err == nil {
+		t.Fatal("invalid custom path saved")
+	}
+}
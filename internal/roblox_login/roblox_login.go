@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"insadem/multi_roblox_macos/internal/logger"
 	"insadem/multi_roblox_macos/internal/open_app"
+	"insadem/multi_roblox_macos/internal/roblox_install"
 	"os/exec"
 	"time"
 )
@@ -19,7 +20,12 @@ func LaunchWithAccount(username, password string) error {
 	logger.LogInfo("LaunchWithAccount called for user: %s", username)
 
 	// Launch Roblox
-	if err := open_app.Open("/Applications/Roblox.app"); err != nil {
+	install, err := roblox_install.Resolve()
+	if err != nil {
+		logger.LogError("Failed to launch Roblox for user %s: %v", username, err)
+		return err
+	}
+	if err := open_app.Open(install.Path); err != nil {
 		logger.LogError("Failed to launch Roblox for user %s: %v", username, err)
 		return fmt.Errorf("failed to launch Roblox: %w", err)
 	}
@@ -44,7 +50,12 @@ func LaunchWithAccount(username, password string) error {
 func LaunchWithoutAccount() error {
 	logger.LogInfo("LaunchWithoutAccount called")
 
-	if err := open_app.Open("/Applications/Roblox.app"); err != nil {
+	install, err := roblox_install.Resolve()
+	if err != nil {
+		logger.LogError("Failed to launch Roblox: %v", err)
+		return err
+	}
+	if err := open_app.Open(install.Path); err != nil {
 		logger.LogError("Failed to launch Roblox: %v", err)
 		return err
 	}
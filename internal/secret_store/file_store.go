@@ -0,0 +1,229 @@
+package secret_store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	fileStoreName = "secrets.enc"
+	keyFileName   = "secrets.key"
+	keyFileSuffix = ".key" // Where earlier versions kept the key, next to the data
+	fileKeySize   = 32     // AES-256
+)
+
+// FileStore keeps secrets in an AES-256-GCM encrypted file. The key is
+// generated on first use and kept with owner-only permissions in a separate
+// directory. The encryption only protects the data file when it is copied
+// without the key: never back up or sync the key along with it.
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	keyPath string
+}
+
+// DefaultFileStorePath returns the path of the encrypted secrets file
+func DefaultFileStorePath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "Application Support", "multi_roblox_macos", fileStoreName)
+}
+
+// DefaultFileStoreKeyPath returns the path of the secrets file's key,
+// outside the app's data directory
+func DefaultFileStoreKeyPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "multi_roblox_macos", keyFileName)
+}
+
+// NewFileStore returns a store that reads and writes the encrypted file at
+// path with the key at keyPath
+func NewFileStore(path, keyPath string) *FileStore {
+	return &FileStore{
+		path:    path,
+		keyPath: keyPath,
+	}
+}
+
+// Get returns the secret for service/account
+func (f *FileStore) Get(service, account string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secrets, err := f.load()
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := secrets[service][account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set creates or replaces the secret for service/account
+func (f *FileStore) Set(service, account, secret string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	if secrets[service] == nil {
+		secrets[service] = make(map[string]string)
+	}
+	secrets[service][account] = secret
+	return f.save(secrets)
+}
+
+// Delete removes the secret for service/account
+func (f *FileStore) Delete(service, account string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secrets[service][account]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets[service], account)
+	if len(secrets[service]) == 0 {
+		delete(secrets, service)
+	}
+	return f.save(secrets)
+}
+
+// load decrypts the secrets file. A missing file is an empty store.
+func (f *FileStore) load() (map[string]map[string]string, error) {
+	secrets := make(map[string]map[string]string)
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return secrets, nil
+		}
+		return nil, err
+	}
+
+	gcm, err := f.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("secrets file is corrupt: too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file: %w", err)
+	}
+
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+	return secrets, nil
+}
+
+// save encrypts secrets and replaces the file atomically
+func (f *FileStore) save(secrets map[string]map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := f.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := gcm.Seal(nonce, nonce, plaintext, nil)
+
+	tmpPath := f.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, f.path)
+}
+
+// cipher loads the key file (creating it if allowed) and returns an AEAD
+func (f *FileStore) cipher(create bool) (cipher.AEAD, error) {
+	if err := f.moveLegacyKey(); err != nil {
+		return nil, fmt.Errorf("failed to move secrets key: %w", err)
+	}
+
+	key, err := os.ReadFile(f.keyPath)
+	if os.IsNotExist(err) && create {
+		key = make([]byte, fileKeySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(f.keyPath), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(f.keyPath, key, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write secrets key: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read secrets key: %w", err)
+	}
+
+	if len(key) != fileKeySize {
+		return nil, fmt.Errorf("secrets key has invalid length %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// moveLegacyKey moves a key kept next to the data file, as earlier versions
+// did, to keyPath
+func (f *FileStore) moveLegacyKey() error {
+	legacy := f.path + keyFileSuffix
+	if legacy == f.keyPath {
+		return nil
+	}
+	key, err := os.ReadFile(legacy)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(f.keyPath); err == nil {
+		return fmt.Errorf("both %s and %s exist", legacy, f.keyPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.keyPath), 0700); err != nil {
+		return err
+	}
+	// Written before removing the old copy, so a crash loses neither
+	if err := os.WriteFile(f.keyPath, key, 0600); err != nil {
+		return err
+	}
+	return os.Remove(legacy)
+}
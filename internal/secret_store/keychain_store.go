@@ -0,0 +1,82 @@
+package secret_store
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychainItemNotFound is the exit status `security` uses for a missing item
+const keychainItemNotFound = 44
+
+// KeychainStore keeps secrets as generic passwords in the macOS login Keychain
+type KeychainStore struct{}
+
+// NewKeychainStore returns a store backed by the `security` CLI
+func NewKeychainStore() *KeychainStore {
+	return &KeychainStore{}
+}
+
+// Get reads a generic password from the Keychain
+func (k *KeychainStore) Get(service, account string) (string, error) {
+	cmd := exec.Command("security", "find-generic-password",
+		"-s", service,
+		"-a", account,
+		"-w") // Print password only
+
+	output, err := cmd.Output()
+	if err != nil {
+		if isKeychainNotFound(err) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read from keychain: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Set writes a generic password to the Keychain, replacing any existing entry.
+// Items get the default access list, which trusts the `security` tool that
+// creates and later reads them, so reads don't raise a prompt.
+func (k *KeychainStore) Set(service, account, secret string) error {
+	// Updating in place keeps an item's old access list, so recreate it to
+	// get the same one however the item was first written (ignore errors)
+	exec.Command("security", "delete-generic-password",
+		"-s", service,
+		"-a", account).Run()
+
+	cmd := exec.Command("security", "add-generic-password",
+		"-s", service,
+		"-a", account,
+		"-w", secret,
+		"-U") // Update if exists
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to write to keychain: %w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// Delete removes a generic password from the Keychain
+func (k *KeychainStore) Delete(service, account string) error {
+	err := exec.Command("security", "delete-generic-password",
+		"-s", service,
+		"-a", account).Run()
+	if err != nil {
+		if isKeychainNotFound(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete from keychain: %w", err)
+	}
+
+	return nil
+}
+
+// isKeychainNotFound reports whether a `security` failure means "no such item"
+func isKeychainNotFound(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == keychainItemNotFound
+}
@@ -0,0 +1,51 @@
+package secret_store
+
+import "sync"
+
+// MemoryStore keeps secrets in process memory only. Nothing survives a restart,
+// which makes it suitable for tests.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	secrets map[string]map[string]string
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{secrets: make(map[string]map[string]string)}
+}
+
+// Get returns the secret for service/account
+func (m *MemoryStore) Get(service, account string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	secret, ok := m.secrets[service][account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set creates or replaces the secret for service/account
+func (m *MemoryStore) Set(service, account, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.secrets[service] == nil {
+		m.secrets[service] = make(map[string]string)
+	}
+	m.secrets[service][account] = secret
+	return nil
+}
+
+// Delete removes the secret for service/account
+func (m *MemoryStore) Delete(service, account string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.secrets[service][account]; !ok {
+		return ErrNotFound
+	}
+	delete(m.secrets[service], account)
+	return nil
+}
@@ -0,0 +1,92 @@
+package secret_store
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when no secret exists for a service/account pair
+var ErrNotFound = errors.New("secret not found")
+
+// SecretStore stores small secrets (passwords, cookies) keyed by service and account
+type SecretStore interface {
+	// Get returns the secret for service/account, or ErrNotFound
+	Get(service, account string) (string, error)
+
+	// Set creates or replaces the secret for service/account
+	Set(service, account, secret string) error
+
+	// Delete removes the secret for service/account. Deleting a missing
+	// secret returns ErrNotFound.
+	Delete(service, account string) error
+}
+
+// StoreEnvVar selects the backend used by Default: "keychain", "file" or "memory"
+const StoreEnvVar = "MULTI_ROBLOX_SECRET_STORE"
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore SecretStore
+)
+
+// Default returns the process-wide secret store.
+// The backend is chosen by MULTI_ROBLOX_SECRET_STORE; when unset, macOS uses
+// the Keychain and every other platform uses the encrypted file store.
+func Default() SecretStore {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultStore == nil {
+		defaultStore = newStoreFromEnv()
+	}
+	return defaultStore
+}
+
+// SetDefault replaces the process-wide secret store (used by tests and by
+// users who can't access the Keychain)
+func SetDefault(store SecretStore) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = store
+}
+
+// newStoreFromEnv builds the backend named by StoreEnvVar
+func newStoreFromEnv() SecretStore {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(StoreEnvVar))) {
+	case "keychain":
+		return NewKeychainStore()
+	case "file":
+		return NewFileStore(DefaultFileStorePath(), DefaultFileStoreKeyPath())
+	case "memory":
+		return NewMemoryStore()
+	}
+
+	if runtime.GOOS == "darwin" {
+		return NewKeychainStore()
+	}
+	return NewFileStore(DefaultFileStorePath(), DefaultFileStoreKeyPath())
+}
+
+// Rename moves a secret to a new account key within the same service. It
+// copies before deleting, so an interrupted rename can simply be retried;
+// a missing source is not an error.
+func Rename(store SecretStore, service, oldAccount, newAccount string) error {
+	secret, err := store.Get(service, oldAccount)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := store.Set(service, newAccount, secret); err != nil {
+		return err
+	}
+	if err := store.Delete(service, oldAccount); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package secret_store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, store SecretStore) {
+	if _, err := store.Get("svc", "acct"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get on empty store: got %v, want ErrNotFound", err)
+	}
+
+	if err := store.Set("svc", "acct", "hunter2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := store.Set("svc", "acct", "hunter3"); err != nil {
+		t.Fatalf("Set (replace): %v", err)
+	}
+	if err := store.Set("other", "acct", "unrelated"); err != nil {
+		t.Fatalf("Set (other service): %v", err)
+	}
+
+	got, err := store.Get("svc", "acct")
+	if err != nil || got != "hunter3" {
+		t.Fatalf("Get: got %q, %v; want hunter3", got, err)
+	}
+
+	if err := store.Delete("svc", "acct"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete("svc", "acct"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete: got %v, want ErrNotFound", err)
+	}
+	if got, _ := store.Get("other", "acct"); got != "unrelated" {
+		t.Fatalf("Delete removed the wrong secret, other = %q", got)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "secrets.enc")
+	keyPath := filepath.Join(t.TempDir(), "keys", "secrets.key")
+	testStore(t, NewFileStore(path, keyPath))
+
+	// A fresh store on the same path must see the persisted data
+	reopened := NewFileStore(path, keyPath)
+	if got, err := reopened.Get("other", "acct"); err != nil || got != "unrelated" {
+		t.Fatalf("reopened Get: got %q, %v", got, err)
+	}
+
+	// Nothing but the encrypted data is kept in its directory
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("data directory holds %d files, want only the secrets file", len(entries))
+	}
+}
+
+func TestFileStoreMovesLegacyKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.enc")
+	legacy := NewFileStore(path, path+keyFileSuffix)
+	if err := legacy.Set("svc", "acct", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath := filepath.Join(t.TempDir(), "secrets.key")
+	store := NewFileStore(path, keyPath)
+	if got, err := store.Get("svc", "acct"); err != nil || got != "value" {
+		t.Fatalf("Get after moving the key = %q, %v", got, err)
+	}
+	if _, err := os.Stat(path + keyFileSuffix); !os.IsNotExist(err) {
+		t.Fatalf("key left next to the data: %v", err)
+	}
+	if _, err := os.Stat(keyPath); err != nil {
+		t.Fatalf("key not moved: %v", err)
+	}
+}
+
+func TestFileStoreWrongKey(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStore(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "keys", "secrets.key"))
+	if err := store.Set("svc", "acct", "value"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	other := NewFileStore(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "keys", "other.key"))
+	if err := other.Set("svc", "x", "y"); err == nil {
+		t.Fatalf("expected decryption failure with a different key")
+	}
+}
@@ -0,0 +1,123 @@
+package state_db
+
+import (
+	"database/sql"
+	"errors"
+	"insadem/multi_roblox_macos/internal/config_store"
+	"insadem/multi_roblox_macos/internal/logger"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// importedSuffix is appended to a JSON file once it has been imported
+const importedSuffix = ".imported"
+
+// JSONSource moves one kind of data between the JSON file it used to be
+// stored in and the database. Packages register theirs so old files are
+// imported and vaults can keep carrying the file format.
+type JSONSource struct {
+	File string // Name in the app directory, such as presets.json
+	// Import replaces the data in the database with the file's contents
+	Import func(tx *sql.Tx, data []byte) error
+	// Export renders the data in the file's format
+	Export func(db *sql.DB) ([]byte, error)
+}
+
+var (
+	sourcesMu sync.Mutex
+	sources   = map[string]JSONSource{}
+)
+
+// RegisterJSONSource registers how a JSON file maps onto the database. Call
+// it from an init function, before the database is first opened.
+func RegisterJSONSource(src JSONSource) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sources[src.File] = src
+}
+
+func lookupSource(file string) (JSONSource, bool) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	src, ok := sources[file]
+	return src, ok
+}
+
+// importJSONFiles imports each registered JSON file found in dir that hasn't
+// been imported yet, then renames it so it's kept as a backup but unused
+func importJSONFiles(db *sql.DB, dir string) {
+	sourcesMu.Lock()
+	var pending []JSONSource
+	for _, src := range sources {
+		pending = append(pending, src)
+	}
+	sourcesMu.Unlock()
+
+	for _, src := range pending {
+		path := filepath.Join(dir, src.File)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			logger.LogError("Failed to read %s for import: %v", src.File, err)
+			continue
+		}
+
+		err = InTx(db, func(tx *sql.Tx) error {
+			var done int
+			if err := tx.QueryRow("SELECT COUNT(*) FROM json_imports WHERE file = ?", src.File).Scan(&done); err != nil {
+				return err
+			}
+			if done > 0 {
+				// Imported before, but the rename failed; the database wins
+				return nil
+			}
+			if err := src.Import(tx, data); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO json_imports (file, imported_at) VALUES (?, ?)", src.File, time.Now())
+			return err
+		})
+		if err != nil {
+			logger.LogError("Failed to import %s: %v", src.File, err)
+			continue
+		}
+
+		if err := os.Rename(path, path+importedSuffix); err != nil {
+			logger.LogError("Imported %s but couldn't rename it: %v", src.File, err)
+			continue
+		}
+		logger.LogInfo("Imported %s into the state database", src.File)
+	}
+}
+
+// ExportJSON returns the data for a JSON file in that file's format. Files
+// no package has registered are read from the app directory as they are.
+func ExportJSON(file string) ([]byte, error) {
+	src, ok := lookupSource(file)
+	if !ok {
+		return os.ReadFile(config_store.Path(file))
+	}
+
+	db, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return src.Export(db)
+}
+
+// ImportJSON replaces the data for a JSON file with data in that file's
+// format. Files no package has registered are written to the app directory.
+func ImportJSON(file string, data []byte) error {
+	src, ok := lookupSource(file)
+	if !ok {
+		return config_store.WriteFile(file, data)
+	}
+
+	return Update(func(tx *sql.Tx) error {
+		return src.Import(tx, data)
+	})
+}
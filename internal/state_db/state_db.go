@@ -0,0 +1,231 @@
+package state_db
+
+import (
+	"database/sql"
+	"fmt"
+	"insadem/multi_roblox_macos/internal/config_store"
+	"insadem/multi_roblox_macos/internal/logger"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// FileName is the database's name in the app directory
+const FileName = "state.db"
+
+// migrations upgrade the schema one version at a time. PRAGMA user_version
+// records how many have run; append new ones, never edit old ones.
+var migrations = []string{
+	// 1: tables for the data previously kept in JSON files
+	`
+	CREATE TABLE accounts (
+		seq       INTEGER PRIMARY KEY,
+		id        TEXT NOT NULL, -- Not unique: old files can repeat sequential IDs until migrated
+		username  TEXT NOT NULL,
+		label     TEXT NOT NULL DEFAULT '',
+		user_id   INTEGER NOT NULL DEFAULT 0,
+		legacy_id TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX accounts_id ON accounts (id);
+	CREATE INDEX accounts_user_id ON accounts (user_id);
+
+	CREATE TABLE presets (
+		seq                      INTEGER PRIMARY KEY,
+		name                     TEXT NOT NULL,
+		url                      TEXT NOT NULL DEFAULT '',
+		place_id                 INTEGER NOT NULL DEFAULT 0,
+		thumbnail_url            TEXT NOT NULL DEFAULT '',
+		last_account_used        TEXT NOT NULL DEFAULT '',
+		private_server_link_code TEXT NOT NULL DEFAULT '',
+		job_id                   TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE friends (
+		seq               INTEGER PRIMARY KEY,
+		user_id           INTEGER NOT NULL UNIQUE,
+		username          TEXT NOT NULL,
+		display_name      TEXT NOT NULL DEFAULT '',
+		added_at          DATETIME NOT NULL,
+		notes             TEXT NOT NULL DEFAULT '',
+		last_account_used TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE labels (
+		pid   INTEGER PRIMARY KEY,
+		label TEXT NOT NULL,
+		color TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE instance_accounts (
+		pid         INTEGER PRIMARY KEY,
+		account_id  TEXT NOT NULL,
+		launched_at DATETIME NOT NULL,
+		place_id    INTEGER NOT NULL DEFAULT 0,
+		job_id      TEXT NOT NULL DEFAULT '',
+		log_path    TEXT NOT NULL DEFAULT '',
+		keep_alive  TEXT -- KeepAlivePolicy as JSON, NULL if none
+	);
+	CREATE INDEX instance_accounts_account_id ON instance_accounts (account_id);
+
+	CREATE TABLE launch_history (
+		id          INTEGER PRIMARY KEY,
+		account_id  TEXT NOT NULL,
+		description TEXT NOT NULL,
+		place_id    INTEGER NOT NULL DEFAULT 0,
+		job_id      TEXT NOT NULL DEFAULT '',
+		pid         INTEGER NOT NULL DEFAULT 0,
+		status      TEXT NOT NULL,
+		error       TEXT NOT NULL DEFAULT '',
+		result      TEXT NOT NULL DEFAULT '', -- Verified outcome, once known
+		detail      TEXT NOT NULL DEFAULT '',
+		queued_at   DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL
+	);
+	CREATE INDEX launch_history_account_id ON launch_history (account_id);
+
+	CREATE TABLE json_imports (
+		file        TEXT PRIMARY KEY,
+		imported_at DATETIME NOT NULL
+	);
+	`,
+
+	// 2: remember that an account was offered a username lookup
+	`
+	ALTER TABLE accounts ADD COLUMN user_id_looked_up INTEGER NOT NULL DEFAULT 0;
+	`,
+}
+
+// Open opens the database at path, creating it if needed, and brings its
+// schema up to date
+func Open(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	dsn := "file:" + path + "?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on&_txlock=immediate"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// One connection serializes this process's access; other processes wait
+	// on SQLite's own locks
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		logger.LogError("Failed to restrict permissions on %s: %v", path, err)
+	}
+	return db, nil
+}
+
+// migrate runs the migrations the database hasn't had yet
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database version %d is newer than this app supports (%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		logger.LogInfo("Migrated state database to version %d", i+1)
+	}
+	return nil
+}
+
+var (
+	defaultMu   sync.Mutex
+	defaultDB   *sql.DB
+	defaultPath string
+)
+
+// Default returns the database in the app directory. The first use opens it
+// and imports any JSON files it replaces. It is reopened if the app
+// directory changes, as it does between tests.
+func Default() (*sql.DB, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	path := config_store.Path(FileName)
+	if defaultDB != nil && defaultPath == path {
+		return defaultDB, nil
+	}
+	if defaultDB != nil {
+		defaultDB.Close()
+		defaultDB = nil
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	importJSONFiles(db, filepath.Dir(path))
+
+	defaultDB, defaultPath = db, path
+	generation.Add(1) // Nothing loaded from the old database applies
+	return db, nil
+}
+
+// Querier is a database or a transaction
+type Querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// Update runs fn in a transaction on the default database, committing if it
+// returns nil. The database has a single connection, so fn must make every
+// query through tx.
+func Update(fn func(tx *sql.Tx) error) error {
+	db, err := Default()
+	if err != nil {
+		return err
+	}
+	return InTx(db, fn)
+}
+
+// generation counts committed transactions
+var generation atomic.Uint64
+
+// Generation changes whenever a transaction run through InTx or Update
+// commits, so readers can cache what they loaded until then
+func Generation() uint64 {
+	return generation.Load()
+}
+
+// InTx runs fn in a transaction on db, committing if it returns nil
+func InTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	generation.Add(1)
+	return nil
+}
@@ -0,0 +1,133 @@
+package state_db
+
+import (
+	"database/sql"
+	"insadem/multi_roblox_macos/internal/config_store"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func useTempAppDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	config_store.SetAppDir(dir)
+	t.Cleanup(func() { config_store.SetAppDir("") })
+	return dir
+}
+
+func TestOpenMigratesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	for i := 0; i < 2; i++ {
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open #%d: %v", i+1, err)
+		}
+		var version int
+		if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		if version != len(migrations) {
+			t.Fatalf("user_version = %d, want %d", version, len(migrations))
+		}
+		db.Close()
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("database has mode %v, want 0600", perm)
+	}
+
+	// A database from a newer app is refused rather than misread
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("PRAGMA user_version = 999"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("Open of newer database = %v", err)
+	}
+}
+
+func TestImportJSONFiles(t *testing.T) {
+	dir := useTempAppDir(t)
+
+	imports := 0
+	RegisterJSONSource(JSONSource{
+		File: "test_labels.json",
+		Import: func(tx *sql.Tx, data []byte) error {
+			imports++
+			_, err := tx.Exec("INSERT OR REPLACE INTO labels (pid, label) VALUES (1, ?)", string(data))
+			return err
+		},
+		Export: func(db *sql.DB) ([]byte, error) {
+			var label string
+			err := db.QueryRow("SELECT label FROM labels WHERE pid = 1").Scan(&label)
+			return []byte(label), err
+		},
+	})
+	t.Cleanup(func() {
+		sourcesMu.Lock()
+		delete(sources, "test_labels.json")
+		sourcesMu.Unlock()
+	})
+
+	path := filepath.Join(dir, "test_labels.json")
+	if err := os.WriteFile(path, []byte("Main"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ExportJSON("test_labels.json")
+	if err != nil || string(data) != "Main" {
+		t.Fatalf("ExportJSON = %q, %v", data, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("imported file still in place: %v", err)
+	}
+	if _, err := os.Stat(path + importedSuffix); err != nil {
+		t.Fatalf("imported file not kept as a backup: %v", err)
+	}
+
+	// A file reappearing after its import is ignored
+	if err := os.WriteFile(path, []byte("Alt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	importJSONFiles(db, dir)
+	if imports != 1 {
+		t.Fatalf("imported %d times, want 1", imports)
+	}
+
+	// ImportJSON goes through the registered source
+	if err := ImportJSON("test_labels.json", []byte("Alt 2")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ExportJSON("test_labels.json"); string(data) != "Alt 2" {
+		t.Fatalf("ExportJSON after ImportJSON = %q", data)
+	}
+}
+
+func TestUnregisteredFilesStayFiles(t *testing.T) {
+	dir := useTempAppDir(t)
+
+	if err := ImportJSON("other.json", []byte(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "other.json")); err != nil || string(data) != `{"a":1}` {
+		t.Fatalf("other.json = %q, %v", data, err)
+	}
+	if data, err := ExportJSON("other.json"); err != nil || string(data) != `{"a":1}` {
+		t.Fatalf("ExportJSON = %q, %v", data, err)
+	}
+}
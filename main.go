@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"image/color"
 	"insadem/multi_roblox_macos/internal/account_manager"
+	"insadem/multi_roblox_macos/internal/browser_source"
+	"insadem/multi_roblox_macos/internal/clone_pool"
 	"insadem/multi_roblox_macos/internal/close_all_app_instances"
 	"insadem/multi_roblox_macos/internal/cookie_manager"
 	"insadem/multi_roblox_macos/internal/discord_link_parser"
@@ -11,14 +13,21 @@ import (
 	"insadem/multi_roblox_macos/internal/friends_manager"
 	"insadem/multi_roblox_macos/internal/instance_account_tracker"
 	"insadem/multi_roblox_macos/internal/instance_manager"
+	"insadem/multi_roblox_macos/internal/instance_supervisor"
 	"insadem/multi_roblox_macos/internal/label_manager"
+	"insadem/multi_roblox_macos/internal/launch_verifier"
+	"insadem/multi_roblox_macos/internal/launcher"
 	"insadem/multi_roblox_macos/internal/logger"
+	"insadem/multi_roblox_macos/internal/party_launch"
 	"insadem/multi_roblox_macos/internal/preset_manager"
 	"insadem/multi_roblox_macos/internal/resource_monitor"
 	"insadem/multi_roblox_macos/internal/roblox_api"
+	"insadem/multi_roblox_macos/internal/roblox_install"
 	"insadem/multi_roblox_macos/internal/roblox_login"
 	"insadem/multi_roblox_macos/internal/roblox_session"
 	"insadem/multi_roblox_macos/internal/thumbnail_cache"
+	"io"
+	"net/url"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -46,25 +55,40 @@ func main() {
 
 	logger.LogInfo("Multi Roblox Manager started")
 
-	// Cleanup old /tmp Roblox copies on startup
-	cookie_manager.CleanupTempRobloxCopies()
+	// Free Roblox clones left by instances that have since exited
+	clone_pool.RemoveLegacyCopies()
+	if _, err := clone_pool.GC(); err != nil {
+		logger.LogError("Failed to clean up Roblox clones: %v", err)
+	}
+
+	// Move accounts off the old sequential IDs before anything reads them
+	if err := account_manager.MigrateAccountIDs(
+		cookie_manager.RekeyAccount,
+		instance_account_tracker.RekeyAccount,
+		preset_manager.RekeyAccount,
+		friends_manager.RekeyAccount,
+	); err != nil {
+		logger.LogError("Account ID migration incomplete, will retry next launch: %v", err)
+	}
+
+	// Keep the running instances in memory, and relaunch kept-alive ones
+	// that crash or get disconnected
+	instance_manager.Start()
+	instance_supervisor.Start()
 
 	// Auto-refresh expired cookies on startup and periodically
 	go func() {
 		refreshCookies := func() {
+			// Validating saved cookies also binds accounts saved before user
+			// IDs were tracked
 			accounts, err := account_manager.LoadAccounts()
 			if err == nil && len(accounts) > 0 {
-				var accountIDs []string
-				accountUsernames := make(map[string]string)
-				for _, acc := range accounts {
-					accountIDs = append(accountIDs, acc.ID)
-					accountUsernames[acc.ID] = acc.Username
-				}
-
-				results := cookie_manager.AutoRefreshExpiredCookies(accountIDs, accountUsernames)
+				results := cookie_manager.AutoRefreshExpiredCookies(accounts)
 				for _, r := range results {
 					if r.Refreshed {
 						logger.LogInfo("Auto-refreshed cookie for %s", r.Username)
+					} else if r.ExpiresSoon {
+						logger.LogInfo("Cookie for %s expires in %d days: %s", r.Username, r.DaysUntilExpiry, r.Error)
 					} else if r.WasExpired && r.Error != "" {
 						logger.LogDebug("Could not auto-refresh %s: %s", r.Username, r.Error)
 					}
@@ -100,14 +124,52 @@ func main() {
 
 	// Cleanup on app close
 	window.SetOnClosed(func() {
-		logger.LogInfo("App closing, cleaning up temporary files...")
-		cookie_manager.CleanupTempRobloxCopies()
+		logger.LogInfo("App closing, cleaning up unused Roblox clones...")
+		if _, err := clone_pool.GC(); err != nil {
+			logger.LogError("Failed to clean up Roblox clones: %v", err)
+		}
 		logger.LogInfo("Cleanup complete, goodbye!")
 	})
 
+	// Accounts without a cookie can only be bound by username, if the user agrees
+	go offerUserIDLookups(window)
+
 	window.ShowAndRun()
 }
 
+// offerUserIDLookups asks, once per account, whether to bind accounts saved
+// before user IDs were tracked to the user now holding their username
+func offerUserIDLookups(window fyne.Window) {
+	accounts, err := account_manager.PendingUserIDLookups(cookie_manager.HasSavedCookie)
+	if err != nil {
+		logger.LogError("Failed to list accounts without a user ID: %v", err)
+		return
+	}
+
+	for _, acc := range accounts {
+		user, err := account_manager.LookupUserID(acc.ID)
+		if err != nil {
+			logger.LogError("Failed to look up user ID for %s: %v", acc.Username, err)
+			continue
+		}
+
+		answered := make(chan bool)
+		dialog.ShowConfirm("Bind Account",
+			fmt.Sprintf("Account %s was saved without a Roblox user ID.\n\n"+
+				"The username now belongs to %s (@%s, user ID %d). Usernames can change hands, "+
+				"so only bind it if this is your account.\n\nCapturing a cookie binds the account without asking.",
+				acc.Username, user.DisplayName, user.Username, user.UserID),
+			func(ok bool) { answered <- ok }, window)
+		if !<-answered {
+			logger.LogInfo("Left account %s unbound", acc.Username)
+			continue
+		}
+		if err := account_manager.SyncAccountIdentity(acc.ID, user.UserID, ""); err != nil {
+			logger.LogError("Failed to bind account %s: %v", acc.Username, err)
+		}
+	}
+}
+
 func createInstancesTab(window fyne.Window) fyne.CanvasObject {
 	// Instance counter and system stats labels
 	counterLabel := widget.NewLabel("Running Instances: 0")
@@ -115,9 +177,34 @@ func createInstancesTab(window fyne.Window) fyne.CanvasObject {
 
 	systemStatsLabel := widget.NewLabel("System: CPU 0% | Memory 0 MB / 0 MB")
 
+	// The registry's instances as last shown. Updates come from several
+	// goroutines, so the list reads them through instanceAt.
+	var instancesMu sync.Mutex
+	var currentInstances []instance_manager.Instance
+	instanceAt := func(id int) (instance_manager.Instance, bool) {
+		instancesMu.Lock()
+		defer instancesMu.Unlock()
+		if id < 0 || id >= len(currentInstances) {
+			return instance_manager.Instance{}, false
+		}
+		return currentInstances[id], true
+	}
+
+	// refreshRegistry picks up changes made from this tab right away rather
+	// than on the next scan; the registry's events then update the list
+	refreshRegistry := func() {
+		if err := instance_manager.Default().Refresh(); err != nil {
+			logger.LogError("Failed to list instances: %v", err)
+		}
+	}
+
 	// Instance list with resource stats and labels
 	instanceList := widget.NewList(
-		func() int { return 0 },
+		func() int {
+			instancesMu.Lock()
+			defer instancesMu.Unlock()
+			return len(currentInstances)
+		},
 		func() fyne.CanvasObject {
 			colorIndicator := canvas.NewRectangle(color.Transparent)
 			colorIndicator.SetMinSize(fyne.NewSize(4, 40))
@@ -127,6 +214,7 @@ func createInstancesTab(window fyne.Window) fyne.CanvasObject {
 				colorIndicator,
 				container.NewVBox(
 					widget.NewButton("Label", nil),
+					widget.NewButton("Keep Alive", nil),
 					widget.NewButton("Close", nil),
 				),
 				container.NewVBox(
@@ -135,41 +223,11 @@ func createInstancesTab(window fyne.Window) fyne.CanvasObject {
 				),
 			)
 		},
-		func(id widget.ListItemID, obj fyne.CanvasObject) {},
-	)
-
-	var currentInstances []instance_manager.Instance
-	var updateInstances func()
-
-	// Update instance list function
-	updateInstances = func() {
-		instances, err := instance_manager.GetRunningInstances()
-		if err != nil {
-			return
-		}
-
-		currentInstances = instances
-		counterLabel.SetText(fmt.Sprintf("Running Instances: %d", len(instances)))
-
-		// Update system stats
-		cpuPercent, memUsed, memTotal, err := resource_monitor.GetSystemStats()
-		if err == nil {
-			systemStatsLabel.SetText(fmt.Sprintf("System: CPU %.1f%% | Memory %s / %s",
-				cpuPercent,
-				resource_monitor.FormatMemory(memUsed),
-				resource_monitor.FormatMemory(memTotal)))
-		}
-
-		instanceList.Length = func() int {
-			return len(currentInstances)
-		}
-
-		instanceList.UpdateItem = func(id widget.ListItemID, obj fyne.CanvasObject) {
-			if id >= len(currentInstances) {
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			instance, ok := instanceAt(id)
+			if !ok {
 				return
 			}
-
-			instance := currentInstances[id]
 			border := obj.(*fyne.Container)
 
 			// BorderLayout NewBorder(top, bottom, left, right, center) stores objects as:
@@ -183,7 +241,8 @@ func createInstancesTab(window fyne.Window) fyne.CanvasObject {
 			resourceLabel := labelBox.Objects[1].(*widget.Label)
 
 			labelButton := buttonBox.Objects[0].(*widget.Button)
-			closeButton := buttonBox.Objects[1].(*widget.Button)
+			keepAliveButton := buttonBox.Objects[1].(*widget.Button)
+			closeButton := buttonBox.Objects[2].(*widget.Button)
 
 			// Set color indicator
 			if instance.Color != "" {
@@ -217,59 +276,156 @@ func createInstancesTab(window fyne.Window) fyne.CanvasObject {
 			}
 
 			// Add account info if available
-			if accountID, found := instance_account_tracker.GetAccountForInstance(instance.PID); found {
-				if account, err := account_manager.GetAccount(accountID); err == nil {
-					accountLabel := account.Username
-					if account.Label != "" {
-						accountLabel = account.Label
-					}
-					labelText += fmt.Sprintf(" - 👤 %s", accountLabel)
+			if tracked := instance.Tracking; tracked != nil {
+				if tracked.AccountName != "" {
+					labelText += fmt.Sprintf(" - 👤 %s", tracked.AccountName)
+				}
+				if tracked.JobID != "" {
+					labelText += fmt.Sprintf(" - 🖥 %s", tracked.JobID[:min(8, len(tracked.JobID))])
 				}
+				if tracked.KeepAlive != nil {
+					labelText += fmt.Sprintf(" - 🔁 %s", tracked.KeepAlive.PresetName)
+				}
+			} else if suggested := instance.Suggested; suggested != nil {
+				// A guess from the launch's preset or friend, until presence confirms it
+				labelText += fmt.Sprintf(" - ❓ Probably %s (%s, unconfirmed)", suggested.AccountName, suggested.Source)
 			} else if instance.Label == "" {
 				// Untracked instance - prompt user to label it
 				labelText += " - ❓ Unknown account"
+				if launch := instance.Launch; launch != nil && launch.Request != nil && launch.Request.PlaceID > 0 {
+					labelText += fmt.Sprintf(" in place %d", launch.Request.PlaceID)
+				}
+			}
+			if launch := instance.Launch; launch != nil {
+				switch launch.Source {
+				case instance_manager.SourceClone:
+					resourceInfo += " | 📦 " + launch.CloneName
+				case instance_manager.SourceOther:
+					resourceInfo += " | " + launch.Path
+				}
 			}
 
 			instanceLabel.SetText(labelText)
 			resourceLabel.SetText(resourceInfo)
 
-			// Label button
+			// Label button; saving a label publishes the update itself
 			labelButton.OnTapped = func() {
-				showLabelDialog(window, instance.PID, updateInstances)
+				showLabelDialog(window, instance.PID, func() {})
+			}
+
+			// Keep-alive needs an account to relaunch with
+			if instance.Tracking != nil {
+				keepAliveButton.Enable()
+			} else {
+				keepAliveButton.Disable()
+			}
+			keepAliveButton.OnTapped = func() {
+				showKeepAliveDialog(window, instance.PID, refreshRegistry)
 			}
 
 			// Close button
 			closeButton.OnTapped = func() {
 				instance_manager.CloseInstance(instance.PID)
-				updateInstances()
 			}
+		},
+	)
+
+	// Update system stats, and each row's through the list refresh
+	updateStats := func() {
+		cpuPercent, memUsed, memTotal, err := resource_monitor.GetSystemStats()
+		if err == nil {
+			systemStatsLabel.SetText(fmt.Sprintf("System: CPU %.1f%% | Memory %s / %s",
+				cpuPercent,
+				resource_monitor.FormatMemory(memUsed),
+				resource_monitor.FormatMemory(memTotal)))
 		}
+		instanceList.Refresh()
+	}
+
+	// Update instance list function
+	updateInstances := func() {
+		instances := instance_manager.Default().Instances()
+		instancesMu.Lock()
+		currentInstances = instances
+		instancesMu.Unlock()
 
+		counterLabel.SetText(fmt.Sprintf("Running Instances: %d", len(instances)))
 		instanceList.Refresh()
 	}
 
-	// Auto-refresh every 2 seconds
+	// Launch queue progress, shown while launches are pending or just finished
+	queueLabel := widget.NewLabel("")
+	queueLabel.Wrapping = fyne.TextWrapWord
+	queueLabel.Hide()
+	updateQueue := func() {
+		var lines []string
+		for _, job := range launcher.Jobs() {
+			if job.Status.Done() && time.Since(job.UpdatedAt) > 30*time.Second {
+				continue
+			}
+			lines = append(lines, formatLaunchJob(job))
+		}
+		if len(lines) == 0 {
+			queueLabel.Hide()
+			return
+		}
+		queueLabel.SetText("Launch Queue\n" + strings.Join(lines, "\n"))
+		queueLabel.Show()
+	}
+	go func() {
+		updates, _ := launcher.Subscribe()
+		for update := range updates {
+			updateQueue()
+			if update.Status == launcher.StatusRunning {
+				// Pick the new instance up now rather than on the next scan
+				refreshRegistry()
+			}
+		}
+	}()
+
+	// The list changes with the registry; resource stats and finished queue
+	// entries still age, so those refresh on a timer
+	go func() {
+		events, _ := instance_manager.Default().Subscribe()
+		for range events {
+			updateInstances()
+		}
+	}()
 	go func() {
 		for {
 			time.Sleep(2 * time.Second)
-			updateInstances()
+			updateStats()
+			updateQueue()
 		}
 	}()
 
 	// Initial update
 	updateInstances()
+	updateStats()
 
 	// Buttons
 	newInstanceButton := widget.NewButtonWithIcon("New Instance", resourceMorePng, func() {
 		showAccountSelectionDialog(window, func() {
 			time.Sleep(500 * time.Millisecond)
-			updateInstances()
+			refreshRegistry()
 		})
 	})
 
 	closeAllButton := widget.NewButtonWithIcon("Close All", resourceMopPng, func() {
+		// Closed on purpose, so don't relaunch anything
+		if err := instance_account_tracker.ClearKeepAlive(); err != nil {
+			logger.LogError("Failed to clear keep-alive policies: %v", err)
+		}
 		close_all_app_instances.Close("RobloxPlayer")
-		updateInstances()
+		refreshRegistry()
+	})
+
+	queueSettingsButton := widget.NewButton("Launch Queue Settings", func() {
+		showLaunchQueueSettingsDialog(window)
+	})
+
+	historyButton := widget.NewButton("Launch History", func() {
+		showLaunchHistoryDialog(window)
 	})
 
 	// Layout
@@ -277,12 +433,15 @@ func createInstancesTab(window fyne.Window) fyne.CanvasObject {
 		container.NewVBox(
 			counterLabel,
 			systemStatsLabel,
+			queueLabel,
 			widget.NewSeparator(),
 		),
 		container.NewVBox(
 			widget.NewSeparator(),
 			newInstanceButton,
 			closeAllButton,
+			queueSettingsButton,
+			historyButton,
 		),
 		nil,
 		nil,
@@ -290,6 +449,104 @@ func createInstancesTab(window fyne.Window) fyne.CanvasObject {
 	)
 }
 
+// formatLaunchJob describes a launch queue job in one line
+func formatLaunchJob(job launcher.JobState) string {
+	icon := map[launcher.Status]string{
+		launcher.StatusQueued:         "⏳",
+		launcher.StatusFetchingTicket: "🎟",
+		launcher.StatusCloning:        "📦",
+		launcher.StatusStarting:       "🚀",
+		launcher.StatusRunning:        "✅",
+		launcher.StatusFailed:         "❌",
+	}[job.Status]
+
+	line := fmt.Sprintf("%s %s - %s", icon, job.Request.Label(), job.Status)
+	switch {
+	case job.Err != nil:
+		line += fmt.Sprintf(": %v", job.Err)
+	case job.PID > 0:
+		line += fmt.Sprintf(" (PID: %d)", job.PID)
+	}
+
+	if outcome := job.Outcome; outcome != nil {
+		if outcome.OK() {
+			line += " - verified in game"
+		} else {
+			line += fmt.Sprintf("\n    ⚠️ %s: %s", outcome.Result, outcome.Detail)
+		}
+	} else if job.Status == launcher.StatusRunning {
+		line += " - verifying..."
+	}
+	return line
+}
+
+// showLaunchQueueSettingsDialog edits how many launches run at once and how
+// far apart they start
+func showLaunchQueueSettingsDialog(window fyne.Window) {
+	config := launcher.LoadConfig()
+
+	maxEntry := widget.NewEntry()
+	maxEntry.SetText(strconv.Itoa(config.MaxConcurrent))
+	gapEntry := widget.NewEntry()
+	gapEntry.SetText(strconv.FormatFloat(config.MinGapSeconds, 'f', -1, 64))
+
+	form := []*widget.FormItem{
+		widget.NewFormItem("Launches at once", maxEntry),
+		widget.NewFormItem("Seconds between launches", gapEntry),
+	}
+	dialog.ShowForm("Launch Queue Settings", "Save", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		maxConcurrent, err := strconv.Atoi(strings.TrimSpace(maxEntry.Text))
+		if err != nil || maxConcurrent < 1 {
+			dialog.ShowError(fmt.Errorf("Launches at once must be a whole number of at least 1"), window)
+			return
+		}
+		gap, err := strconv.ParseFloat(strings.TrimSpace(gapEntry.Text), 64)
+		if err != nil || gap < 0 {
+			dialog.ShowError(fmt.Errorf("Seconds between launches must be 0 or more"), window)
+			return
+		}
+		if err := launcher.SaveConfig(launcher.Config{MaxConcurrent: maxConcurrent, MinGapSeconds: gap}); err != nil {
+			dialog.ShowError(err, window)
+		}
+	}, window)
+}
+
+// showLaunchHistoryDialog lists recent launches and how they ended
+func showLaunchHistoryDialog(window fyne.Window) {
+	entries, err := launcher.History(100)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to load launch history: %w", err), window)
+		return
+	}
+
+	lines := []string{}
+	for _, entry := range entries {
+		line := fmt.Sprintf("%s  %s - %s", entry.FinishedAt.Format("Jan 2 15:04"), entry.Description, entry.Status)
+		switch {
+		case entry.Err != "":
+			line += ": " + entry.Err
+		case entry.Result != "":
+			line += fmt.Sprintf(", %s", entry.Result)
+			if entry.Detail != "" && entry.Result != launch_verifier.ResultJoined {
+				line += " (" + entry.Detail + ")"
+			}
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No launches yet")
+	}
+
+	label := widget.NewLabel(strings.Join(lines, "\n"))
+	label.Wrapping = fyne.TextWrapWord
+	historyDialog := dialog.NewCustom("Launch History", "Close", container.NewVScroll(label), window)
+	historyDialog.Resize(fyne.NewSize(560, 480))
+	historyDialog.Show()
+}
+
 func createPresetsTab(window fyne.Window) fyne.CanvasObject {
 	// Load presets
 	presets, _ := preset_manager.LoadPresets()
@@ -314,6 +571,8 @@ func createPresetsTab(window fyne.Window) fyne.CanvasObject {
 
 			buttonBox := container.NewHBox(
 				widget.NewButton("Launch", nil),
+				widget.NewButton("Servers...", nil),
+				widget.NewButton("Party...", nil),
 				widget.NewButton("Settings", nil),
 				widget.NewButton("Delete Preset", nil),
 			)
@@ -347,8 +606,10 @@ func createPresetsTab(window fyne.Window) fyne.CanvasObject {
 			buttonBox := infoBox.Objects[3].(*fyne.Container)
 
 			launchBtn := buttonBox.Objects[0].(*widget.Button)
-			settingsBtn := buttonBox.Objects[1].(*widget.Button)
-			deleteBtn := buttonBox.Objects[2].(*widget.Button)
+			serversBtn := buttonBox.Objects[1].(*widget.Button)
+			partyBtn := buttonBox.Objects[2].(*widget.Button)
+			settingsBtn := buttonBox.Objects[3].(*widget.Button)
+			deleteBtn := buttonBox.Objects[4].(*widget.Button)
 
 			// Set game name
 			nameLabel.SetText(preset.Name)
@@ -363,6 +624,8 @@ func createPresetsTab(window fyne.Window) fyne.CanvasObject {
 			// Show private server status
 			if preset.PrivateServerLinkCode != "" {
 				serverLabel.SetText("🔒 Private Server configured")
+			} else if preset.JobID != "" {
+				serverLabel.SetText("📌 Pinned to server " + preset.JobID[:min(8, len(preset.JobID))])
 			} else {
 				serverLabel.SetText("")
 			}
@@ -394,6 +657,20 @@ func createPresetsTab(window fyne.Window) fyne.CanvasObject {
 				})
 			}
 
+			serversBtn.OnTapped = func() {
+				showServerBrowserDialog(window, preset, id)
+			}
+			partyBtn.OnTapped = func() {
+				showPartyLaunchDialog(window, preset, id)
+			}
+			if preset.PlaceID > 0 {
+				serversBtn.Enable()
+				partyBtn.Enable()
+			} else {
+				serversBtn.Disable()
+				partyBtn.Disable()
+			}
+
 			settingsBtn.OnTapped = func() {
 				showPresetSettingsDialog(window, preset, id, func() {
 					presets, _ = preset_manager.LoadPresets()
@@ -462,6 +739,11 @@ func createPresetsTab(window fyne.Window) fyne.CanvasObject {
 		customDialog.Show()
 	})
 
+	dataReloaders = append(dataReloaders, func() {
+		presets, _ = preset_manager.LoadPresets()
+		presetList.Refresh()
+	})
+
 	// Layout
 	return container.NewBorder(
 		nil,
@@ -476,6 +758,17 @@ func createPresetsTab(window fyne.Window) fyne.CanvasObject {
 	)
 }
 
+// dataReloaders reload each tab's list after its data changed on disk, e.g.
+// after a vault import
+var dataReloaders []func()
+
+// reloadAllData reloads every tab's list
+func reloadAllData() {
+	for _, reload := range dataReloaders {
+		reload()
+	}
+}
+
 // Global friends state for periodic refresh
 var (
 	friendsListWidget    *widget.List
@@ -516,10 +809,11 @@ func createFriendsTab(window fyne.Window) fyne.CanvasObject {
 			gameLabel := widget.NewLabel("")
 			joinBtn := widget.NewButton("Join", nil)
 			joinBtn.Importance = widget.HighImportance
+			joinAsBtn := widget.NewButton("Join As...", nil)
 			deleteBtn := widget.NewButton("Remove", nil)
 
 			leftBox := container.NewVBox(nameLabel, statusLabel, gameLabel)
-			rightBox := container.NewHBox(joinBtn, deleteBtn)
+			rightBox := container.NewHBox(joinBtn, joinAsBtn, deleteBtn)
 
 			return container.NewBorder(nil, nil, nil, rightBox, leftBox)
 		},
@@ -537,7 +831,8 @@ func createFriendsTab(window fyne.Window) fyne.CanvasObject {
 			statusLabel := leftBox.Objects[1].(*widget.Label)
 			gameLabel := leftBox.Objects[2].(*widget.Label)
 			joinBtn := rightBox.Objects[0].(*widget.Button)
-			deleteBtn := rightBox.Objects[1].(*widget.Button)
+			joinAsBtn := rightBox.Objects[1].(*widget.Button)
+			deleteBtn := rightBox.Objects[2].(*widget.Button)
 
 			// Display name
 			displayText := friend.Username
@@ -578,10 +873,18 @@ func createFriendsTab(window fyne.Window) fyne.CanvasObject {
 				joinBtn.Disable()
 			}
 
-			// Join button - launches game to join this friend
+			// Join button - follows this friend with the account last used for them
 			joinBtn.OnTapped = func() {
+				joinFriend(window, friend, presence)
+			}
+			joinAsBtn.OnTapped = func() {
 				showJoinFriendDialog(window, friend, presence)
 			}
+			if joinBtn.Disabled() {
+				joinAsBtn.Disable()
+			} else {
+				joinAsBtn.Enable()
+			}
 
 			// Delete button
 			deleteBtn.OnTapped = func() {
@@ -613,6 +916,8 @@ func createFriendsTab(window fyne.Window) fyne.CanvasObject {
 
 	buttonBox := container.NewHBox(addFriendBtn, refreshBtn)
 
+	dataReloaders = append(dataReloaders, func() { refreshFriendsList(countLabel) })
+
 	// Start periodic status refresh
 	go startFriendsStatusRefresh()
 
@@ -765,18 +1070,17 @@ func showAddFriendDialog(window fyne.Window, countLabel *widget.Label) {
 }
 
 func showJoinFriendDialog(window fyne.Window, friend friends_manager.Friend, presence roblox_api.UserPresence) {
-	if presence.PlaceID == 0 {
+	// Show account selection for joining via browser
+	accounts, err := account_manager.LoadAccounts()
+	if presence.PlaceID == 0 && (err != nil || len(accounts) == 0) {
 		dialog.ShowInformation("Cannot Join",
 			fmt.Sprintf("%s is not in a joinable game.", friend.Username),
 			window)
 		return
 	}
-
-	// Show account selection for joining via browser
-	accounts, err := account_manager.LoadAccounts()
 	if err != nil || len(accounts) == 0 {
 		// Launch without account selection
-		launchJoinFriendViaBrowser(presence.PlaceID, friend.UserID, window)
+		launchJoinFriendViaBrowser(presence.PlaceID, presence.GameID, friend.UserID, window)
 		dialog.ShowInformation("Join Friend",
 			fmt.Sprintf("Opening game page to join %s!\n\nClick Play on the game page.", friend.Username),
 			window)
@@ -797,8 +1101,14 @@ func showJoinFriendDialog(window fyne.Window, friend friends_manager.Friend, pre
 	if len(options) > 0 {
 		selectWidget.SetSelected(options[0])
 	}
+	for i, acc := range accounts {
+		if acc.ID == friend.LastAccountUsed {
+			selectWidget.SetSelected(options[i])
+			break
+		}
+	}
 
-	infoLabel := widget.NewLabel(fmt.Sprintf("Join %s in:\n%s\n\nOpens game page in browser - click Play to join.", friend.Username, presence.LastLocation))
+	infoLabel := widget.NewLabel(fmt.Sprintf("Join %s in:\n%s\n\nAccounts with a saved cookie follow them straight into their server; others open the game page in your browser.", friend.Username, presence.LastLocation))
 	infoLabel.Wrapping = fyne.TextWrapWord
 
 	content := container.NewVBox(
@@ -808,7 +1118,7 @@ func showJoinFriendDialog(window fyne.Window, friend friends_manager.Friend, pre
 		selectWidget,
 	)
 
-	dialog.ShowCustomConfirm("Join Friend", "Open Game Page", "Cancel", content,
+	dialog.ShowCustomConfirm("Join Friend", "Join", "Cancel", content,
 		func(ok bool) {
 			if !ok {
 				return
@@ -824,21 +1134,33 @@ func showJoinFriendDialog(window fyne.Window, friend friends_manager.Friend, pre
 
 			account := accounts[selectedIndex]
 
+			// Saved cookie: follow directly into the friend's server
+			if cookie_manager.HasSavedCookie(account.ID) {
+				launchJoinFriend(account, friend, presence, window)
+				return
+			}
+			if presence.PlaceID == 0 {
+				dialog.ShowInformation("Cannot Join",
+					fmt.Sprintf("%s's game isn't visible. Capture a cookie for %s to follow them directly.", friend.Username, account.Username),
+					window)
+				return
+			}
+
 			// Check browser account mismatch
-			browserUsername, _ := cookie_manager.GetCurrentBrowserCookieUsername()
+			browserUser, _ := cookie_manager.GetCurrentBrowserCookieUser()
 
-			if browserUsername != "" && !strings.EqualFold(browserUsername, account.Username) {
+			if browserUser != nil && !cookie_manager.UserMatchesAccount(browserUser, account) {
 				dialog.ShowConfirm("Account Mismatch",
 					fmt.Sprintf("Browser logged in as: %s\nYou selected: %s\n\nClear browser session to log in as %s?",
-						browserUsername, account.Username, account.Username),
+						browserUser.Username, account.Username, account.Username),
 					func(clearSession bool) {
 						if clearSession {
 							// Save current browser cookie before clearing
 							saveBrowserCookieBeforeClear()
-							cookie_manager.ClearVivaldiRobloxCookies()
-							logger.LogInfo("Cleared Vivaldi cookies for friend join")
+							cookie_manager.ClearBrowserRobloxCookies()
+							logger.LogInfo("Cleared browser cookies for friend join")
 						}
-						launchJoinFriendViaBrowser(presence.PlaceID, friend.UserID, window)
+						launchJoinFriendViaBrowser(presence.PlaceID, presence.GameID, friend.UserID, window)
 						if clearSession {
 							dialog.ShowInformation("Join Friend",
 								fmt.Sprintf("Browser cleared! Log in as %s, then click Play.", account.Username),
@@ -848,8 +1170,8 @@ func showJoinFriendDialog(window fyne.Window, friend friends_manager.Friend, pre
 				return
 			}
 
-			launchJoinFriendViaBrowser(presence.PlaceID, friend.UserID, window)
-			if browserUsername == "" {
+			launchJoinFriendViaBrowser(presence.PlaceID, presence.GameID, friend.UserID, window)
+			if browserUser == nil {
 				dialog.ShowInformation("Join Friend",
 					fmt.Sprintf("Opening game page! Log in as %s, then click Play.", account.Username),
 					window)
@@ -857,10 +1179,14 @@ func showJoinFriendDialog(window fyne.Window, friend friends_manager.Friend, pre
 		}, window)
 }
 
-func launchJoinFriendViaBrowser(placeID, userID int64, window fyne.Window) {
+func launchJoinFriendViaBrowser(placeID int64, jobID string, userID int64, window fyne.Window) {
 	// Open the game page directly - this will show the Play button and auto-detect friend
 	// Using the experiences URL which has better join functionality
 	gameURL := fmt.Sprintf("https://www.roblox.com/games/%d", placeID)
+	if jobID != "" {
+		// The start URL launches straight into the friend's server
+		gameURL = fmt.Sprintf("https://www.roblox.com/games/start?placeId=%d&gameInstanceId=%s", placeID, url.QueryEscape(jobID))
+	}
 	logger.LogInfo("Opening game page in browser: %s (friend userID: %d)", gameURL, userID)
 	exec.Command("open", gameURL).Start()
 }
@@ -873,57 +1199,52 @@ func saveBrowserCookieBeforeClear() {
 		return
 	}
 
-	// Convert to the format expected by SaveCurrentBrowserCookieToAccount
-	var accountList []struct{ ID, Username string }
-	for _, acc := range accounts {
-		accountList = append(accountList, struct{ ID, Username string }{acc.ID, acc.Username})
-	}
-
-	if err := cookie_manager.SaveCurrentBrowserCookieToAccount(accountList); err != nil {
+	if err := cookie_manager.SaveCurrentBrowserCookieToAccount(accounts); err != nil {
 		logger.LogError("Failed to save browser cookie before clear: %v", err)
 	}
 }
 
-func launchJoinFriend(placeID, followUserID int64, authTicket string, window fyne.Window) {
-	logger.LogInfo("Launching to join friend (placeID: %d, followUserID: %d)", placeID, followUserID)
+// joinFriend joins a friend in one click with the account last used for
+// them, falling back to asking which account to use
+func joinFriend(window fyne.Window, friend friends_manager.Friend, presence roblox_api.UserPresence) {
+	if friend.LastAccountUsed != "" && cookie_manager.HasSavedCookie(friend.LastAccountUsed) {
+		if account, err := account_manager.GetAccount(friend.LastAccountUsed); err == nil {
+			launchJoinFriend(*account, friend, presence, window)
+			return
+		}
+	}
+	showJoinFriendDialog(window, friend, presence)
+}
 
-	launchTime := fmt.Sprintf("%d", time.Now().UnixNano()/int64(time.Millisecond))
-	browserTrackerId := fmt.Sprintf("%d", time.Now().UnixNano()%1000000000)
+// launchJoinFriend follows a friend into their server with an account's
+// saved cookie, falling back to their place if the follow is rejected
+func launchJoinFriend(account account_manager.Account, friend friends_manager.Friend, presence roblox_api.UserPresence, window fyne.Window) {
+	logger.LogInfo("Launching %s to follow friend %s (placeID: %d)", account.Username, friend.Username, presence.PlaceID)
 
-	var protocolString string
-	if authTicket != "" {
-		// With auth ticket - use RequestGame with the place ID (more reliable than RequestFollowUser)
-		// The friend join happens automatically when joining the same server
-		protocolString = fmt.Sprintf("roblox-player:1+launchmode:play+gameinfo:%s+launchtime:%s+placelauncherurl:https://assetgame.roblox.com/game/PlaceLauncher.ashx?request=RequestGame&browserTrackerId=%s&placeId=%d&isPlayTogetherGame=false+browsertrackerid:%s+robloxLocale:en_us+gameLocale:en_us+channel:",
-			authTicket, launchTime, browserTrackerId, placeID, browserTrackerId)
-	} else {
-		// Without auth ticket - use roblox:// protocol
-		protocolString = fmt.Sprintf("roblox://placeId=%d", placeID)
+	if _, err := cookie_manager.PreLaunchCookieCheck(account); err != nil {
+		dialog.ShowError(fmt.Errorf("Cookie issue for %s:\n%v\n\nGo to Accounts tab to recapture.", account.Username, err), window)
+		return
 	}
 
-	// Check if Roblox is running for multi-instance
-	robloxApp := "/Applications/Roblox.app/Contents/MacOS/RobloxPlayer"
-
-	if authTicket != "" {
-		cmd := exec.Command(robloxApp, "-protocolString", protocolString)
-		if err := cmd.Start(); err != nil {
-			logger.LogError("Failed to launch join friend: %v", err)
-			dialog.ShowError(fmt.Errorf("Failed to join friend: %v", err), window)
-			return
-		}
-		logger.LogInfo("Launched join friend, PID: %d", cmd.Process.Pid)
-	} else {
-		cmd := exec.Command("open", protocolString)
-		if err := cmd.Run(); err != nil {
-			logger.LogError("Failed to launch join friend: %v", err)
-			dialog.ShowError(fmt.Errorf("Failed to join friend: %v", err), window)
-			return
-		}
+	request := launcher.Request{
+		Account:      account,
+		Preset:       preset_manager.Preset{Name: friend.Username, PlaceID: presence.PlaceID},
+		FollowUserID: friend.UserID,
 	}
+	queueLaunch(window, request, func(state launcher.JobState) {
+		friends_manager.UpdateFriendLastAccount(friend.UserID, account.ID)
 
-	dialog.ShowInformation("Joining Friend",
-		fmt.Sprintf("Launching Roblox to join your friend!"),
-		window)
+		if state.Follow == nil || state.Follow.Followed {
+			dialog.ShowInformation("Joining Friend",
+				fmt.Sprintf("Launching Roblox as %s into %s's server!", account.Username, friend.Username),
+				window)
+		} else {
+			dialog.ShowInformation("Joining Game",
+				fmt.Sprintf("Couldn't follow %s (%s).\n\nLaunching %s into their game instead - you may land in a different server.",
+					friend.Username, state.Follow.Reason, account.Username),
+				window)
+		}
+	})
 }
 
 func createAboutTab(window fyne.Window) fyne.CanvasObject {
@@ -938,7 +1259,7 @@ func createAboutTab(window fyne.Window) fyne.CanvasObject {
 
 Features:
 • 🎮 Multi-Instance: Run multiple Roblox windows at once
-• 🍪 Cookie Auth: Instant account switching via Vivaldi cookies
+• 🍪 Cookie Auth: Instant account switching via browser cookies (Chrome, Brave, Edge, Arc, Vivaldi, Firefox, Safari)
 • ✅ Cookie Validation: See which accounts are ready to launch
 • 📋 Presets: Quick-launch saved games with specific accounts
 • 🔐 Keychain Security: Cookies stored securely in macOS Keychain
@@ -946,7 +1267,7 @@ Features:
 
 How it works:
 1. Add accounts in Accounts tab
-2. Log into each account in Vivaldi browser
+2. Log into each account in your browser
 3. Click "Capture" to save the session cookie
 4. Use Presets or New Instance to launch with any account!`)
 	description.Wrapping = fyne.TextWrapWord
@@ -996,6 +1317,62 @@ How it works:
 		dialog.ShowCustom("Debug Log Options", "Close", dialogContent, window)
 	})
 
+	backupTitle := widget.NewLabel("Backup")
+	backupTitle.TextStyle = fyne.TextStyle{Bold: true}
+
+	backupInfo := widget.NewLabel("Export accounts, saved cookies, presets and friends to one encrypted file to move them to another Mac.")
+	backupInfo.Wrapping = fyne.TextWrapWord
+
+	exportButton := widget.NewButton("Export Vault...", func() {
+		showExportVaultDialog(window)
+	})
+	importButton := widget.NewButton("Import Vault...", func() {
+		showImportVaultDialog(window)
+	})
+
+	robloxTitle := widget.NewLabel("Roblox Installation")
+	robloxTitle.TextStyle = fyne.TextStyle{Bold: true}
+
+	robloxInstallLabel := widget.NewLabel("")
+	robloxInstallLabel.Wrapping = fyne.TextWrapWord
+	refreshRobloxInstall := func() {
+		install, err := roblox_install.Resolve()
+		if err != nil {
+			robloxInstallLabel.SetText("⚠️ Roblox not found. Install it or choose Roblox.app.")
+			return
+		}
+		source := "detected"
+		if install.Custom {
+			source = "custom"
+		}
+		robloxInstallLabel.SetText(fmt.Sprintf("%s\nVersion %s (%s)", install.Path, install.Version, source))
+	}
+	refreshRobloxInstall()
+
+	chooseRobloxButton := widget.NewButton("Choose Roblox.app...", func() {
+		dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if folder == nil {
+				return
+			}
+			if err := roblox_install.SetCustomPath(folder.Path()); err != nil {
+				dialog.ShowError(fmt.Errorf("Not a usable Roblox app: %v", err), window)
+				return
+			}
+			refreshRobloxInstall()
+		}, window)
+	})
+	detectRobloxButton := widget.NewButton("Auto-detect", func() {
+		if err := roblox_install.SetCustomPath(""); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		refreshRobloxInstall()
+	})
+
 	return container.NewVBox(
 		widget.NewSeparator(),
 		title,
@@ -1005,45 +1382,234 @@ How it works:
 		widget.NewSeparator(),
 		discordButton,
 		viewLogButton,
+		widget.NewSeparator(),
+		backupTitle,
+		backupInfo,
+		container.NewGridWithColumns(2, exportButton, importButton),
+		widget.NewSeparator(),
+		robloxTitle,
+		robloxInstallLabel,
+		container.NewGridWithColumns(2, chooseRobloxButton, detectRobloxButton),
 	)
 }
 
-func createAccountsTab(window fyne.Window) fyne.CanvasObject {
-	// Load accounts
-	accounts, _ := account_manager.LoadAccounts()
-	var accountList *widget.List
+// accountSecretServices are the secret store services holding per-account
+// data besides passwords; they're backed up and deleted with the account
+func accountSecretServices() []string {
+	return cookie_manager.SecretServices()
+}
 
-	// Detect current logged-in account from Vivaldi cookie
-	currentAccountLabel := widget.NewLabel("🔍 Detecting current account...")
-	currentAccountLabel.TextStyle = fyne.TextStyle{Bold: true}
+// showExportVaultDialog asks for a passphrase and saves an encrypted vault
+func showExportVaultDialog(window fyne.Window) {
+	passphraseEntry := widget.NewPasswordEntry()
+	confirmEntry := widget.NewPasswordEntry()
 
-	// Async detect current account from browser cookie
-	go func() {
-		browserUsername, err := cookie_manager.GetCurrentBrowserCookieUsername()
-		if err == nil && browserUsername != "" {
-			currentAccountLabel.SetText(fmt.Sprintf("🌐 Browser Session: %s", browserUsername))
-		} else {
-			// Fallback to session detection
-			sessionUsername, _ := roblox_session.GetCurrentUsername()
-			if sessionUsername != "" {
-				currentAccountLabel.SetText(fmt.Sprintf("🎮 Active Session: %s", sessionUsername))
-			} else {
-				currentAccountLabel.SetText("⚠️ No active session detected")
-			}
+	formItems := []*widget.FormItem{
+		widget.NewFormItem("Passphrase", passphraseEntry),
+		widget.NewFormItem("Confirm", confirmEntry),
+	}
+
+	dialog.ShowForm("Export Vault", "Next", "Cancel", formItems, func(ok bool) {
+		if !ok {
+			return
+		}
+		if passphraseEntry.Text == "" {
+			dialog.ShowError(fmt.Errorf("a passphrase is required"), window)
+			return
+		}
+		if passphraseEntry.Text != confirmEntry.Text {
+			dialog.ShowError(fmt.Errorf("passphrases don't match"), window)
+			return
 		}
-	}()
 
-	// Cookie status label showing valid cookies
-	cookieStatusLabel := widget.NewLabel("🍪 Cookie-based account switching available")
-	cookieStatusLabel.TextStyle = fyne.TextStyle{Italic: true}
+		data, err := account_manager.ExportVault(passphraseEntry.Text, accountSecretServices())
+		if err != nil {
+			logger.LogError("Vault export failed: %v", err)
+			dialog.ShowError(fmt.Errorf("Export failed: %w", err), window)
+			return
+		}
 
-	// Count valid cookies
-	go func() {
-		validCount := 0
-		for _, acc := range accounts {
-			if cookie_manager.HasSavedCookie(acc.ID) {
-				// Verify cookie is still valid
-				cookie, err := cookie_manager.GetCookieForAccount(acc.ID)
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if _, err := writer.Write(data); err != nil {
+				dialog.ShowError(fmt.Errorf("Failed to write vault: %w", err), window)
+				return
+			}
+			logger.LogInfo("Vault exported to %s", writer.URI().Path())
+			dialog.ShowInformation("Vault Exported", "Keep the passphrase safe — the vault can't be opened without it.", window)
+		}, window)
+		saveDialog.SetFileName(fmt.Sprintf("multi_roblox_vault_%s.json", time.Now().Format("2006-01-02")))
+		saveDialog.Show()
+	}, window)
+}
+
+// showImportVaultDialog opens a vault, shows what would change and applies
+// the chosen per-account modes
+func showImportVaultDialog(window fyne.Window) {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("Failed to read vault: %w", err), window)
+			return
+		}
+
+		passphraseEntry := widget.NewPasswordEntry()
+		formItems := []*widget.FormItem{
+			widget.NewFormItem("Passphrase", passphraseEntry),
+		}
+		dialog.ShowForm("Import Vault", "Open", "Cancel", formItems, func(ok bool) {
+			if !ok {
+				return
+			}
+			vault, err := account_manager.OpenVault(data, passphraseEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			plan, err := account_manager.PlanImport(vault, accountSecretServices())
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			showImportPlanDialog(window, vault, plan)
+		}, window)
+	}, window)
+}
+
+// showImportPlanDialog shows the dry-run diff of a vault import
+func showImportPlanDialog(window fyne.Window, vault *account_manager.Vault, plan *account_manager.ImportPlan) {
+	const (
+		modeSkip    = "Skip"
+		modeMerge   = "Merge"
+		modeReplace = "Replace"
+	)
+
+	rows := container.NewVBox()
+	selects := make(map[string]*widget.Select)
+	for _, change := range plan.Accounts {
+		var summary string
+		switch {
+		case change.IsNew():
+			summary = fmt.Sprintf("➕ %s (new)", change.Vault.Username)
+		case len(change.Changes) == 0:
+			summary = fmt.Sprintf("✅ %s (unchanged)", change.Vault.Username)
+		default:
+			summary = fmt.Sprintf("✏️ %s: %s", change.Vault.Username, strings.Join(change.Changes, ", "))
+		}
+		summaryLabel := widget.NewLabel(summary)
+		summaryLabel.Wrapping = fyne.TextWrapWord
+
+		modeSelect := widget.NewSelect([]string{modeSkip, modeMerge, modeReplace}, nil)
+		if change.IsNew() {
+			modeSelect.SetSelected(modeMerge)
+		} else {
+			modeSelect.SetSelected(modeSkip)
+		}
+		selects[change.Vault.ID] = modeSelect
+
+		rows.Add(container.NewBorder(nil, nil, nil, modeSelect, summaryLabel))
+	}
+	for _, acc := range plan.LocalOnly {
+		rows.Add(widget.NewLabel(fmt.Sprintf("• %s (only on this Mac, kept)", acc.Username)))
+	}
+
+	replaceFilesCheck := widget.NewCheck("Replace presets, friends and labels", nil)
+	if len(plan.ChangedFiles) == 0 {
+		replaceFilesCheck.Disable()
+	} else {
+		replaceFilesCheck.SetText(fmt.Sprintf("Replace %s", strings.Join(plan.ChangedFiles, ", ")))
+	}
+
+	header := widget.NewLabel(fmt.Sprintf("Vault from %s. Merge keeps existing values and only fills in what's missing; Replace makes them match the vault, removing secrets it doesn't have.",
+		vault.CreatedAt.Local().Format("Jan 2, 2006 15:04")))
+	header.Wrapping = fyne.TextWrapWord
+
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(500, 300))
+
+	content := container.NewBorder(header, replaceFilesCheck, nil, nil, scroll)
+
+	dialog.ShowCustomConfirm("Import Vault", "Import", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		opts := account_manager.ImportOptions{
+			Modes:          make(map[string]account_manager.ImportMode),
+			ReplaceFiles:   replaceFilesCheck.Checked,
+			SecretServices: accountSecretServices(),
+		}
+		for id, modeSelect := range selects {
+			switch modeSelect.Selected {
+			case modeMerge:
+				opts.Modes[id] = account_manager.ImportMerge
+			case modeReplace:
+				opts.Modes[id] = account_manager.ImportReplace
+			}
+		}
+
+		if err := account_manager.ApplyImport(vault, opts, preset_manager.RekeyAccount, friends_manager.RekeyAccount); err != nil {
+			logger.LogError("Vault import failed: %v", err)
+			dialog.ShowError(fmt.Errorf("Import failed: %w", err), window)
+			return
+		}
+		reloadAllData()
+		dialog.ShowInformation("Vault Imported", "The imported accounts and presets are ready to use.", window)
+	}, window)
+}
+
+func createAccountsTab(window fyne.Window) fyne.CanvasObject {
+	// Load accounts
+	accounts, _ := account_manager.LoadAccounts()
+	var accountList *widget.List
+
+	// Detect current logged-in account from the selected browser's cookie
+	currentAccountLabel := widget.NewLabel("🔍 Detecting current account...")
+	currentAccountLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	// Async detect current account from browser cookie
+	go func() {
+		browserUsername, err := cookie_manager.GetCurrentBrowserCookieUsername()
+		if err == nil && browserUsername != "" {
+			currentAccountLabel.SetText(fmt.Sprintf("🌐 Browser Session: %s", browserUsername))
+		} else {
+			// Fallback to session detection
+			sessionUsername, _ := roblox_session.GetCurrentUsername()
+			if sessionUsername != "" {
+				currentAccountLabel.SetText(fmt.Sprintf("🎮 Active Session: %s", sessionUsername))
+			} else {
+				currentAccountLabel.SetText("⚠️ No active session detected")
+			}
+		}
+	}()
+
+	// Cookie status label showing valid cookies
+	cookieStatusLabel := widget.NewLabel("🍪 Cookie-based account switching available")
+	cookieStatusLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	// Count valid cookies
+	go func() {
+		validCount := 0
+		for _, acc := range accounts {
+			if cookie_manager.HasSavedCookie(acc.ID) {
+				// Verify cookie is still valid
+				cookie, err := cookie_manager.GetCookieForAccount(acc.ID)
 				if err == nil {
 					if _, verifyErr := cookie_manager.VerifyCookieUsername(cookie.Value); verifyErr == nil {
 						validCount++
@@ -1063,8 +1629,8 @@ func createAccountsTab(window fyne.Window) fyne.CanvasObject {
 	var cacheMutex sync.Mutex
 
 	// Background validation of all cookies
-	go func() {
-		for _, acc := range accounts {
+	validateCookies := func(list []account_manager.Account) {
+		for _, acc := range list {
 			result := cookie_manager.ValidateCookieForAccount(acc.ID)
 			cacheMutex.Lock()
 			cookieStatusCache[acc.ID] = result
@@ -1072,7 +1638,8 @@ func createAccountsTab(window fyne.Window) fyne.CanvasObject {
 		}
 		// Refresh list after validation completes
 		accountList.Refresh()
-	}()
+	}
+	go validateCookies(accounts)
 
 	// Account list with status indicators
 	accountList = widget.NewList(
@@ -1168,8 +1735,7 @@ func createAccountsTab(window fyne.Window) fyne.CanvasObject {
 					fmt.Sprintf("Delete account '%s'?\n\nPassword and saved cookie will be removed.", account.Username),
 					func(yes bool) {
 						if yes {
-							account_manager.DeleteAccount(account.ID)
-							cookie_manager.ClearSavedCookie(account.ID)
+							account_manager.DeleteAccount(account.ID, accountSecretServices()...)
 							cacheMutex.Lock()
 							delete(cookieStatusCache, account.ID)
 							cacheMutex.Unlock()
@@ -1215,7 +1781,14 @@ func createAccountsTab(window fyne.Window) fyne.CanvasObject {
 		}, window)
 	})
 
-	infoLabel := widget.NewLabel("🍪 Cookie Method: Log into Roblox in Vivaldi, then click 'Capture' to save the session.\n\n✅ = Valid cookie (ready to switch)  ❌ = Expired (recapture needed)  ⚪ = No cookie")
+	// Imported accounts may bring cookies, so they're validated again
+	dataReloaders = append(dataReloaders, func() {
+		accounts, _ = account_manager.LoadAccounts()
+		accountList.Refresh()
+		go validateCookies(accounts)
+	})
+
+	infoLabel := widget.NewLabel("🍪 Cookie Method: Log into Roblox in your browser, then click 'Capture' to save the session.\n\n✅ = Valid cookie (ready to switch)  ❌ = Expired (recapture needed)  ⚪ = No cookie")
 	infoLabel.Wrapping = fyne.TextWrapWord
 
 	// Layout
@@ -1247,8 +1820,8 @@ func showCaptureDialog(window fyne.Window, account account_manager.Account, refr
 	instructionsText := fmt.Sprintf(`🍪 Capture Cookie for: %s
 
 Steps:
-1. Make sure Vivaldi is open
-2. Log into Roblox as %s
+1. Pick the browser and profile you use for Roblox
+2. Log into Roblox there as %s
 3. Click 'Capture Now' below
 
 The cookie will be saved securely in your Keychain.
@@ -1257,7 +1830,57 @@ You can then switch to this account instantly when launching games!`, displayNam
 	instructionsLabel := widget.NewLabel(instructionsText)
 	instructionsLabel.Wrapping = fyne.TextWrapWord
 
-	content := container.NewVBox(instructionsLabel)
+	// Browser and profile pickers, starting from the last selection
+	selection := cookie_manager.GetBrowserSelection()
+	var profiles []browser_source.Profile
+
+	profileSelect := widget.NewSelect(nil, nil)
+	loadProfiles := func(browserName string) {
+		profiles = nil
+		profileSelect.Options = nil
+		profileSelect.ClearSelected()
+
+		if source, err := cookie_manager.GetBrowser(browserName); err == nil {
+			profiles, _ = source.Profiles()
+		}
+		for _, p := range profiles {
+			profileSelect.Options = append(profileSelect.Options, p.Name)
+		}
+		profileSelect.Refresh()
+
+		for _, p := range profiles {
+			if p.Path == selection.Profile {
+				profileSelect.SetSelected(p.Name)
+				return
+			}
+		}
+		if len(profiles) > 0 {
+			profileSelect.SetSelectedIndex(0)
+		}
+	}
+
+	var browserNames []string
+	for _, source := range browser_source.All() {
+		browserNames = append(browserNames, source.Name())
+	}
+	browserSelect := widget.NewSelect(browserNames, loadProfiles)
+	browserSelect.SetSelected(selection.Browser)
+
+	selectedProfile := func() (browser_source.Profile, bool) {
+		index := profileSelect.SelectedIndex()
+		if index < 0 || index >= len(profiles) {
+			return browser_source.Profile{}, false
+		}
+		return profiles[index], true
+	}
+
+	content := container.NewVBox(
+		instructionsLabel,
+		widget.NewForm(
+			widget.NewFormItem("Browser", browserSelect),
+			widget.NewFormItem("Profile", profileSelect),
+		),
+	)
 
 	customDialog := dialog.NewCustomWithoutButtons("Capture Cookie", content, window)
 
@@ -1283,28 +1906,43 @@ You can then switch to this account instantly when launching games!`, displayNam
 	captureBtn := widget.NewButton("Capture Now", func() {
 		logger.LogInfo("Attempting to capture cookie for account: %s", account.Username)
 
-		// Read current cookie from Vivaldi
-		cookie, err := cookie_manager.GetCurrentRobloxCookie()
+		source, err := cookie_manager.GetBrowser(browserSelect.Selected)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("Pick a browser to capture from"), window)
+			return
+		}
+		profile, ok := selectedProfile()
+		if !ok {
+			dialog.ShowError(fmt.Errorf("No %s profile found.\n\nOpen %s and log into Roblox first.", source.Name(), source.Name()), window)
+			return
+		}
+
+		// Remember the choice for auto-refresh and account switching
+		if err := cookie_manager.SetBrowserSelection(cookie_manager.BrowserSelection{Browser: source.Name(), Profile: profile.Path}); err != nil {
+			logger.LogError("Failed to save browser selection: %v", err)
+		}
+
+		cookie, err := cookie_manager.GetRobloxCookieFrom(source, profile)
 		if err != nil {
 			logger.LogError("Failed to capture cookie: %v", err)
-			dialog.ShowError(fmt.Errorf("Failed to capture cookie:\n%v\n\nMake sure you're logged into Roblox in Vivaldi.", err), window)
+			dialog.ShowError(fmt.Errorf("Failed to capture cookie:\n%v\n\nMake sure you're logged into Roblox in %s.", err, source.Name()), window)
 			return
 		}
 
 		// Verify the cookie belongs to the expected account
-		verifiedUsername, verifyErr := cookie_manager.VerifyCookieUsername(cookie.Value)
+		verifiedUser, verifyErr := cookie_manager.VerifyCookieUser(cookie.Value)
 		if verifyErr != nil {
 			logger.LogError("Failed to verify cookie: %v", verifyErr)
 			dialog.ShowError(fmt.Errorf("Failed to verify cookie:\n%v", verifyErr), window)
 			return
 		}
 
-		logger.LogInfo("Cookie verified - belongs to: %s", verifiedUsername)
+		logger.LogInfo("Cookie verified - belongs to: %s (user ID %d)", verifiedUser.Username, verifiedUser.UserID)
 
-		// Check if username matches (case-insensitive)
-		if !strings.EqualFold(verifiedUsername, account.Username) {
+		// Check the cookie's user ID against the account's
+		if !cookie_manager.UserMatchesAccount(verifiedUser, account) {
 			dialog.ShowConfirm("Username Mismatch",
-				fmt.Sprintf("⚠️ Cookie belongs to: %s\nExpected: %s\n\nSave anyway?", verifiedUsername, account.Username),
+				fmt.Sprintf("⚠️ Cookie belongs to: %s\nExpected: %s\n\nSave anyway?", verifiedUser.Username, account.Username),
 				func(saveAnyway bool) {
 					if saveAnyway {
 						saveCookieAndNotify(cookie, account, displayName, customDialog, window, refreshCallback)
@@ -1313,6 +1951,11 @@ You can then switch to this account instantly when launching games!`, displayNam
 			return
 		}
 
+		// Bind the account to this user ID and pick up any username change
+		if err := account_manager.SyncAccountIdentity(account.ID, verifiedUser.UserID, verifiedUser.Username); err != nil {
+			logger.LogError("Failed to sync account identity: %v", err)
+		}
+
 		saveCookieAndNotify(cookie, account, displayName, customDialog, window, refreshCallback)
 	})
 
@@ -1320,13 +1963,17 @@ You can then switch to this account instantly when launching games!`, displayNam
 		customDialog.Hide()
 	})
 
-	openVivaldiBtn := widget.NewButton("Open Roblox Login", func() {
-		exec.Command("open", "-a", "Vivaldi", "https://www.roblox.com/login").Start()
+	openLoginBtn := widget.NewButton("Open Roblox Login", func() {
+		source, err := cookie_manager.GetBrowser(browserSelect.Selected)
+		if err != nil {
+			return
+		}
+		exec.Command("open", "-a", source.AppName(), "https://www.roblox.com/login").Start()
 	})
 
 	buttonBox := container.NewHBox(
 		cancelBtn,
-		openVivaldiBtn,
+		openLoginBtn,
 		captureBtn,
 	)
 
@@ -1381,10 +2028,14 @@ func showLabelDialog(window fyne.Window, pid int, refreshCallback func()) {
 				colorValue = colorMap[selected]
 			}
 
+			var err error
 			if labelEntry.Text != "" {
-				label_manager.SetLabel(pid, labelEntry.Text, colorValue)
-			} else if labelEntry.Text == "" && colorValue == "" {
-				label_manager.DeleteLabel(pid)
+				err = instance_manager.Default().SetLabel(pid, labelEntry.Text, colorValue)
+			} else if colorValue == "" {
+				err = instance_manager.Default().SetLabel(pid, "", "")
+			}
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to save label: %w", err), window)
 			}
 
 			refreshCallback()
@@ -1392,6 +2043,109 @@ func showLabelDialog(window fyne.Window, pid int, refreshCallback func()) {
 	}, window)
 }
 
+// showKeepAliveDialog sets or clears the policy that relaunches an instance
+// when it crashes or is disconnected
+func showKeepAliveDialog(window fyne.Window, pid int, refreshCallback func()) {
+	tracked, found := instance_account_tracker.GetInstance(pid)
+	if !found {
+		dialog.ShowInformation("Keep Alive", "This instance isn't tracked to an account, so it can't be relaunched.", window)
+		return
+	}
+
+	presets, _ := preset_manager.LoadPresets()
+	var playable []preset_manager.Preset
+	var presetNames []string
+	for _, p := range presets {
+		if p.PlaceID > 0 {
+			playable = append(playable, p)
+			presetNames = append(presetNames, p.Name)
+		}
+	}
+	if len(playable) == 0 {
+		dialog.ShowInformation("Keep Alive", "Add a preset for the game to keep this instance in first.", window)
+		return
+	}
+
+	presetSelect := widget.NewSelect(presetNames, nil)
+	retriesEntry := widget.NewEntry()
+	backoffEntry := widget.NewEntry()
+
+	policy := tracked.KeepAlive
+	if policy == nil {
+		policy = &instance_account_tracker.KeepAlivePolicy{MaxRetries: 5, BackoffSeconds: 30}
+		for _, p := range playable {
+			if p.PlaceID == tracked.PlaceID {
+				policy.PresetName = p.Name
+				break
+			}
+		}
+	}
+	if policy.PresetName != "" {
+		presetSelect.SetSelected(policy.PresetName)
+	} else {
+		presetSelect.SetSelectedIndex(0)
+	}
+	retriesEntry.SetText(strconv.Itoa(policy.MaxRetries))
+	backoffEntry.SetText(strconv.FormatFloat(policy.BackoffSeconds, 'f', -1, 64))
+
+	formItems := []*widget.FormItem{
+		widget.NewFormItem("Rejoin Preset", presetSelect),
+		widget.NewFormItem("Max Retries", retriesEntry),
+		widget.NewFormItem("Backoff (seconds)", backoffEntry),
+	}
+
+	var form dialog.Dialog
+	save := func() {
+		maxRetries, err := strconv.Atoi(strings.TrimSpace(retriesEntry.Text))
+		if err != nil || maxRetries < 1 {
+			dialog.ShowError(fmt.Errorf("max retries must be a whole number of at least 1"), window)
+			return
+		}
+		backoff, err := strconv.ParseFloat(strings.TrimSpace(backoffEntry.Text), 64)
+		if err != nil || backoff < 0 {
+			dialog.ShowError(fmt.Errorf("backoff must be a number of seconds"), window)
+			return
+		}
+		preset := playable[max(presetSelect.SelectedIndex(), 0)]
+
+		err = instance_account_tracker.SetKeepAlive(pid, &instance_account_tracker.KeepAlivePolicy{
+			PresetName:     preset.Name,
+			PlaceID:        preset.PlaceID,
+			MaxRetries:     maxRetries,
+			BackoffSeconds: backoff,
+		})
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save keep-alive: %w", err), window)
+			return
+		}
+		logger.LogInfo("Keep-alive enabled for PID %d: %s, %d retries, %.0fs backoff", pid, preset.Name, maxRetries, backoff)
+		form.Hide()
+		refreshCallback()
+	}
+
+	buttons := []fyne.CanvasObject{widget.NewButton("Save", save)}
+	if tracked.KeepAlive != nil {
+		buttons = append(buttons, widget.NewButton("Turn Off", func() {
+			if err := instance_account_tracker.SetKeepAlive(pid, nil); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			logger.LogInfo("Keep-alive disabled for PID %d", pid)
+			form.Hide()
+			refreshCallback()
+		}))
+	}
+	buttons = append(buttons, widget.NewButton("Cancel", func() { form.Hide() }))
+
+	content := container.NewVBox(
+		widget.NewLabel("Relaunch this instance with the same account if it crashes or is disconnected."),
+		widget.NewForm(formItems...),
+		container.NewHBox(buttons...),
+	)
+	form = dialog.NewCustomWithoutButtons("Keep Alive", content, window)
+	form.Show()
+}
+
 // parseHexColor converts a hex color string to color.Color
 func parseHexColor(s string) (color.Color, error) {
 	c := color.NRGBA{R: 0, G: 0, B: 0, A: 255}
@@ -1527,42 +2281,26 @@ func showAccountSelectionDialog(window fyne.Window, launchCallback func()) {
 				}
 
 				// Check if Roblox is already running
-				instances, _ := instance_manager.GetRunningInstances()
-				isRobloxRunning := len(instances) > 0
+				isRobloxRunning := instance_manager.Default().Count() > 0
 
 				if isRobloxRunning {
 					// MULTI-INSTANCE: Use auth ticket approach to avoid kicking existing sessions
 					// Auth tickets are passed via URL and don't touch shared cookie storage
 					logger.LogInfo("Roblox already running - using auth ticket approach to preserve existing sessions")
 
-					authTicket, err := cookie_manager.GetAuthTicket(cookie.Value)
-					if err != nil {
-						dialog.ShowError(fmt.Errorf("Failed to get auth ticket: %v\n\nTry using the Presets tab to launch a specific game.", err), window)
-						return
-					}
-
 					// Use Roblox Hub (PlaceID 10275826693) as a safe landing spot
 					// This is Roblox's official hub experience
 					hubPreset := preset_manager.Preset{
 						Name:    "Roblox Hub",
 						PlaceID: 10275826693,
 					}
-					pid, err := preset_manager.LaunchPresetWithTicket(hubPreset, authTicket)
-					if err != nil {
-						dialog.ShowError(fmt.Errorf("Failed to launch: %v", err), window)
-						return
-					}
-
-					if pid > 0 {
-						instance_account_tracker.TrackInstance(pid, account.ID)
-						logger.LogInfo("Tracked instance PID %d with account %s", pid, account.Username)
-					}
-
+					queueLaunch(window, launcher.Request{Account: account, Preset: hubPreset}, func(launcher.JobState) {
+						launchCallback()
+					})
 					customDialog.Hide()
-					launchCallback()
 
 					dialog.ShowInformation("Instance Launched",
-						fmt.Sprintf("Launched new instance as %s!\n\nOpening Roblox Hub. Your other instances are preserved.", account.Username),
+						fmt.Sprintf("Queued a new instance as %s!\n\nOpening Roblox Hub. Your other instances are preserved.", account.Username),
 						window)
 				} else {
 					// FIRST INSTANCE: Safe to write cookie to shared storage
@@ -1593,7 +2331,7 @@ func showAccountSelectionDialog(window fyne.Window, launchCallback func()) {
 			} else if result.Status == cookie_manager.CookieStatusExpired {
 				dialog.ShowError(fmt.Errorf("Cookie for %s has expired!\n\nGo to Accounts tab and click 'Recapture' to refresh it.", account.Username), window)
 			} else {
-				dialog.ShowError(fmt.Errorf("No cookie for %s!\n\nGo to Accounts tab, log into Roblox as this user in Vivaldi, then click 'Capture'.", account.Username), window)
+				dialog.ShowError(fmt.Errorf("No cookie for %s!\n\nGo to Accounts tab, log into Roblox as this user in your browser, then click 'Capture'.", account.Username), window)
 			}
 		}
 	})
@@ -1639,6 +2377,14 @@ func showPresetSettingsDialog(window fyne.Window, preset preset_manager.Preset,
 		currentStatus.SetText("No private server configured")
 	}
 
+	// Pinned public server entry
+	jobIDEntry := widget.NewEntry()
+	jobIDEntry.SetPlaceHolder("Paste a server link or job ID...")
+	jobIDEntry.SetText(preset.JobID)
+
+	jobIDInfo := widget.NewLabel("Pin a public server to always join that exact server (ignored when a private server is set).")
+	jobIDInfo.Wrapping = fyne.TextWrapWord
+
 	content := container.NewVBox(
 		widget.NewLabel("Private Server"),
 		widget.NewSeparator(),
@@ -1646,6 +2392,11 @@ func showPresetSettingsDialog(window fyne.Window, preset preset_manager.Preset,
 		privateServerEntry,
 		currentStatus,
 		widget.NewSeparator(),
+		widget.NewLabel("Pinned Server"),
+		widget.NewSeparator(),
+		jobIDInfo,
+		jobIDEntry,
+		widget.NewSeparator(),
 	)
 
 	dialog.ShowCustomConfirm("Preset Settings: "+preset.Name, "Save", "Cancel", content,
@@ -1662,6 +2413,18 @@ func showPresetSettingsDialog(window fyne.Window, preset preset_manager.Preset,
 				linkCode = ""
 			}
 
+			jobID := preset_manager.ExtractJobID(jobIDEntry.Text)
+			if jobIDEntry.Text != "" && jobID == "" {
+				dialog.ShowError(fmt.Errorf("Couldn't find a job ID in %q", jobIDEntry.Text), window)
+				return
+			}
+			if jobID != preset.JobID {
+				if err := preset_manager.UpdatePresetJobID(presetIndex, jobID); err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+			}
+
 			if err := preset_manager.UpdatePresetPrivateServer(presetIndex, linkCode); err != nil {
 				dialog.ShowError(err, window)
 			} else {
@@ -1669,6 +2432,10 @@ func showPresetSettingsDialog(window fyne.Window, preset preset_manager.Preset,
 					dialog.ShowInformation("Saved",
 						"Private server link saved! When you launch this preset with an account, it will join the private server.",
 						window)
+				} else if jobID != "" {
+					dialog.ShowInformation("Saved",
+						"Server pinned! Launches will join that server while it's running.",
+						window)
 				} else {
 					dialog.ShowInformation("Cleared",
 						"Private server link cleared. Launches will go to the public server.",
@@ -1679,6 +2446,386 @@ func showPresetSettingsDialog(window fyne.Window, preset preset_manager.Preset,
 		}, window)
 }
 
+// queueLaunch queues an account launch on the launcher and shows an error
+// if it fails. onLaunched, if set, runs once the instance has started.
+func queueLaunch(window fyne.Window, req launcher.Request, onLaunched func(state launcher.JobState)) {
+	job := launcher.Submit(req)
+	go func() {
+		state := job.Wait()
+		if state.Status == launcher.StatusFailed {
+			dialog.ShowError(fmt.Errorf("Failed to launch %s:\n%v", req.Account.Username, state.Err), window)
+			return
+		}
+		if onLaunched != nil {
+			onLaunched(state)
+		}
+	}()
+}
+
+// launchPresetVerified launches a preset through the app's current session
+// and verifies the new instance in the background. With an account, the
+// instance is tracked as that account once its PID shows up and presence
+// confirms the join.
+func launchPresetVerified(preset preset_manager.Preset, account *account_manager.Account) error {
+	known, err := launch_verifier.RunningPIDs()
+	if err != nil {
+		logger.LogError("Failed to list Roblox instances before launch: %v", err)
+	}
+	launchedAt := time.Now()
+	if err := preset_manager.LaunchPreset(preset); err != nil {
+		return err
+	}
+
+	target := launch_verifier.Target{KnownPIDs: known, PlaceID: preset.PlaceID, LaunchedAt: launchedAt}
+	if account != nil {
+		target.UserID = account.UserID
+		if cookie, err := cookie_manager.GetCookieForAccount(account.ID); err == nil {
+			target.Cookie = cookie.Value
+		}
+		target.OnPID = func(pid int) {
+			instance_account_tracker.TrackLaunch(pid, account.ID, preset.PlaceID, preset.JobID)
+		}
+	}
+	go func() {
+		outcome := launch_verifier.Verify(target, launch_verifier.Options{})
+		if account != nil && outcome.PID > 0 && outcome.LogPath != "" {
+			instance_account_tracker.SetLogPath(outcome.PID, outcome.LogPath)
+		}
+	}()
+	return nil
+}
+
+// serverBrowserMaxPages caps how many pages of 100 servers the browser loads
+const serverBrowserMaxPages = 5
+
+// showServerBrowserDialog lists a preset's public servers and launches an
+// account into the chosen one
+func showServerBrowserDialog(window fyne.Window, preset preset_manager.Preset, presetIndex int) {
+	logger.LogInfo("Opening server browser for preset: %s (place %d)", preset.Name, preset.PlaceID)
+
+	accounts, _ := account_manager.LoadAccounts()
+	var accountOptions []string
+	for _, acc := range accounts {
+		displayText := acc.Username
+		if acc.Label != "" {
+			displayText = fmt.Sprintf("%s (%s)", acc.Label, acc.Username)
+		}
+		accountOptions = append(accountOptions, displayText)
+	}
+	accountSelect := widget.NewSelect(accountOptions, nil)
+	for i, acc := range accounts {
+		if acc.ID == preset.LastAccountUsed || i == 0 {
+			accountSelect.SetSelected(accountOptions[i])
+		}
+	}
+
+	// Loads finish on their own goroutines; loadGen lets only the latest one
+	// replace the list
+	var serversMu sync.Mutex
+	var allServers, shownServers []roblox_api.GameServer
+	selected := -1
+	loadGen := 0
+	shownServer := func(id int) (roblox_api.GameServer, bool) {
+		serversMu.Lock()
+		defer serversMu.Unlock()
+		if id < 0 || id >= len(shownServers) {
+			return roblox_api.GameServer{}, false
+		}
+		return shownServers[id], true
+	}
+
+	statusLabel := widget.NewLabel("Loading servers...")
+
+	sortOptions := []string{}
+	for _, order := range []roblox_api.ServerSort{roblox_api.SortMostEmpty, roblox_api.SortFullest, roblox_api.SortLowestPing, roblox_api.SortHighestFPS} {
+		sortOptions = append(sortOptions, order.String())
+	}
+	sortSelect := widget.NewSelect(sortOptions, nil)
+
+	maxPlayersEntry := widget.NewEntry()
+	maxPlayersEntry.SetPlaceHolder("Max players (0 = empty)")
+	maxPingEntry := widget.NewEntry()
+	maxPingEntry.SetPlaceHolder("Max ping (ms)")
+	hideFullCheck := widget.NewCheck("Hide full", nil)
+	hideFullCheck.SetChecked(true)
+
+	serverList := widget.NewList(
+		func() int {
+			serversMu.Lock()
+			defer serversMu.Unlock()
+			return len(shownServers)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("👥 00/00  📶 000 ms  🎞 00 fps  00000000")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			server, ok := shownServer(id)
+			if !ok {
+				return
+			}
+			obj.(*widget.Label).SetText(fmt.Sprintf("👥 %d/%d  📶 %d ms  🎞 %.0f fps  %s",
+				server.Playing, server.MaxPlayers, server.Ping, server.FPS, server.JobID[:min(8, len(server.JobID))]))
+		},
+	)
+	serverList.OnSelected = func(id widget.ListItemID) {
+		serversMu.Lock()
+		selected = id
+		serversMu.Unlock()
+	}
+
+	applyView := func() {
+		filter := roblox_api.ServerFilter{}
+		if hideFullCheck.Checked {
+			filter.MinFreeSlots = 1
+		}
+		if maxPlayers, err := strconv.Atoi(strings.TrimSpace(maxPlayersEntry.Text)); err == nil {
+			filter.MaxPlayers = &maxPlayers
+		}
+		filter.MaxPing, _ = strconv.Atoi(strings.TrimSpace(maxPingEntry.Text))
+		order := roblox_api.ServerSort(0)
+		for i, opt := range sortOptions {
+			if opt == sortSelect.Selected {
+				order = roblox_api.ServerSort(i)
+			}
+		}
+
+		serversMu.Lock()
+		shownServers = roblox_api.FilterServers(allServers, filter)
+		roblox_api.SortServers(shownServers, order)
+		selected = -1
+		loaded, shown, total := allServers != nil, len(shownServers), len(allServers)
+		serversMu.Unlock()
+
+		serverList.UnselectAll()
+		serverList.Refresh()
+		if loaded {
+			statusLabel.SetText(fmt.Sprintf("Showing %d of %d servers", shown, total))
+		}
+	}
+	sortSelect.OnChanged = func(string) { applyView() }
+	maxPlayersEntry.OnChanged = func(string) { applyView() }
+	maxPingEntry.OnChanged = func(string) { applyView() }
+	hideFullCheck.OnChanged = func(bool) { applyView() }
+	sortSelect.SetSelected(sortOptions[0])
+
+	loadServers := func() {
+		serversMu.Lock()
+		loadGen++
+		gen := loadGen
+		serversMu.Unlock()
+
+		statusLabel.SetText("Loading servers...")
+		go func() {
+			servers, err := roblox_api.ListPublicServers(preset.PlaceID, serverBrowserMaxPages)
+			if err != nil {
+				logger.LogError("Failed to list servers for place %d: %v", preset.PlaceID, err)
+			}
+
+			// A newer refresh was started; its result wins
+			serversMu.Lock()
+			if gen != loadGen {
+				serversMu.Unlock()
+				return
+			}
+			failed := len(servers) == 0 && err != nil
+			if !failed {
+				// Never nil once loaded, even with no servers
+				allServers = append([]roblox_api.GameServer{}, servers...)
+			}
+			serversMu.Unlock()
+
+			if failed {
+				statusLabel.SetText(fmt.Sprintf("⚠️ Failed to load servers: %v", err))
+				return
+			}
+			applyView()
+		}()
+	}
+	refreshBtn := widget.NewButton("🔄 Refresh", loadServers)
+
+	launchBtn := widget.NewButton("🚀 Launch Into Server", func() {
+		serversMu.Lock()
+		id := selected
+		serversMu.Unlock()
+		server, ok := shownServer(id)
+		if !ok {
+			dialog.ShowInformation("Select Server", "Please select a server from the list first.", window)
+			return
+		}
+		accountIndex := -1
+		for i, opt := range accountOptions {
+			if opt == accountSelect.Selected {
+				accountIndex = i
+			}
+		}
+		if accountIndex < 0 {
+			dialog.ShowInformation("Select Account", "Please select an account first.", window)
+			return
+		}
+
+		account := accounts[accountIndex]
+
+		serverPreset := preset
+		serverPreset.PrivateServerLinkCode = ""
+		serverPreset.JobID = server.JobID
+
+		logger.LogInfo("Launching %s into server %s of %s", account.Username, server.JobID, preset.Name)
+		queueLaunch(window, launcher.Request{Account: account, Preset: serverPreset}, func(launcher.JobState) {
+			preset_manager.UpdatePresetLastAccount(presetIndex, account.ID)
+		})
+
+		dialog.ShowInformation("Launching",
+			fmt.Sprintf("Queued %s to join server %s (%d/%d players).", account.Username, server.JobID[:min(8, len(server.JobID))], server.Playing, server.MaxPlayers),
+			window)
+	})
+	launchBtn.Importance = widget.HighImportance
+
+	controls := container.NewVBox(
+		container.NewGridWithColumns(2, widget.NewLabel("Sort:"), sortSelect),
+		container.NewGridWithColumns(3, maxPlayersEntry, maxPingEntry, hideFullCheck),
+		container.NewBorder(nil, nil, nil, refreshBtn, statusLabel),
+		widget.NewSeparator(),
+	)
+	footer := container.NewVBox(
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2, widget.NewLabel("Account:"), accountSelect),
+		launchBtn,
+	)
+
+	content := container.NewBorder(controls, footer, nil, nil, serverList)
+	serversDialog := dialog.NewCustom("Servers: "+preset.Name, "Close", content, window)
+	serversDialog.Resize(fyne.NewSize(560, 560))
+	serversDialog.Show()
+
+	loadServers()
+}
+
+// showPartyLaunchDialog picks accounts to launch together into one server
+func showPartyLaunchDialog(window fyne.Window, preset preset_manager.Preset, presetIndex int) {
+	accounts, _ := account_manager.LoadAccounts()
+
+	var options []string
+	var partyAccounts []account_manager.Account
+	for _, acc := range accounts {
+		if !cookie_manager.HasSavedCookie(acc.ID) {
+			continue // Party launches need a ticket for every account
+		}
+		displayText := acc.Username
+		if acc.Label != "" {
+			displayText = fmt.Sprintf("%s (%s)", acc.Label, acc.Username)
+		}
+		options = append(options, displayText)
+		partyAccounts = append(partyAccounts, acc)
+	}
+	if len(partyAccounts) < 2 {
+		dialog.ShowInformation("Party Launch",
+			"Party launch needs at least two accounts with a captured cookie.\n\nCapture cookies in the Accounts tab first.",
+			window)
+		return
+	}
+
+	accountChecks := widget.NewCheckGroup(options, nil)
+	staggerEntry := widget.NewEntry()
+	staggerEntry.SetText("8")
+
+	infoLabel := widget.NewLabel("The first account joins a server; the rest follow it into the same server one at a time.")
+	infoLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(
+		infoLabel,
+		widget.NewSeparator(),
+		container.NewVScroll(accountChecks),
+		container.NewGridWithColumns(2, widget.NewLabel("Seconds between launches:"), staggerEntry),
+	)
+
+	dialog.ShowCustomConfirm("Party Launch: "+preset.Name, "Launch", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		// Keep the list order so the host is predictable
+		var selected []account_manager.Account
+		for i, opt := range options {
+			for _, chosen := range accountChecks.Selected {
+				if chosen == opt {
+					selected = append(selected, partyAccounts[i])
+				}
+			}
+		}
+		if len(selected) == 0 {
+			dialog.ShowInformation("Party Launch", "Select at least one account.", window)
+			return
+		}
+
+		opts := party_launch.Options{}
+		if seconds, err := strconv.Atoi(strings.TrimSpace(staggerEntry.Text)); err == nil && seconds > 0 {
+			opts.Stagger = time.Duration(seconds) * time.Second
+		}
+
+		runPartyLaunch(window, preset, presetIndex, selected, opts)
+	}, window)
+}
+
+// runPartyLaunch runs a party launch in the background with a progress dialog
+func runPartyLaunch(window fyne.Window, preset preset_manager.Preset, presetIndex int, accounts []account_manager.Account, opts party_launch.Options) {
+	statusLabels := make([]*widget.Label, len(accounts))
+	rows := container.NewVBox()
+	for i, acc := range accounts {
+		statusLabels[i] = widget.NewLabel("⏳ waiting")
+		rows.Add(container.NewBorder(nil, nil, widget.NewLabel(acc.Username), statusLabels[i]))
+	}
+	progressBar := widget.NewProgressBar()
+	serverLabel := widget.NewLabel("Server: finding...")
+
+	progressDialog := dialog.NewCustomWithoutButtons("Party Launch: "+preset.Name,
+		container.NewVBox(progressBar, serverLabel, widget.NewSeparator(), rows), window)
+	progressDialog.Show()
+
+	finished := make(map[int]bool)
+	go func() {
+		result, err := party_launch.Launch(preset, accounts, opts, func(p party_launch.Progress) {
+			icon := map[party_launch.Stage]string{
+				party_launch.StageWaiting:    "⏳",
+				party_launch.StageLaunching:  "🚀",
+				party_launch.StageWaitingJob: "🔎",
+				party_launch.StageLaunched:   "✅",
+				party_launch.StageFailed:     "❌",
+			}[p.Stage]
+			text := fmt.Sprintf("%s %s", icon, p.Stage)
+			if p.Err != nil {
+				text = fmt.Sprintf("%s %v", icon, p.Err)
+			}
+			statusLabels[p.Index].SetText(text)
+
+			if p.Stage == party_launch.StageFailed || p.Stage == party_launch.StageLaunched {
+				finished[p.Index] = true
+			}
+			progressBar.SetValue(float64(len(finished)) / float64(p.Total))
+			if p.JobID != "" {
+				serverLabel.SetText("Server: " + p.JobID)
+			}
+		})
+		progressDialog.Hide()
+
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("Party launch failed: %v", err), window)
+			return
+		}
+		preset_manager.UpdatePresetLastAccount(presetIndex, result.Host.ID)
+
+		summary := fmt.Sprintf("Launched %d of %d accounts", len(accounts)-len(result.Failed()), len(accounts))
+		if result.JobID != "" {
+			summary += fmt.Sprintf(" into server %s.", result.JobID[:min(8, len(result.JobID))])
+		} else {
+			summary += ".\nThe host's server couldn't be found, so the others followed them instead."
+		}
+		for _, failed := range result.Failed() {
+			summary += fmt.Sprintf("\n\n❌ %s: %v", failed.Account.Username, failed.Err)
+		}
+		dialog.ShowInformation("Party Launch", summary, window)
+	}()
+}
+
 // showAccountSelectionForPreset shows account selection for preset launch with cookie switching
 func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Preset, presetIndex int, launchCallback func()) {
 	logger.LogInfo("showAccountSelectionForPreset called for preset: %s (index: %d)", preset.Name, presetIndex)
@@ -1686,7 +2833,9 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 	accounts, err := account_manager.LoadAccounts()
 	if err != nil || len(accounts) == 0 {
 		logger.LogInfo("No accounts found, launching without account selection")
-		preset_manager.LaunchPreset(preset)
+		if err := launchPresetVerified(preset, nil); err != nil {
+			logger.LogError("Failed to launch preset: %v", err)
+		}
 		launchCallback()
 		return
 	}
@@ -1791,60 +2940,43 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 			logger.LogInfo("Switching to account: %s using cookie", account.Username)
 
 			// Pre-launch cookie check with auto-refresh
-			cookieValue, err := cookie_manager.PreLaunchCookieCheck(account.ID, account.Username)
-			if err != nil {
+			if _, err := cookie_manager.PreLaunchCookieCheck(account); err != nil {
 				logger.LogError("Pre-launch cookie check failed: %v", err)
 				dialog.ShowError(fmt.Errorf("Cookie issue for %s:\n%v\n\nGo to Accounts tab to recapture.", account.Username, err), window)
 				return
 			}
 
-			// Create a temporary cookie struct for compatibility
-			cookie := &cookie_manager.RobloxCookie{Value: cookieValue}
-
-			// Check if Vivaldi is running
-			if isVivaldiRunning() {
-				dialog.ShowConfirm("Close Vivaldi",
-					"Vivaldi must be closed to switch accounts.\n\nClose Vivaldi and switch to "+account.Username+"?",
+			// Check if the capture browser is running
+			if cookie_manager.IsBrowserRunning() {
+				browserName := cookie_manager.GetSelectedBrowserName()
+				dialog.ShowConfirm("Close "+browserName,
+					browserName+" must be closed to switch accounts.\n\nClose "+browserName+" and switch to "+account.Username+"?",
 					func(yes bool) {
 						if yes {
-							// Close Vivaldi
-							exec.Command("pkill", "-x", "Vivaldi").Run()
-							time.Sleep(500 * time.Millisecond)
-
-							// Get auth ticket for the selected account
-							// Note: We don't clear Roblox cache anymore - auth ticket is independent
-							// This allows multi-instance to work properly without closing other windows
-							logger.LogInfo("Getting auth ticket for: %s", account.Username)
-
-							// Get auth ticket directly from cookie (no cache clearing needed)
-							authTicket, err := cookie_manager.GetAuthTicket(cookie.Value)
-							if err != nil {
-								logger.LogError("Failed to get auth ticket: %v", err)
-								dialog.ShowError(fmt.Errorf("Failed to get auth ticket: %v\n\nThe cookie may have expired. Try recapturing it.", err), window)
-								return
+							// Close the browser
+							if err := cookie_manager.QuitSelectedBrowser(); err != nil {
+								logger.LogError("Failed to close browser: %v", err)
 							}
 
-							logger.LogInfo("Got auth ticket, launching as: %s", account.Username)
-
 							// Step 4: Check if private server was selected
 							usePrivateServer := serverTypeSelect != nil && serverTypeSelect.Selected == "Private Server"
 
 							if usePrivateServer && preset.PrivateServerLinkCode != "" {
 								// Check if browser is logged in as the correct account
-								browserUsername, _ := cookie_manager.GetCurrentBrowserCookieUsername()
+								browserUser, _ := cookie_manager.GetCurrentBrowserCookieUser()
 
-								if browserUsername != "" && !strings.EqualFold(browserUsername, account.Username) {
+								if browserUser != nil && !cookie_manager.UserMatchesAccount(browserUser, account) {
 									// Browser is logged in as different account - offer to clear
 									dialog.ShowConfirm("Account Mismatch",
 										fmt.Sprintf("Browser is logged in as: %s\nYou selected: %s\n\nClear browser session to log in as %s?",
-											browserUsername, account.Username, account.Username),
+											browserUser.Username, account.Username, account.Username),
 										func(clearSession bool) {
 											if clearSession {
 												// Save current browser cookie before clearing
 												saveBrowserCookieBeforeClear()
-												// Clear Vivaldi cookies for Roblox
-												cookie_manager.ClearVivaldiRobloxCookies()
-												logger.LogInfo("Cleared Vivaldi Roblox cookies for account switch")
+												// Clear browser cookies for Roblox
+												cookie_manager.ClearBrowserRobloxCookies()
+												logger.LogInfo("Cleared browser Roblox cookies for account switch")
 											}
 
 											// Open private server via browser
@@ -1874,7 +3006,7 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 								customDialog.Hide()
 								launchCallback()
 
-								if browserUsername == "" {
+								if browserUser == nil {
 									dialog.ShowInformation("Private Server",
 										fmt.Sprintf("Opening private server!\n\nPlease log in as %s when the page loads.", account.Username),
 										window)
@@ -1884,69 +3016,44 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 										window)
 								}
 							} else {
-								// Launch public server directly with auth ticket
+								// Launch public server with an auth ticket
 								// Clear private server link temporarily for public launch
 								tempPreset := preset
 								tempPreset.PrivateServerLinkCode = "" // Force public server
 
-								pid, err := preset_manager.LaunchPresetWithTicket(tempPreset, authTicket)
-								if err != nil {
-									logger.LogError("Failed to launch preset: %v", err)
-									dialog.ShowError(fmt.Errorf("Failed to launch: %v", err), window)
-									return
-								}
-
-								// Track which account this instance belongs to
-								if pid > 0 {
-									instance_account_tracker.TrackInstance(pid, account.ID)
-									logger.LogInfo("Tracked instance PID %d with account %s", pid, account.Username)
-								}
-
-								preset_manager.UpdatePresetLastAccount(presetIndex, account.ID)
-
+								queueLaunch(window, launcher.Request{Account: account, Preset: tempPreset}, func(launcher.JobState) {
+									preset_manager.UpdatePresetLastAccount(presetIndex, account.ID)
+									launchCallback()
+								})
 								customDialog.Hide()
-								launchCallback()
 
 								dialog.ShowInformation("Account Switched",
-									fmt.Sprintf("Switched to %s and launching game!\n\nRoblox will open with this account.", account.Username),
+									fmt.Sprintf("Switched to %s and queued the launch!\n\nRoblox will open with this account. Progress is shown on the Instances tab.", account.Username),
 									window)
 							}
 						}
 					}, window)
 			} else {
-				// Vivaldi not running - get auth ticket directly
-				// Note: Auth ticket is independent, no cache clearing needed
-				// This allows multi-instance to work properly
-				logger.LogInfo("Getting auth ticket for: %s", account.Username)
-
-				// Get auth ticket directly from cookie
-				authTicket, err := cookie_manager.GetAuthTicket(cookie.Value)
-				if err != nil {
-					logger.LogError("Failed to get auth ticket: %v", err)
-					dialog.ShowError(fmt.Errorf("Failed to get auth ticket: %v\n\nThe cookie may have expired. Try recapturing it.", err), window)
-					return
-				}
-
-				logger.LogInfo("Got auth ticket, launching as: %s", account.Username)
-
+				// Browser not running - launches use auth tickets, which don't
+				// touch the browser session
 				// Step 4: Check if private server was selected
 				usePrivateServer := serverTypeSelect != nil && serverTypeSelect.Selected == "Private Server"
 
 				if usePrivateServer && preset.PrivateServerLinkCode != "" {
 					// Check if browser is logged in as the correct account
-					browserUsername, _ := cookie_manager.GetCurrentBrowserCookieUsername()
+					browserUser, _ := cookie_manager.GetCurrentBrowserCookieUser()
 
-					if browserUsername != "" && !strings.EqualFold(browserUsername, account.Username) {
+					if browserUser != nil && !cookie_manager.UserMatchesAccount(browserUser, account) {
 						// Browser is logged in as different account - offer to clear
 						dialog.ShowConfirm("Account Mismatch",
 							fmt.Sprintf("Browser is logged in as: %s\nYou selected: %s\n\nClear browser session to log in as %s?",
-								browserUsername, account.Username, account.Username),
+								browserUser.Username, account.Username, account.Username),
 							func(clearSession bool) {
 								if clearSession {
 									// Save current browser cookie before clearing
 									saveBrowserCookieBeforeClear()
-									cookie_manager.ClearVivaldiRobloxCookies()
-									logger.LogInfo("Cleared Vivaldi Roblox cookies for account switch")
+									cookie_manager.ClearBrowserRobloxCookies()
+									logger.LogInfo("Cleared browser Roblox cookies for account switch")
 								}
 
 								shareURL := fmt.Sprintf("https://www.roblox.com/share?code=%s&type=Server", preset.PrivateServerLinkCode)
@@ -1973,7 +3080,7 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 					customDialog.Hide()
 					launchCallback()
 
-					if browserUsername == "" {
+					if browserUser == nil {
 						dialog.ShowInformation("Private Server",
 							fmt.Sprintf("Opening private server!\n\nPlease log in as %s.", account.Username),
 							window)
@@ -1983,30 +3090,18 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 							window)
 					}
 				} else {
-					// Launch public server directly with auth ticket
+					// Launch public server with an auth ticket
 					tempPreset := preset
 					tempPreset.PrivateServerLinkCode = "" // Force public server
 
-					pid, err := preset_manager.LaunchPresetWithTicket(tempPreset, authTicket)
-					if err != nil {
-						logger.LogError("Failed to launch preset: %v", err)
-						dialog.ShowError(fmt.Errorf("Failed to launch: %v", err), window)
-						return
-					}
-
-					// Track which account this instance belongs to
-					if pid > 0 {
-						instance_account_tracker.TrackInstance(pid, account.ID)
-						logger.LogInfo("Tracked instance PID %d with account %s", pid, account.Username)
-					}
-
-					preset_manager.UpdatePresetLastAccount(presetIndex, account.ID)
-
+					queueLaunch(window, launcher.Request{Account: account, Preset: tempPreset}, func(launcher.JobState) {
+						preset_manager.UpdatePresetLastAccount(presetIndex, account.ID)
+						launchCallback()
+					})
 					customDialog.Hide()
-					launchCallback()
 
 					dialog.ShowInformation("Account Switched",
-						fmt.Sprintf("Switched to %s and launching game!", account.Username),
+						fmt.Sprintf("Switched to %s and queued the launch!\n\nProgress is shown on the Instances tab.", account.Username),
 						window)
 				}
 			}
@@ -2055,7 +3150,7 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 			// Force public server by clearing private server code
 			tempPreset := preset
 			tempPreset.PrivateServerLinkCode = ""
-			if err := preset_manager.LaunchPreset(tempPreset); err != nil {
+			if err := launchPresetVerified(tempPreset, nil); err != nil {
 				logger.LogError("Failed to launch preset: %v", err)
 			}
 		} else if selectedIndex > 0 {
@@ -2067,7 +3162,7 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 			// Force public server by clearing private server code
 			tempPreset := preset
 			tempPreset.PrivateServerLinkCode = ""
-			if err := preset_manager.LaunchPreset(tempPreset); err != nil {
+			if err := launchPresetVerified(tempPreset, &account); err != nil {
 				logger.LogError("Failed to launch preset with account: %v", err)
 			}
 
@@ -2104,13 +3199,6 @@ func showAccountSelectionForPreset(window fyne.Window, preset preset_manager.Pre
 	customDialog.Show()
 }
 
-// isVivaldiRunning checks if Vivaldi browser is running
-func isVivaldiRunning() bool {
-	cmd := exec.Command("pgrep", "-x", "Vivaldi")
-	err := cmd.Run()
-	return err == nil
-}
-
 // showAccountSwitchWorkflow guides user through switching Roblox account
 func showAccountSwitchWorkflow(window fyne.Window, account account_manager.Account, preset preset_manager.Preset, presetIndex int, launchCallback func()) {
 	logger.LogInfo("Starting account switch workflow for: %s", account.Username)
@@ -2153,7 +3241,7 @@ Make sure you're logged into the correct account before launching.`, displayName
 	launchGameBtn := widget.NewButton("Launch Game", func() {
 		logger.LogInfo("Launching preset after account switch: %s with account %s", preset.Name, account.Username)
 
-		if err := preset_manager.LaunchPreset(preset); err != nil {
+		if err := launchPresetVerified(preset, &account); err != nil {
 			logger.LogError("Failed to launch preset: %v", err)
 			dialog.ShowError(err, window)
 		}